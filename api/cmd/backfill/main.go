@@ -0,0 +1,84 @@
+// Command backfill completes lesson answers stored before answers carried the
+// expected word, song id, line index and timestamp, and songs stored before
+// they carried a line count and flattened lyrics for search. It is safe to run
+// more than once: documents that already have the fields are left untouched.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.tomerab1/todo-api/internal/config"
+	"github.tomerab1/todo-api/internal/repositories"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only report how many lessons and songs need a backfill")
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	_ = godotenv.Load()
+
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.Getenv)
+	if err != nil {
+		logger.Error("invalid configuration", "err", err)
+		os.Exit(2)
+	}
+
+	client, err := mongo.Connect(options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		logger.Error("failed to connect", "err", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	defer client.Disconnect(context.Background())
+
+	db := client.Database(cfg.Database)
+	lessonRepo := repositories.NewLessonRepo(
+		db.Collection("lessons"),
+		slog.New(logger.Handler()).With("repo", "lessons"),
+	)
+	songRepo := repositories.NewSongRepoMongo(
+		db.Collection("songs"),
+		slog.New(logger.Handler()).With("repo", "songs"),
+	)
+
+	backfill(ctx, logger, "lessons", *dryRun, lessonRepo.CountAnswersToBackfill, lessonRepo.BackfillAnswers)
+	backfill(ctx, logger, "songs", *dryRun, songRepo.CountSearchFieldsToBackfill, songRepo.BackfillSearchFields)
+}
+
+// backfill counts the documents of one collection needing a backfill and,
+// unless dryRun, updates them. Failures end the command.
+func backfill(
+	ctx context.Context,
+	logger *slog.Logger,
+	name string,
+	dryRun bool,
+	count func(context.Context) (int64, error),
+	run func(context.Context) (int64, error),
+) {
+	pending, err := count(ctx)
+	if err != nil {
+		logger.Error("failed to count "+name, "err", err)
+		os.Exit(1)
+	}
+	logger.Info(name+" to backfill", "count", pending)
+	if dryRun || pending == 0 {
+		return
+	}
+
+	updated, err := run(ctx)
+	if err != nil {
+		logger.Error(name+" backfill failed", "err", err)
+		os.Exit(1)
+	}
+	logger.Info(name+" backfill done", "updated", updated)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log/slog"
 	"net/http"
 	"os"
@@ -11,50 +12,70 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/config"
 	"github.tomerab1/todo-api/internal/httpserver"
+	"github.tomerab1/todo-api/internal/telemetry"
+	"go.opentelemetry.io/otel"
 )
 
-func getenv(k, def string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
-	}
-	return def
-}
-
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	logger := slog.New(telemetry.NewContextHandler(
+		slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+	))
 
 	_ = godotenv.Load()
 
-	mongoURI := getenv("MONGODB_URI", "")
-	if mongoURI == "" {
-		logger.Warn("MONGODB_URI is empty")
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.Getenv)
+	if err != nil {
+		logger.Error("invalid configuration", "err", err)
+		os.Exit(2)
+	}
+	for _, w := range cfg.Warnings {
+		logger.Warn(w)
+	}
+
+	var tracer *telemetry.Tracer
+	if cfg.OTLPEndpoint != "" {
+		exporter, err := telemetry.NewOTLPExporter(context.Background(), cfg.OTLPEndpoint)
+		if err != nil {
+			logger.Error("failed to create trace exporter", "err", err)
+			os.Exit(1)
+		}
+		tracingLogger := slog.New(logger.Handler()).With("component", "tracing")
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			tracingLogger.Warn("export spans failed", "err", err)
+		}))
+		tracer = telemetry.NewTracer(exporter, cfg.ServiceName)
+		logger.Info("tracing enabled", "endpoint", cfg.OTLPEndpoint)
 	}
 
-	port := getenv("PORT", "8080")
-	addr := ":" + port
+	metrics := telemetry.NewMetrics()
 
-	app, err := app.New(logger, mongoURI)
+	app, err := app.New(logger, cfg, tracer, metrics)
 	if err != nil {
 		logger.Error("failed to create app", "err", err)
 		os.Exit(1)
 	}
 
-	handler := httpserver.New(app)
+	sweepCtx, stopSweeper := context.WithCancel(context.Background())
+	defer stopSweeper()
+	go app.LessonSvc.RunExpirySweeper(sweepCtx, cfg.LessonTTL, cfg.SweepInterval)
+
+	handler := httpserver.New(app, cfg)
 
 	mux := http.NewServeMux()
 	mux.Handle("/", handler)
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
+	mux.Handle("/healthz", httpserver.Liveness())
+	mux.Handle("/readyz", httpserver.Readiness(app))
+	mux.Handle("/metrics", metrics.Handler())
 
 	srv := &http.Server{
-		Addr:              addr,
+		Addr:              cfg.Addr,
 		Handler:           mux,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
-	logger.Info("server starting", "addr", addr)
+	logger.Info("server starting", "addr", cfg.Addr)
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -67,11 +88,18 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("shutting down")
+	stopSweeper()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error("server shutdown failed", "err", err)
 	}
+	if err := app.Close(ctx); err != nil {
+		logger.Error("database disconnect failed", "err", err)
+	}
+	if err := tracer.Shutdown(ctx); err != nil {
+		logger.Error("tracer shutdown failed", "err", err)
+	}
 	logger.Info("server stopped")
 }
@@ -1,50 +1,206 @@
 package app
 
 import (
+	"context"
 	"log/slog"
+	"net/netip"
+	"time"
 
+	"github.tomerab1/todo-api/internal/config"
 	"github.tomerab1/todo-api/internal/repositories"
 	"github.tomerab1/todo-api/internal/services"
+	"github.tomerab1/todo-api/internal/telemetry"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
 )
 
 type Application struct {
-	db        *mongo.Client
-	logger    *slog.Logger
-	UserSvc   *services.UserService
-	SongSvc   *services.SongService
-	LessonSvc *services.LessonService
+	db     *mongo.Client
+	logger *slog.Logger
+	// Tracer records request and database spans; nil disables tracing.
+	Tracer *telemetry.Tracer
+	// Metrics counts requests, database commands and lesson activity; nil
+	// disables them.
+	Metrics        *telemetry.Metrics
+	UserSvc        *services.UserService
+	SongSvc        *services.SongService
+	CollectionSvc  *services.CollectionService
+	CourseSvc      *services.CourseService
+	LessonSvc      *services.LessonService
+	StatsSvc       *services.StatsService
+	ProgressSvc    *services.ProgressService
+	LeaderboardSvc *services.LeaderboardService
+	ChallengeSvc   *services.ChallengeService
+	ClassroomSvc   *services.ClassroomService
+	IdempotencySvc *services.IdempotencyService
+	// RateLimitSvc limits clients per route group; nil disables limiting.
+	RateLimitSvc *services.RateLimitService
+	// Checks are the dependencies /readyz reports on.
+	Checks []Check
+	// AdminToken unlocks admin-only request options when sent as the
+	// X-Admin-Token header. Empty disables them.
+	AdminToken string
+	// TrustedProxies are the proxies whose forwarding headers name the
+	// client address. Empty trusts none and uses the connection's peer.
+	TrustedProxies []netip.Prefix
 }
 
-func New(logger *slog.Logger, dbConnString string) (*Application, error) {
-	dbConn, err := mongo.Connect(options.Client().ApplyURI(dbConnString))
+// Repos are the stores the services run on. New fills it with MongoDB
+// repositories; tests fill it with in-memory ones.
+type Repos struct {
+	Users       repositories.UserRepoIface
+	Songs       repositories.SongRepoIface
+	Collections repositories.CollectionRepoIface
+	Courses     repositories.CourseRepoIface
+	Enrollments repositories.EnrollmentRepoIface
+	Lessons     repositories.LessonRepoIface
+	Mastery     repositories.WordMasteryRepoIface
+	Progress    repositories.ProgressRepoIface
+	Boards      repositories.LeaderboardRepoIface
+	Groups      repositories.GroupRepoIface
+	Assignments repositories.AssignmentRepoIface
+	Challenges  repositories.ChallengeRepoIface
+	Idempotency repositories.IdempotencyRepoIface
+	// RateLimits may be nil, which disables rate limiting.
+	RateLimits repositories.RateLimitRepoIface
+}
+
+func New(logger *slog.Logger, cfg *config.Config, tracer *telemetry.Tracer, metrics *telemetry.Metrics) (*Application, error) {
+	dbConn, err := mongo.Connect(options.Client().ApplyURI(cfg.MongoURI).SetMonitor(telemetry.MongoMonitor(tracer, metrics)))
 	if err != nil {
 		return nil, err
 	}
+	startCtx, cancelStart := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancelStart()
+	if err := waitForDB(startCtx, dbConn, logger); err != nil {
+		dbConn.Disconnect(context.Background())
+		return nil, err
+	}
+	db := dbConn.Database(cfg.Database)
 
-	userRepoLogger := slog.New(logger.Handler()).With("repo", "user")
-	userSvcLogger := slog.New(logger.Handler()).With("service", "user")
-
-	songRepoLogger := slog.New(logger.Handler()).With("repo", "songs")
-	songSvcLogger := slog.New(logger.Handler()).With("service", "songs")
+	repoLogger := func(name string) *slog.Logger {
+		return slog.New(logger.Handler()).With("repo", name)
+	}
+	repos := Repos{
+		Users:       repositories.NewUserRepoMongo(db.Collection("users"), repoLogger("user")),
+		Songs:       repositories.NewSongRepoMongo(db.Collection("songs"), repoLogger("songs")),
+		Collections: repositories.NewCollectionRepoMongo(db.Collection("collections"), repoLogger("collections")),
+		Courses:     repositories.NewCourseRepoMongo(db.Collection("courses"), repoLogger("courses")),
+		Enrollments: repositories.NewEnrollmentRepoMongo(db.Collection("enrollments"), repoLogger("enrollments")),
+		Lessons:     repositories.NewLessonRepo(db.Collection("lessons"), repoLogger("lessons")),
+		Mastery:     repositories.NewWordMasteryRepoMongo(db.Collection("word_mastery"), repoLogger("word_mastery")),
+		Progress:    repositories.NewProgressRepoMongo(db.Collection("progress"), repoLogger("progress")),
+		Boards:      repositories.NewLeaderboardRepoMongo(db.Collection("leaderboards"), repoLogger("leaderboards")),
+		Groups:      repositories.NewGroupRepoMongo(db.Collection("groups"), repoLogger("groups")),
+		Assignments: repositories.NewAssignmentRepoMongo(
+			db.Collection("assignments"),
+			db.Collection("assignment_results"),
+			repoLogger("assignments"),
+		),
+		Challenges:  repositories.NewChallengeRepoMongo(db.Collection("challenges"), repoLogger("challenges")),
+		Idempotency: repositories.NewIdempotencyRepoMongo(db.Collection("idempotency_keys"), repoLogger("idempotency")),
+		RateLimits:  repositories.NewRateLimitRepoMemory(),
+	}
+	if cfg.RateLimitStore == "mongodb" {
+		repos.RateLimits = repositories.NewRateLimitRepoMongo(db.Collection("rate_limits"), repoLogger("rate_limits"))
+	}
 
-	lessonsRepoLogger := slog.New(logger.Handler()).With("repo", "lessons")
-	lessonsSvcLogger := slog.New(logger.Handler()).With("service", "lessons")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := repos.Users.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure user indexes", "err", err)
+	}
+	if err := repos.Songs.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure song indexes", "err", err)
+	}
+	if err := repos.Enrollments.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure enrollment indexes", "err", err)
+	}
+	if err := repos.Lessons.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure lesson indexes", "err", err)
+	}
+	if err := repos.Mastery.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure word mastery indexes", "err", err)
+	}
+	if err := repos.Boards.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure leaderboard indexes", "err", err)
+	}
+	if err := repos.Assignments.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure assignment indexes", "err", err)
+	}
+	if err := repos.Challenges.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure challenge indexes", "err", err)
+	}
+	if err := repos.Idempotency.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure idempotency indexes", "err", err)
+	}
+	if err := repos.RateLimits.EnsureIndexes(ctx); err != nil {
+		logger.Warn("failed to ensure rate limit indexes", "err", err)
+	}
 
-	userRepo := repositories.NewUserRepoMongo(dbConn.Database("lyrics-app").Collection("users"), userRepoLogger)
-	userSvc := services.NewUserService(userRepo, userSvcLogger)
+	a := Wire(logger, cfg, repos, metrics)
+	a.db = dbConn
+	a.Tracer = tracer
+	a.Checks = []Check{{
+		Name: "mongodb",
+		Run: func(ctx context.Context) error {
+			return dbConn.Ping(ctx, readpref.Primary())
+		},
+	}}
+	return a, nil
+}
 
-	songsRepo := repositories.NewSongRepoMongo(dbConn.Database("lyrics-app").Collection("songs"), songRepoLogger)
-	songsSvc := services.NewSongService(songsRepo, songSvcLogger)
+// Wire builds the services on top of repos. It connects to nothing, so tests
+// use it to run the real services on in-memory repositories.
+func Wire(logger *slog.Logger, cfg *config.Config, repos Repos, metrics *telemetry.Metrics) *Application {
+	svcLogger := func(name string) *slog.Logger {
+		return slog.New(logger.Handler()).With("service", name)
+	}
 
-	lessonRepo := repositories.NewLessonRepo(dbConn.Database("lyrics-app").Collection("lessons"), lessonsRepoLogger)
-	lessonSvc := services.NewLessonService(userRepo, songsRepo, lessonRepo, lessonsSvcLogger)
+	a := &Application{
+		logger:        logger,
+		Metrics:       metrics,
+		UserSvc:       services.NewUserService(repos.Users, svcLogger("user")),
+		SongSvc:       services.NewSongService(repos.Songs, svcLogger("songs")),
+		CollectionSvc: services.NewCollectionService(repos.Collections, repos.Songs, svcLogger("collections")),
+		CourseSvc: services.NewCourseService(
+			repos.Courses, repos.Enrollments, repos.Songs, repos.Users, svcLogger("courses"),
+		),
+		LessonSvc: services.NewLessonService(
+			repos.Users, repos.Songs, repos.Lessons, repos.Collections, repos.Courses, repos.Enrollments,
+			repos.Mastery, repos.Progress, repos.Boards, repos.Assignments, repos.Challenges, svcLogger("lessons"),
+		).
+			WithLessonSize(cfg.LessonSize).
+			WithMetrics(metrics),
+		StatsSvc: services.NewStatsService(
+			repos.Users, repos.Songs, repos.Lessons, repos.Mastery, svcLogger("stats"),
+		),
+		ProgressSvc:    services.NewProgressService(repos.Users, repos.Progress, svcLogger("progress")),
+		LeaderboardSvc: services.NewLeaderboardService(repos.Users, repos.Songs, repos.Boards, svcLogger("leaderboards")),
+		ClassroomSvc: services.NewClassroomService(
+			repos.Groups, repos.Assignments, repos.Users, repos.Songs, svcLogger("classrooms"),
+		),
+		ChallengeSvc: services.NewChallengeService(
+			repos.Challenges, repos.Users, repos.Songs, svcLogger("challenges"),
+		).WithLessonSize(cfg.LessonSize),
+		IdempotencySvc: services.NewIdempotencyService(repos.Idempotency, svcLogger("idempotency")).
+			WithTTL(cfg.IdempotencyTTL).
+			WithLease(cfg.RequestTimeout),
+		AdminToken:     cfg.AdminToken,
+		TrustedProxies: cfg.TrustedProxies,
+	}
+	if repos.RateLimits != nil {
+		a.RateLimitSvc = services.NewRateLimitService(repos.RateLimits, cfg.RateLimits, svcLogger("rate_limits"))
+	}
+	return a
+}
 
-	return &Application{
-		db:        dbConn,
-		UserSvc:   userSvc,
-		SongSvc:   songsSvc,
-		LessonSvc: lessonSvc,
-	}, nil
+// Logger returns the application logger, or the default logger for an
+// Application built without New, as in tests.
+func (a *Application) Logger() *slog.Logger {
+	if a.logger == nil {
+		return slog.Default()
+	}
+	return a.logger
 }
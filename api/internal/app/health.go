@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+)
+
+// Check probes one dependency the application cannot serve without.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+const (
+	pingTimeout = 5 * time.Second
+	maxBackoff  = 8 * time.Second
+)
+
+// waitForDB pings the database until it answers, backing off between
+// attempts, and gives up when ctx ends. mongo.Connect alone does not reach
+// the server, so without this a wrong URI only shows on the first request.
+func waitForDB(ctx context.Context, db *mongo.Client, logger *slog.Logger) error {
+	backoff := 500 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err := db.Ping(pingCtx, readpref.Primary())
+		cancel()
+		if err == nil {
+			return nil
+		}
+		logger.Warn("database unreachable, retrying", "attempt", attempt, "in", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// Close disconnects from the database.
+func (a *Application) Close(ctx context.Context) error {
+	return a.db.Disconnect(ctx)
+}
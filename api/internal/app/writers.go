@@ -3,6 +3,8 @@ package app
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.tomerab1/todo-api/internal/contracts"
 )
 
 func (a *Application) WriteJSON(
@@ -16,13 +18,27 @@ func (a *Application) WriteJSON(
 	})
 }
 
+// WritePage writes one page of a list along with its pagination meta.
+func (a *Application) WritePage(
+	w http.ResponseWriter,
+	status int,
+	data any,
+	meta contracts.Meta,
+) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"data": data,
+		"meta": meta,
+	})
+}
+
 func (a *Application) WriteErrorJSON(
 	w http.ResponseWriter,
 	status int,
-	errMsg string,
+	body contracts.ErrorResponse,
 ) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(map[string]any{
-		"error": errMsg,
+		"error": body,
 	})
 }
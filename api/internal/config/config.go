@@ -0,0 +1,324 @@
+// Package config loads the server settings from defaults, an optional JSON
+// config file, the environment and command-line flags, in increasing order
+// of precedence, and validates them.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+)
+
+type Config struct {
+	Addr           string        // SERVER_ADDR
+	MongoURI       string        // MONGODB_URI
+	Database       string        // MONGODB_DATABASE
+	ConnectTimeout time.Duration // MONGODB_CONNECT_TIMEOUT: how long startup waits for the database
+	RequestTimeout time.Duration // REQUEST_TIMEOUT
+	CORSOrigins    []string      // CORS_ALLOWED_ORIGINS, comma separated
+	AdminToken     string        // ADMIN_TOKEN: empty disables admin-only options
+	LessonSize     int           // LESSON_SIZE: items per lesson
+	LessonTTL      time.Duration // LESSON_TTL
+	SweepInterval  time.Duration // LESSON_SWEEP_INTERVAL
+	IdempotencyTTL time.Duration // IDEMPOTENCY_TTL
+	OTLPEndpoint   string        // OTEL_EXPORTER_OTLP_ENDPOINT: empty disables tracing
+	ServiceName    string        // OTEL_SERVICE_NAME
+	// RateLimits holds the limit of each route group (RATE_LIMITS), applied
+	// per client IP.
+	RateLimits     map[string]models.RateLimit
+	RateLimitStore string // RATE_LIMIT_STORE: "memory" or "mongodb"
+	// TrustedProxies are the proxies (TRUSTED_PROXIES) whose
+	// X-Forwarded-For and Forwarded headers name the client address.
+	TrustedProxies []netip.Prefix
+
+	// Warnings lists deprecated settings that were honoured anyway.
+	Warnings []string
+}
+
+// setting ties a Config field to its name in the config file and the
+// environment and to its command-line flag.
+type setting struct {
+	name  string // env var and config file key
+	flag  string
+	def   string
+	usage string
+	set   func(c *Config, v string) error
+}
+
+var settings = []setting{
+	{"SERVER_ADDR", "addr", ":8080", "address to listen on",
+		func(c *Config, v string) error { c.Addr = v; return nil }},
+	{"MONGODB_URI", "mongodb-uri", "", "MongoDB connection string",
+		func(c *Config, v string) error { c.MongoURI = v; return nil }},
+	{"MONGODB_DATABASE", "mongodb-database", "lyrics-app", "MongoDB database name",
+		func(c *Config, v string) error { c.Database = v; return nil }},
+	{"MONGODB_CONNECT_TIMEOUT", "mongodb-connect-timeout", "30s", "how long startup retries an unreachable database",
+		durationField(func(c *Config) *time.Duration { return &c.ConnectTimeout })},
+	{"REQUEST_TIMEOUT", "request-timeout", "5s", "time limit of a request",
+		durationField(func(c *Config) *time.Duration { return &c.RequestTimeout })},
+	{"CORS_ALLOWED_ORIGINS", "cors-allowed-origins", "*", "comma-separated origins allowed by CORS, * for any",
+		func(c *Config, v string) error { c.CORSOrigins = splitList(v); return nil }},
+	{"ADMIN_TOKEN", "admin-token", "", "token unlocking admin-only request options; empty disables them",
+		func(c *Config, v string) error { c.AdminToken = v; return nil }},
+	{"LESSON_SIZE", "lesson-size", "6", "number of items per lesson",
+		func(c *Config, v string) (err error) { c.LessonSize, err = strconv.Atoi(v); return err }},
+	{"LESSON_TTL", "lesson-ttl", "24h", "idle time after which open lessons expire",
+		durationField(func(c *Config) *time.Duration { return &c.LessonTTL })},
+	{"LESSON_SWEEP_INTERVAL", "lesson-sweep-interval", "5m", "how often stale lessons are expired",
+		durationField(func(c *Config) *time.Duration { return &c.SweepInterval })},
+	{"IDEMPOTENCY_TTL", "idempotency-ttl", "24h", "how long Idempotency-Key replies are kept",
+		durationField(func(c *Config) *time.Duration { return &c.IdempotencyTTL })},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", "otlp-endpoint", "", "OpenTelemetry collector to send traces to; empty disables tracing",
+		func(c *Config, v string) error { c.OTLPEndpoint = v; return nil }},
+	{"OTEL_SERVICE_NAME", "service-name", "lyrics-api", "service name reported in traces",
+		func(c *Config, v string) error { c.ServiceName = v; return nil }},
+	{"RATE_LIMITS", "rate-limits", "api=20/s:40,lessons=1/s:5,answers=5/s:20",
+		"per route group limits as group=requests/unit:burst, comma separated, unit s, m or h; off disables them",
+		func(c *Config, v string) (err error) { c.RateLimits, err = parseRateLimits(v); return err }},
+	{"RATE_LIMIT_STORE", "rate-limit-store", "memory", "where rate limit buckets live: memory, or mongodb to share them between instances",
+		func(c *Config, v string) error { c.RateLimitStore = v; return nil }},
+	{"TRUSTED_PROXIES", "trusted-proxies", "", "comma-separated proxy IPs or CIDR ranges whose X-Forwarded-For and Forwarded headers are believed; empty trusts none",
+		func(c *Config, v string) (err error) { c.TrustedProxies, err = parsePrefixes(v); return err }},
+}
+
+// RateLimitGroups are the route groups RATE_LIMITS may name.
+var RateLimitGroups = []string{"api", "lessons", "answers"}
+
+// parseRateLimits reads limits such as "api=20/s:40,lessons=10/m:5".
+func parseRateLimits(v string) (map[string]models.RateLimit, error) {
+	limits := make(map[string]models.RateLimit)
+	if v == "off" {
+		return limits, nil
+	}
+	units := map[string]float64{"s": 1, "m": 60, "h": 3600}
+	for _, entry := range splitList(v) {
+		group, spec, ok1 := strings.Cut(entry, "=")
+		rate, burst, ok2 := strings.Cut(spec, ":")
+		count, unit, ok3 := strings.Cut(rate, "/")
+		n, err1 := strconv.ParseFloat(count, 64)
+		b, err2 := strconv.Atoi(burst)
+		if !ok1 || !ok2 || !ok3 || err1 != nil || err2 != nil || units[unit] == 0 {
+			return nil, fmt.Errorf("%q is not group=requests/unit:burst", entry)
+		}
+		limits[strings.TrimSpace(group)] = models.RateLimit{Rate: n / units[unit], Burst: b}
+	}
+	return limits, nil
+}
+
+// parsePrefixes reads a list of IPs and CIDR ranges; an IP stands for a
+// range of one address.
+func parsePrefixes(v string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, entry := range splitList(v) {
+		if p, err := netip.ParsePrefix(entry); err == nil {
+			out = append(out, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP or CIDR range", entry)
+		}
+		addr = addr.Unmap()
+		out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return out, nil
+}
+
+// aliases are deprecated environment variables still read when the setting
+// they replace is not given.
+var aliases = []struct {
+	old, name string
+	convert   func(string) string
+}{
+	{"PORT", "SERVER_ADDR", func(v string) string { return ":" + v }},
+	{"MONGO_ADDR", "MONGODB_URI", func(v string) string { return v }},
+}
+
+func durationField(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) (err error) {
+		*field(c), err = time.ParseDuration(v)
+		return err
+	}
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Default returns the configuration with every setting at its default.
+func Default() *Config {
+	c := &Config{}
+	for _, s := range settings {
+		if err := s.set(c, s.def); err != nil {
+			panic(fmt.Sprintf("config: bad default for %s: %v", s.name, err))
+		}
+	}
+	return c
+}
+
+// Load builds the configuration. It registers a flag per setting, plus
+// -config naming a JSON file of settings keyed by their environment names,
+// on fs and parses args with it; getenv reads the environment. The file can
+// also be named by CONFIG_FILE. Every invalid value is reported in the
+// returned error, not just the first.
+func Load(fs *flag.FlagSet, args []string, getenv func(string) string) (*Config, error) {
+	file := fs.String("config", getenv("CONFIG_FILE"), "JSON config file")
+	flags := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flags[s.name] = fs.String(s.flag, "", fmt.Sprintf("%s (env %s, default %q)", s.usage, s.name, s.def))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(settings))
+	for _, s := range settings {
+		values[s.name] = s.def
+	}
+	if *file != "" {
+		fromFile, err := readFile(*file)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fromFile {
+			values[k] = v
+		}
+	}
+
+	c := &Config{}
+	for _, a := range aliases {
+		if v := getenv(a.old); v != "" && getenv(a.name) == "" {
+			values[a.name] = a.convert(v)
+			c.Warnings = append(c.Warnings, fmt.Sprintf("%s is deprecated, use %s", a.old, a.name))
+		}
+	}
+	for _, s := range settings {
+		if v := getenv(s.name); v != "" {
+			values[s.name] = v
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name {
+				values[s.name] = *flags[s.name]
+			}
+		}
+	})
+
+	var errs []error
+	for _, s := range settings {
+		if err := s.set(c, values[s.name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q: %w", s.name, values[s.name], err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// readFile reads a flat JSON object of settings. Values may be strings,
+// numbers or booleans; unknown keys are errors so that typos do not go
+// unnoticed.
+func readFile(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.name] = true
+	}
+	out := make(map[string]string, len(doc))
+	var errs []error
+	for k, v := range doc {
+		if !known[k] {
+			errs = append(errs, fmt.Errorf("config file %s: unknown setting %s", path, k))
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			out[k] = v
+		case float64, bool:
+			out[k] = fmt.Sprint(v)
+		default:
+			errs = append(errs, fmt.Errorf("config file %s: %s must be a string, number or boolean", path, k))
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// Validate reports every setting that is out of range.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, name, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{name}, args...)...))
+		}
+	}
+
+	_, port, err := net.SplitHostPort(c.Addr)
+	n, perr := strconv.Atoi(port)
+	check(err == nil && perr == nil && n >= 0 && n <= 65535, "SERVER_ADDR", "must be host:port, got %q", c.Addr)
+	check(c.MongoURI != "", "MONGODB_URI", "is required")
+	if c.MongoURI != "" {
+		check(strings.HasPrefix(c.MongoURI, "mongodb://") || strings.HasPrefix(c.MongoURI, "mongodb+srv://"),
+			"MONGODB_URI", "must start with mongodb:// or mongodb+srv://")
+	}
+	check(c.Database != "" && !strings.ContainsAny(c.Database, `/\. "$`), "MONGODB_DATABASE", "must be a valid database name, got %q", c.Database)
+	for _, d := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"MONGODB_CONNECT_TIMEOUT", c.ConnectTimeout},
+		{"REQUEST_TIMEOUT", c.RequestTimeout},
+		{"LESSON_TTL", c.LessonTTL},
+		{"LESSON_SWEEP_INTERVAL", c.SweepInterval},
+		{"IDEMPOTENCY_TTL", c.IdempotencyTTL},
+	} {
+		check(d.value > 0, d.name, "must be positive, got %s", d.value)
+	}
+	check(len(c.CORSOrigins) > 0, "CORS_ALLOWED_ORIGINS", "must list at least one origin")
+	for _, o := range c.CORSOrigins {
+		u, err := url.Parse(o)
+		check(o == "*" || err == nil && u.Scheme != "" && u.Host != "", "CORS_ALLOWED_ORIGINS", "%q is not * or an origin such as https://example.com", o)
+	}
+	check(c.LessonSize >= 2 && c.LessonSize <= 20, "LESSON_SIZE", "must be between 2 and 20, got %d", c.LessonSize)
+	if c.OTLPEndpoint != "" {
+		u, err := url.Parse(c.OTLPEndpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "OTEL_EXPORTER_OTLP_ENDPOINT", "must be an http(s) URL, got %q", c.OTLPEndpoint)
+	}
+	for _, group := range slices.Sorted(maps.Keys(c.RateLimits)) {
+		l := c.RateLimits[group]
+		check(slices.Contains(RateLimitGroups, group), "RATE_LIMITS", "unknown route group %q, want one of %s", group, strings.Join(RateLimitGroups, ", "))
+		check(l.Rate > 0 && l.Burst >= 1, "RATE_LIMITS", "%s needs a positive rate and a burst of at least 1", group)
+	}
+	check(c.RateLimitStore == "memory" || c.RateLimitStore == "mongodb", "RATE_LIMIT_STORE", "must be memory or mongodb, got %q", c.RateLimitStore)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func load(t *testing.T, env map[string]string, args ...string) (*Config, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return Load(fs, args, func(k string) string { return env[k] })
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(file, []byte(`{
+		"MONGODB_URI": "mongodb://file",
+		"REQUEST_TIMEOUT": "7s",
+		"LESSON_SIZE": 8,
+		"SERVER_ADDR": ":1000"
+	}`), 0o600)
+	env := map[string]string{
+		"CONFIG_FILE":     file,
+		"REQUEST_TIMEOUT": "9s",
+		"SERVER_ADDR":     ":2000",
+	}
+
+	c, err := load(t, env, "-addr", ":3000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.MongoURI != "mongodb://file" || c.LessonSize != 8 {
+		t.Errorf("file values not applied: %+v", c)
+	}
+	if c.RequestTimeout != 9*time.Second {
+		t.Errorf("RequestTimeout = %v, want the env value over the file", c.RequestTimeout)
+	}
+	if c.Addr != ":3000" {
+		t.Errorf("Addr = %q, want the flag over env and file", c.Addr)
+	}
+	if c.Database != "lyrics-app" || c.LessonTTL != 24*time.Hour || len(c.CORSOrigins) != 1 || c.CORSOrigins[0] != "*" {
+		t.Errorf("defaults not applied: %+v", c)
+	}
+}
+
+func TestLoadAliases(t *testing.T) {
+	c, err := load(t, map[string]string{"PORT": "5555", "MONGO_ADDR": "mongodb://alias"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Addr != ":5555" || c.MongoURI != "mongodb://alias" || len(c.Warnings) != 2 {
+		t.Errorf("aliases not applied: %+v", c)
+	}
+
+	c, err = load(t, map[string]string{"PORT": "5555", "SERVER_ADDR": ":6000", "MONGODB_URI": "mongodb://new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Addr != ":6000" || len(c.Warnings) != 0 {
+		t.Errorf("alias overrode the current name: %+v", c)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	_, err := load(t, map[string]string{"LESSON_TTL": "soon", "LESSON_SIZE": "1"})
+	if err == nil || !strings.Contains(err.Error(), "LESSON_TTL") {
+		t.Fatalf("got %v, want a LESSON_TTL parse error", err)
+	}
+
+	_, err = load(t, map[string]string{"LESSON_SIZE": "1", "CORS_ALLOWED_ORIGINS": "example.com"}, "-request-timeout", "0s")
+	if err == nil {
+		t.Fatal("invalid config accepted")
+	}
+	for _, name := range []string{"MONGODB_URI", "LESSON_SIZE", "CORS_ALLOWED_ORIGINS", "REQUEST_TIMEOUT"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error does not mention %s: %v", name, err)
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(file, []byte(`{"MONGODB_URL": "mongodb://typo"}`), 0o600)
+	if _, err := load(t, nil, "-config", file); err == nil || !strings.Contains(err.Error(), "unknown setting MONGODB_URL") {
+		t.Errorf("got %v, want an unknown setting error", err)
+	}
+}
+
+func TestDefaultsValidate(t *testing.T) {
+	c := Default()
+	c.MongoURI = "mongodb://localhost:27017"
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadRateLimits(t *testing.T) {
+	c, err := load(t, map[string]string{"MONGODB_URI": "mongodb://x", "RATE_LIMITS": "lessons=30/m:5, answers=2/s:10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.RateLimits["lessons"]; got.Rate != 0.5 || got.Burst != 5 {
+		t.Errorf("lessons = %+v, want 0.5/s burst 5", got)
+	}
+	if _, ok := c.RateLimits["api"]; ok || len(c.RateLimits) != 2 {
+		t.Errorf("limits = %+v, want only the given groups", c.RateLimits)
+	}
+
+	c, err = load(t, map[string]string{"MONGODB_URI": "mongodb://x", "RATE_LIMITS": "off"})
+	if err != nil || len(c.RateLimits) != 0 {
+		t.Errorf("off: got %+v, %v", c, err)
+	}
+
+	for _, bad := range []string{"lessons=5", "lessons=5/d:1", "songs=1/s:1", "lessons=1/s:0"} {
+		if _, err := load(t, map[string]string{"MONGODB_URI": "mongodb://x", "RATE_LIMITS": bad}); err == nil || !strings.Contains(err.Error(), "RATE_LIMITS") {
+			t.Errorf("%q: got %v, want a RATE_LIMITS error", bad, err)
+		}
+	}
+}
+
+func TestLoadTrustedProxies(t *testing.T) {
+	c, err := load(t, map[string]string{"MONGODB_URI": "mongodb://x", "TRUSTED_PROXIES": "10.0.0.0/8, 192.168.1.7,::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.7/32", "::1/128"}
+	if len(c.TrustedProxies) != len(want) {
+		t.Fatalf("proxies = %v, want %v", c.TrustedProxies, want)
+	}
+	for i, p := range c.TrustedProxies {
+		if p.String() != want[i] {
+			t.Errorf("proxy %d = %s, want %s", i, p, want[i])
+		}
+	}
+
+	if _, err := load(t, map[string]string{"MONGODB_URI": "mongodb://x", "TRUSTED_PROXIES": "10.0.0.0/8,proxy.local"}); err == nil || !strings.Contains(err.Error(), "TRUSTED_PROXIES") {
+		t.Errorf("got %v, want a TRUSTED_PROXIES error", err)
+	}
+}
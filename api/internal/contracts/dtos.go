@@ -1,32 +1,117 @@
 package contracts
 
+import "time"
+
 type CreateUserDto struct {
 	Name string `json:"name"`
 }
 
+type CreateUserResponse struct {
+	Id string `json:"id"`
+}
+
 type GetUserResponse struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
+	Id          string               `json:"id"`
+	Name        string               `json:"name"`
+	Memberships []MembershipResponse `json:"memberships,omitempty"`
+}
+
+type MembershipResponse struct {
+	GroupId string `json:"groupId"`
+	Role    string `json:"role"` // "teacher" | "student"
+}
+
+type ListUsersQuery struct {
+	Query  string
+	Sort   string
+	Cursor string
+	Limit  int
 }
 
 type CreateSongDto struct {
-	Title  string `json:"title"`
-	Artist string `json:"artist"`
-	Lyrics string `json:"lyrics"`
+	Title      string   `json:"title"`
+	Artist     string   `json:"artist"`
+	Lyrics     string   `json:"lyrics"`
+	Tags       []string `json:"tags,omitempty"`
+	Genres     []string `json:"genres,omitempty"`
+	Language   string   `json:"language,omitempty"`
+	Difficulty int      `json:"difficulty,omitempty"` // admin override, 1-5
+}
+
+type UpdateSongMetadataDto struct {
+	Tags       []string `json:"tags"`
+	Genres     []string `json:"genres"`
+	Language   string   `json:"language"`
+	Difficulty int      `json:"difficulty"` // admin override, 1-5; 0 falls back to the computed value
 }
 
 type GetSongResponse struct {
-	Id    string `json:"id"`
-	Title string `json:"title"`
+	Id         string   `json:"id"`
+	Title      string   `json:"title"`
+	Tags       []string `json:"tags,omitempty"`
+	Difficulty float64  `json:"difficulty,omitempty"`
+}
+
+type SongMetadataResponse struct {
+	Id                 string   `json:"id"`
+	Tags               []string `json:"tags"`
+	Genres             []string `json:"genres"`
+	Language           string   `json:"language"`
+	AdminDifficulty    int      `json:"adminDifficulty"`
+	ComputedDifficulty float64  `json:"computedDifficulty"`
+	Difficulty         float64  `json:"difficulty"`
+}
+
+type ListSongsQuery struct {
+	Query      string
+	Artist     string
+	Tag        string
+	Genre      string
+	Language   string
+	Difficulty string // difficulty band
+	MinLines   int
+	MaxLines   int
+	Sort       string
+	Cursor     string
+	Limit      int
+}
+
+type CreateCollectionDto struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	SongIds     []string `json:"songIds"`
+}
+
+type CollectionResponse struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	SongIds     []string `json:"songIds"`
 }
 
 type CreateSongsReponse struct {
-	Id        string `json:"id"`
-	LineCount int    `json:"line_count"`
+	Id         string  `json:"id"`
+	LineCount  int     `json:"lineCount"`
+	Difficulty float64 `json:"difficulty"`
 }
 
 type CreateLessonDto struct {
-	UserId string `json:"userId"`
+	UserId       string `json:"userId"`
+	Tag          string `json:"tag,omitempty"`
+	Difficulty   string `json:"difficulty,omitempty"` // "beginner" | "intermediate" | "advanced"
+	CollectionId string `json:"collectionId,omitempty"`
+	CourseId     string `json:"courseId,omitempty"`
+	AssignmentId string `json:"assignmentId,omitempty"` // practice the song of a group assignment
+	ChallengeId  string `json:"challengeId,omitempty"`  // play your side of a challenge
+	Seed         *int64 `json:"seed,omitempty"`         // admin only: reproduce a lesson
+	Resume       bool   `json:"resume,omitempty"`       // return the latest unfinished lesson
+	Restart      bool   `json:"restart,omitempty"`      // abandon unfinished lessons and start a new one
+}
+
+type RetryLessonDto struct {
+	UserId  string `json:"userId"`            // must own the lesson being retried
+	Resume  bool   `json:"resume,omitempty"`  // return the latest unfinished lesson instead
+	Restart bool   `json:"restart,omitempty"` // abandon unfinished lessons and start the retry
 }
 
 type LessonItem struct {
@@ -38,8 +123,10 @@ type LessonItem struct {
 }
 
 type CreateLessonResponse struct {
-	LessonId string       `json:"lessonId"`
-	Items    []LessonItem `json:"items"`
+	LessonId      string       `json:"lessonId"`
+	Items         []LessonItem `json:"items"`
+	Resumed       bool         `json:"resumed,omitempty"`
+	AnsweredItems []int        `json:"answeredItems,omitempty"` // item indexes already answered in a resumed lesson
 }
 
 type SubmitAnswerDto struct {
@@ -51,8 +138,31 @@ type SubmitAnswerDto struct {
 }
 
 type SubmitAnswerResponse struct {
-	Ok      bool `json:"ok"`
-	Correct bool `json:"correct"`
+	Ok      bool            `json:"ok"`
+	Correct bool            `json:"correct"`
+	Events  []ProgressEvent `json:"events,omitempty"` // XP, daily goal and streak milestones to celebrate
+}
+
+type ProgressEvent struct {
+	Type   string `json:"type"`         // "xp" | "lesson_completed" | "daily_goal_met" | "streak_extended"
+	XP     int    `json:"xp,omitempty"` // awarded on "xp", day total on "daily_goal_met"
+	Streak int    `json:"streak,omitempty"`
+}
+
+type UserProgressResponse struct {
+	UserId        string `json:"userId"`
+	TotalXP       int    `json:"totalXp"`
+	Timezone      string `json:"timezone"`
+	DailyGoalXP   int    `json:"dailyGoalXp"`
+	TodayXP       int    `json:"todayXp"`
+	DailyGoalMet  bool   `json:"dailyGoalMet"`
+	Streak        int    `json:"streak"`
+	LongestStreak int    `json:"longestStreak"`
+}
+
+type UpdateProgressSettingsDto struct {
+	Timezone    string `json:"timezone"`    // IANA name, e.g. "Asia/Jerusalem"
+	DailyGoalXP int    `json:"dailyGoalXp"` // 0 keeps the current goal
 }
 
 type LessonSummaryResponse struct {
@@ -62,3 +172,316 @@ type LessonSummaryResponse struct {
 	Accuracy               float64  `json:"accuracy"`
 	ScheduledForRepractice []string `json:"scheduledForRepractice"`
 }
+
+type CreateCourseDto struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	SongIds        []string `json:"songIds"`        // in learning order
+	UnlockAccuracy float64  `json:"unlockAccuracy"` // percent, defaults to 80
+}
+
+type CourseResponse struct {
+	Id             string   `json:"id"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description,omitempty"`
+	SongIds        []string `json:"songIds"`
+	UnlockAccuracy float64  `json:"unlockAccuracy"`
+}
+
+type EnrollDto struct {
+	UserId string `json:"userId"`
+}
+
+type CourseSongProgress struct {
+	SongId       string  `json:"songId"`
+	Unlocked     bool    `json:"unlocked"`
+	Passed       bool    `json:"passed"`
+	BestAccuracy float64 `json:"bestAccuracy"`
+}
+
+type CourseProgressResponse struct {
+	CourseId      string               `json:"courseId"`
+	UserId        string               `json:"userId"`
+	Position      int                  `json:"position"`
+	CurrentSongId string               `json:"currentSongId"`
+	Completed     bool                 `json:"completed"`
+	Songs         []CourseSongProgress `json:"songs"`
+}
+
+type UserStatsResponse struct {
+	UserId           string       `json:"userId"`
+	Lessons          int          `json:"lessons"`
+	Answers          int          `json:"answers"`
+	Correct          int          `json:"correct"`
+	Wrong            int          `json:"wrong"`
+	Accuracy         float64      `json:"accuracy"`
+	TimeSpentSeconds int64        `json:"timeSpentSeconds"`
+	Daily            []DailyStats `json:"daily"` // one entry per day with lessons, oldest first
+	Songs            []SongStats  `json:"songs"`
+	MostMissedWords  []WordCount  `json:"mostMissedWords"`
+}
+
+type DailyStats struct {
+	Date             string  `json:"date"` // YYYY-MM-DD in the requested timezone
+	Lessons          int     `json:"lessons"`
+	Answers          int     `json:"answers"`
+	Correct          int     `json:"correct"`
+	Accuracy         float64 `json:"accuracy"`
+	TimeSpentSeconds int64   `json:"timeSpentSeconds"`
+}
+
+type SongStats struct {
+	SongId   string  `json:"songId"`
+	Lessons  int     `json:"lessons"`
+	Answers  int     `json:"answers"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+type WordMasteryResponse struct {
+	Word         string    `json:"word"`
+	SeenCount    int       `json:"seenCount"`
+	CorrectCount int       `json:"correctCount"`
+	LastSeenAt   time.Time `json:"lastSeenAt"`
+	Mastery      float64   `json:"mastery"` // 0..1, decayed to the time of the request
+	SongIds      []string  `json:"songIds"`
+}
+
+type SongAnalyticsResponse struct {
+	SongId         string          `json:"songId"`
+	Attempts       int             `json:"attempts"`
+	Errors         int             `json:"errors"`
+	ErrorRate      float64         `json:"errorRate"`
+	Lines          []LineAnalytics `json:"lines"`          // hardest first
+	TopDistractors []WordCount     `json:"topDistractors"` // wrong options picked most often
+}
+
+type LineAnalytics struct {
+	LineIndex int              `json:"lineIndex"`
+	Attempts  int              `json:"attempts"`
+	Errors    int              `json:"errors"`
+	ErrorRate float64          `json:"errorRate"`
+	Blanks    []BlankAnalytics `json:"blanks"`
+}
+
+type BlankAnalytics struct {
+	Word         string      `json:"word"`
+	Attempts     int         `json:"attempts"`
+	Errors       int         `json:"errors"`
+	ErrorRate    float64     `json:"errorRate"`
+	WrongChoices []WordCount `json:"wrongChoices"`
+}
+
+type ListLessonsQuery struct {
+	Status string
+	From   time.Time
+	To     time.Time
+	Cursor string
+	Limit  int
+}
+
+type LessonHistoryItem struct {
+	LessonId  string    `json:"lessonId"`
+	SongId    string    `json:"songId"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	Answered  int       `json:"answered"`
+	LessonSummaryResponse
+}
+
+type LessonAnswerResponse struct {
+	ItemIndex    int       `json:"itemIndex"`
+	Type         string    `json:"type"`
+	LineIndex    int       `json:"lineIndex"`
+	ExpectedWord string    `json:"expectedWord"`
+	UserInput    string    `json:"userInput"`
+	Correct      bool      `json:"correct"`
+	AnsweredAt   time.Time `json:"answeredAt"`
+}
+
+type LessonDetailResponse struct {
+	LessonId     string                 `json:"lessonId"`
+	UserId       string                 `json:"userId"`
+	SongId       string                 `json:"songId"`
+	CourseId     string                 `json:"courseId,omitempty"`
+	RetryOf      string                 `json:"retryOf,omitempty"`
+	AssignmentId string                 `json:"assignmentId,omitempty"`
+	ChallengeId  string                 `json:"challengeId,omitempty"`
+	Seed         *int64                 `json:"seed,omitempty"`
+	Status       string                 `json:"status"`
+	CreatedAt    time.Time              `json:"createdAt"`
+	StartedAt    *time.Time             `json:"startedAt,omitempty"`
+	CompletedAt  *time.Time             `json:"completedAt,omitempty"`
+	AbandonedAt  *time.Time             `json:"abandonedAt,omitempty"`
+	ExpiredAt    *time.Time             `json:"expiredAt,omitempty"`
+	Items        []LessonItem           `json:"items"`
+	Answers      []LessonAnswerResponse `json:"answers"`
+	Summary      LessonSummaryResponse  `json:"summary"`
+}
+
+type LeaderboardQuery struct {
+	Week   string // weekly boards, e.g. "2025-W03"; defaults to the current week
+	SongId string // required for song boards
+	UserId string // the caller, whose own rank is reported as "me"
+	Cursor string
+	Limit  int
+}
+
+type LeaderboardEntryResponse struct {
+	Rank   int     `json:"rank"`
+	UserId string  `json:"userId"`
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`
+}
+
+type LeaderboardResponse struct {
+	Kind    string                     `json:"kind"`
+	Scope   string                     `json:"scope,omitempty"`
+	Entries []LeaderboardEntryResponse `json:"entries"`
+	Me      *LeaderboardEntryResponse  `json:"me,omitempty"`
+}
+
+type CreateGroupDto struct {
+	Name      string `json:"name"`
+	TeacherId string `json:"teacherId"`
+}
+
+type GroupResponse struct {
+	Id         string    `json:"id"`
+	Name       string    `json:"name"`
+	TeacherId  string    `json:"teacherId,omitempty"` // only to admins, or to the teacher in replies to their own requests
+	StudentIds []string  `json:"studentIds"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type AddGroupMemberDto struct {
+	TeacherId string `json:"teacherId"` // must be the group's teacher
+	UserId    string `json:"userId"`
+}
+
+type CreateAssignmentDto struct {
+	TeacherId   string    `json:"teacherId"` // must be the group's teacher
+	SongId      string    `json:"songId"`
+	MinAccuracy float64   `json:"minAccuracy"` // percent, defaults to 80
+	DueAt       time.Time `json:"dueAt"`
+}
+
+type AssignmentResponse struct {
+	Id          string    `json:"id"`
+	GroupId     string    `json:"groupId"`
+	SongId      string    `json:"songId"`
+	MinAccuracy float64   `json:"minAccuracy"`
+	DueAt       time.Time `json:"dueAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type GroupDashboardResponse struct {
+	GroupId     string               `json:"groupId"`
+	Name        string               `json:"name"`
+	Assignments []AssignmentOverview `json:"assignments"`
+	Students    []StudentAssignments `json:"students"`
+}
+
+type AssignmentOverview struct {
+	AssignmentResponse
+	Completed       int     `json:"completed"`       // students who reached minAccuracy
+	AverageAccuracy float64 `json:"averageAccuracy"` // of students' best lessons, over students who tried
+}
+
+type StudentAssignments struct {
+	UserId          string              `json:"userId"`
+	Name            string              `json:"name"`
+	Completed       int                 `json:"completed"`
+	AverageAccuracy float64             `json:"averageAccuracy"`
+	Assignments     []StudentAssignment `json:"assignments"`
+}
+
+type StudentAssignment struct {
+	AssignmentId string     `json:"assignmentId"`
+	Attempts     int        `json:"attempts"`
+	BestAccuracy float64    `json:"bestAccuracy"`
+	Completed    bool       `json:"completed"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+	Late         bool       `json:"late"`    // completed after the due date
+	Overdue      bool       `json:"overdue"` // past due and not completed
+}
+
+type CreateChallengeDto struct {
+	ChallengerId string `json:"challengerId"`
+	OpponentId   string `json:"opponentId"`
+	SongId       string `json:"songId"`
+}
+
+type ChallengeActionDto struct {
+	UserId string `json:"userId"` // the opponent accepting or declining
+}
+
+type ChallengeResponse struct {
+	Id           string     `json:"id"`
+	ChallengerId string     `json:"challengerId"`
+	OpponentId   string     `json:"opponentId"`
+	SongId       string     `json:"songId"`
+	Status       string     `json:"status"` // "pending" | "accepted" | "declined" | "completed"
+	CreatedAt    time.Time  `json:"createdAt"`
+	AcceptedAt   *time.Time `json:"acceptedAt,omitempty"`
+	DeclinedAt   *time.Time `json:"declinedAt,omitempty"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+}
+
+type ChallengeResultResponse struct {
+	ChallengeId string                     `json:"challengeId"`
+	Status      string                     `json:"status"`
+	WinnerId    string                     `json:"winnerId,omitempty"`
+	Draw        bool                       `json:"draw"`
+	Challenger  ChallengeParticipantResult `json:"challenger"`
+	Opponent    ChallengeParticipantResult `json:"opponent"`
+}
+
+// ChallengeParticipantResult carries scores only once the challenge is
+// completed, so neither player sees the other's result early.
+type ChallengeParticipantResult struct {
+	UserId          string  `json:"userId"`
+	LessonId        string  `json:"lessonId,omitempty"`
+	Finished        bool    `json:"finished"`
+	Forfeited       bool    `json:"forfeited,omitempty"`
+	Accuracy        float64 `json:"accuracy,omitempty"`
+	Correct         int     `json:"correct,omitempty"`
+	Wrong           int     `json:"wrong,omitempty"`
+	DurationSeconds float64 `json:"durationSeconds,omitempty"`
+}
+
+// Meta accompanies every page of a paginated list, under "meta".
+type Meta struct {
+	NextCursor string `json:"nextCursor,omitempty"` // absent on the last page
+}
+
+// ErrorResponse is the body of every error reply, under "error".
+type ErrorResponse struct {
+	Code         string       `json:"code"` // "validation_failed" | "not_found" | "conflict" | "lesson_open" | "forbidden" | "idempotency_key_reused" | "rate_limited" | "unavailable" | "body_too_large" | "internal"
+	Message      string       `json:"message"`
+	Fields       []FieldError `json:"fields,omitempty"`
+	OpenLessonId string       `json:"openLessonId,omitempty"` // with lesson_open: the lesson to resume
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ReadinessResponse is the body of /readyz. It is not wrapped in "data":
+// probes are read by orchestrators, not API clients.
+type ReadinessResponse struct {
+	Status string                      `json:"status"` // "ok" | "unavailable"
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+type DependencyStatus struct {
+	Status    string `json:"status"` // "ok" | "down"
+	LatencyMs int64  `json:"latencyMs"`
+	Code      string `json:"code,omitempty"` // when down: "timeout" | "unreachable"
+}
@@ -0,0 +1,203 @@
+package contracts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.tomerab1/todo-api/internal/models"
+)
+
+// Validator is implemented by request bodies. Validate returns one entry per
+// offending field, or none when the body is acceptable.
+type Validator interface {
+	Validate() []FieldError
+}
+
+const (
+	maxNameLength  = 200
+	maxTextLength  = 2000
+	maxListEntries = 500
+)
+
+// fieldErrors collects the errors of one body.
+type fieldErrors []FieldError
+
+func (errs *fieldErrors) add(field, format string, args ...any) {
+	*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (errs *fieldErrors) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		errs.add(field, "is required")
+	}
+}
+
+func (errs *fieldErrors) name(field, value string) {
+	errs.required(field, value)
+	errs.maxLength(field, value, maxNameLength)
+}
+
+func (errs *fieldErrors) maxLength(field, value string, limit int) {
+	if len(value) > limit {
+		errs.add(field, "must be at most %d characters", limit)
+	}
+}
+
+func (errs *fieldErrors) oneOf(field, value string, allowed ...string) {
+	if value != "" && !slices.Contains(allowed, value) {
+		errs.add(field, "must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+func (errs *fieldErrors) between(field string, value, lo, hi float64) {
+	if value < lo || value > hi {
+		errs.add(field, "must be between %g and %g", lo, hi)
+	}
+}
+
+// list checks that a list is short enough and has no blank entries.
+func (errs *fieldErrors) list(field string, values []string) {
+	if len(values) > maxListEntries {
+		errs.add(field, "must have at most %d entries", maxListEntries)
+	}
+	for i, v := range values {
+		if strings.TrimSpace(v) == "" {
+			errs.add(fmt.Sprintf("%s[%d]", field, i), "must not be blank")
+		}
+	}
+}
+
+// adminDifficulty checks an optional difficulty override; 0 means none.
+func (errs *fieldErrors) adminDifficulty(field string, value int) {
+	if value != 0 && (value < models.MinDifficulty || value > models.MaxDifficulty) {
+		errs.add(field, "must be between %d and %d", models.MinDifficulty, models.MaxDifficulty)
+	}
+}
+
+func (dto CreateUserDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.name("name", dto.Name)
+	return errs
+}
+
+func (dto CreateSongDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.name("title", dto.Title)
+	errs.name("artist", dto.Artist)
+	errs.required("lyrics", dto.Lyrics)
+	errs.list("tags", dto.Tags)
+	errs.list("genres", dto.Genres)
+	errs.maxLength("language", dto.Language, maxNameLength)
+	errs.adminDifficulty("difficulty", dto.Difficulty)
+	return errs
+}
+
+func (dto UpdateSongMetadataDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.list("tags", dto.Tags)
+	errs.list("genres", dto.Genres)
+	errs.maxLength("language", dto.Language, maxNameLength)
+	errs.adminDifficulty("difficulty", dto.Difficulty)
+	return errs
+}
+
+func (dto CreateCollectionDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.name("name", dto.Name)
+	errs.maxLength("description", dto.Description, maxTextLength)
+	errs.list("songIds", dto.SongIds)
+	return errs
+}
+
+func (dto CreateCourseDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.name("name", dto.Name)
+	errs.maxLength("description", dto.Description, maxTextLength)
+	if len(dto.SongIds) == 0 {
+		errs.add("songIds", "must not be empty")
+	}
+	errs.list("songIds", dto.SongIds)
+	errs.between("unlockAccuracy", dto.UnlockAccuracy, 0, 100)
+	return errs
+}
+
+func (dto EnrollDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.required("userId", dto.UserId)
+	return errs
+}
+
+func (dto CreateLessonDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.required("userId", dto.UserId)
+	errs.oneOf("difficulty", dto.Difficulty,
+		models.DifficultyBeginner, models.DifficultyIntermediate, models.DifficultyAdvanced)
+	return errs
+}
+
+func (dto SubmitAnswerDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.required("lessonId", dto.LessonId)
+	if dto.ItemIndex < 0 {
+		errs.add("itemIndex", "must not be negative")
+	}
+	errs.required("type", dto.Type)
+	errs.oneOf("type", dto.Type, models.LessonTypeFillBlanks, models.LessonTypeArrange)
+	if dto.Type == models.LessonTypeFillBlanks {
+		errs.required("userInput", dto.UserInput)
+	}
+	errs.maxLength("userInput", dto.UserInput, maxNameLength)
+	return errs
+}
+
+func (dto UpdateProgressSettingsDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.maxLength("timezone", dto.Timezone, maxNameLength)
+	if dto.DailyGoalXP < 0 {
+		errs.add("dailyGoalXp", "must not be negative")
+	}
+	return errs
+}
+
+func (dto CreateGroupDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.name("name", dto.Name)
+	errs.required("teacherId", dto.TeacherId)
+	return errs
+}
+
+func (dto AddGroupMemberDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.required("teacherId", dto.TeacherId)
+	errs.required("userId", dto.UserId)
+	return errs
+}
+
+func (dto CreateAssignmentDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.required("teacherId", dto.TeacherId)
+	errs.required("songId", dto.SongId)
+	if dto.DueAt.IsZero() {
+		errs.add("dueAt", "is required")
+	}
+	errs.between("minAccuracy", dto.MinAccuracy, 0, 100)
+	return errs
+}
+
+func (dto CreateChallengeDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.required("challengerId", dto.ChallengerId)
+	errs.required("opponentId", dto.OpponentId)
+	errs.required("songId", dto.SongId)
+	if dto.OpponentId != "" && dto.OpponentId == dto.ChallengerId {
+		errs.add("opponentId", "must differ from challengerId")
+	}
+	return errs
+}
+
+func (dto ChallengeActionDto) Validate() []FieldError {
+	var errs fieldErrors
+	errs.required("userId", dto.UserId)
+	return errs
+}
@@ -0,0 +1,87 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/contracts"
+)
+
+func createChallenge(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.CreateChallengeDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := app.ChallengeSvc.CreateChallenge(r.Context(), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func acceptChallenge(app *app.Application) http.HandlerFunc {
+	return respondToChallenge(app, app.ChallengeSvc.Accept)
+}
+
+func declineChallenge(app *app.Application) http.HandlerFunc {
+	return respondToChallenge(app, app.ChallengeSvc.Decline)
+}
+
+func respondToChallenge(
+	app *app.Application,
+	respond func(ctx context.Context, challengeId string, dto contracts.ChallengeActionDto) (*contracts.ChallengeResponse, error),
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.ChallengeActionDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := respond(r.Context(), chi.URLParam(r, "challengeId"), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func challengeResult(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := app.ChallengeSvc.GetResult(r.Context(), chi.URLParam(r, "challengeId"))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, result)
+	}
+}
+
+func userChallenges(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, err := queryInt(r, "limit")
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		challenges, err := app.ChallengeSvc.GetUserChallenges(r.Context(), chi.URLParam(r, "userId"), limit)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, challenges)
+	}
+}
@@ -0,0 +1,99 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/contracts"
+)
+
+func createGroup(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.CreateGroupDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := app.ClassroomSvc.CreateGroup(r.Context(), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func getGroup(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		group, err := app.ClassroomSvc.GetGroup(r.Context(), chi.URLParam(r, "groupId"), isAdmin(app, r))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, group)
+	}
+}
+
+func addGroupStudent(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.AddGroupMemberDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := app.ClassroomSvc.AddStudent(r.Context(), chi.URLParam(r, "groupId"), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func createAssignment(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.CreateAssignmentDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := app.ClassroomSvc.CreateAssignment(r.Context(), chi.URLParam(r, "groupId"), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func getAssignments(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assignments, err := app.ClassroomSvc.GetAssignments(r.Context(), chi.URLParam(r, "groupId"), r.URL.Query().Get("teacherId"))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, assignments)
+	}
+}
+
+func groupDashboard(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dashboard, err := app.ClassroomSvc.GetDashboard(r.Context(), chi.URLParam(r, "groupId"), r.URL.Query().Get("teacherId"))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, dashboard)
+	}
+}
@@ -0,0 +1,47 @@
+package httpserver
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+)
+
+// TestGroupTeacherStaysHidden checks that nothing public names a group's
+// teacher, since the teacher id is what the teacher-only calls check, and
+// that a student guessing at it is refused.
+func TestGroupTeacherStaysHidden(t *testing.T) {
+	c := newContractClient(t)
+	teacherId := id(c.call("POST", "/users", "/api/v1/users", contracts.CreateUserDto{Name: "Ada"}, http.StatusCreated))
+	studentId := id(c.call("POST", "/users", "/api/v1/users", contracts.CreateUserDto{Name: "Cy"}, http.StatusCreated))
+	c.header.Set("X-Admin-Token", testAdminToken)
+	songId := id(c.call("POST", "/songs", "/api/v1/songs", contracts.CreateSongDto{Title: "T", Artist: "A", Lyrics: "one two three\n"}, http.StatusCreated))
+	c.header.Del("X-Admin-Token")
+	groupId := id(c.call("POST", "/groups", "/api/v1/groups", contracts.CreateGroupDto{Name: "5B", TeacherId: teacherId}, http.StatusCreated))
+	c.call("POST", "/groups/{groupId}/students", "/api/v1/groups/"+groupId+"/students",
+		contracts.AddGroupMemberDto{TeacherId: teacherId, UserId: studentId}, http.StatusCreated)
+
+	for _, u := range lookup(c.call("GET", "/users", "/api/v1/users", nil, http.StatusOK), "data").([]any) {
+		if got := lookup(u, "memberships"); got != nil {
+			t.Errorf("user %v shows memberships %v to a non-admin", lookup(u, "id"), got)
+		}
+	}
+	c.header.Set("X-Admin-Token", testAdminToken)
+	shown := 0
+	for _, u := range lookup(c.call("GET", "/users", "/api/v1/users", nil, http.StatusOK), "data").([]any) {
+		if lookup(u, "memberships") != nil {
+			shown++
+		}
+	}
+	if shown != 2 {
+		t.Errorf("admin sees memberships of %d users, want 2", shown)
+	}
+	c.header.Del("X-Admin-Token")
+
+	assignment := contracts.CreateAssignmentDto{TeacherId: studentId, SongId: songId, DueAt: time.Now().Add(time.Hour)}
+	c.call("POST", "/groups/{groupId}/assignments", "/api/v1/groups/"+groupId+"/assignments", assignment, http.StatusForbidden)
+	c.call("GET", "/groups/{groupId}/assignments", "/api/v1/groups/"+groupId+"/assignments?teacherId="+studentId, nil, http.StatusForbidden)
+	c.call("GET", "/groups/{groupId}/dashboard", "/api/v1/groups/"+groupId+"/dashboard?teacherId="+studentId, nil, http.StatusForbidden)
+	c.call("GET", "/groups/{groupId}/dashboard", "/api/v1/groups/"+groupId+"/dashboard", nil, http.StatusForbidden)
+}
@@ -0,0 +1,89 @@
+package httpserver
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.tomerab1/todo-api/internal/app"
+)
+
+// clientAddr is the address of the client that sent r. Behind trusted
+// proxies, such as Firebase Hosting in front of Cloud Run, the peer is the
+// proxy, so the forwarding headers are walked from the nearest hop back and
+// the first address that is not a trusted proxy is the client. Hops added
+// by untrusted peers are never believed, as the client can forge them.
+func clientAddr(app *app.Application, r *http.Request) string {
+	peer := hostOf(r.RemoteAddr)
+	if !trusted(app, peer) {
+		return peer
+	}
+	hops := forwardedFor(r.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !trusted(app, hops[i]) {
+			return hops[i]
+		}
+	}
+	if len(hops) > 0 {
+		return hops[0]
+	}
+	return peer
+}
+
+// trusted reports whether addr is one of the configured proxies.
+func trusted(app *app.Application, addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, p := range app.TrustedProxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor lists the hops of X-Forwarded-For, or of the for= parameters
+// of Forwarded when it is absent, from the original client to the nearest
+// proxy.
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	for _, v := range h.Values("X-Forwarded-For") {
+		hops = append(hops, splitHops(v)...)
+	}
+	if len(hops) > 0 {
+		return hops
+	}
+	for _, v := range h.Values("Forwarded") {
+		for _, elem := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(elem, ";") {
+				k, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(k, "for") {
+					hops = append(hops, hostOf(strings.Trim(val, `"`)))
+				}
+			}
+		}
+	}
+	return hops
+}
+
+func splitHops(v string) []string {
+	var out []string
+	for _, hop := range strings.Split(v, ",") {
+		if hop = strings.TrimSpace(hop); hop != "" {
+			out = append(out, hostOf(hop))
+		}
+	}
+	return out
+}
+
+// hostOf strips the port and IPv6 brackets from addr, if it has them.
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
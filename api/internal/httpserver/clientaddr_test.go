@@ -0,0 +1,50 @@
+package httpserver
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.tomerab1/todo-api/internal/app"
+)
+
+func TestClientAddr(t *testing.T) {
+	a := &app.Application{TrustedProxies: []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}}
+	for _, tc := range []struct {
+		name   string
+		peer   string
+		header map[string]string
+		want   string
+	}{
+		{"direct", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"untrusted peer's header ignored", "203.0.113.5:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.5"},
+		{"trusted peer without header", "10.1.2.3:1234", nil, "10.1.2.3"},
+		{"one proxy", "10.1.2.3:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"forged hop before the client", "10.1.2.3:1234",
+			map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 10.4.4.4"}, "198.51.100.1"},
+		{"all hops trusted", "10.1.2.3:1234",
+			map[string]string{"X-Forwarded-For": "10.5.5.5, 10.6.6.6"}, "10.5.5.5"},
+		{"garbage hop", "10.1.2.3:1234",
+			map[string]string{"X-Forwarded-For": "1.2.3.4, unknown"}, "unknown"},
+		{"forwarded", "[2001:db8::1]:1234",
+			map[string]string{"Forwarded": `for=192.0.2.60;proto=https, for="[2001:db8::7]:4711"`}, "192.0.2.60"},
+		{"forwarded port", "10.1.2.3:1234",
+			map[string]string{"Forwarded": `for="198.51.100.9:8080"`}, "198.51.100.9"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.peer
+			for k, v := range tc.header {
+				r.Header.Set(k, v)
+			}
+			if got := clientAddr(a, r); got != tc.want {
+				t.Errorf("clientAddr = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
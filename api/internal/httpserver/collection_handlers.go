@@ -0,0 +1,69 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/contracts"
+)
+
+func createCollection(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.CreateCollectionDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := app.CollectionSvc.CreateCollection(r.Context(), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func updateCollection(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.CreateCollectionDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := app.CollectionSvc.UpdateCollection(r.Context(), chi.URLParam(r, "collectionId"), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func getCollections(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collections, err := app.CollectionSvc.GetAllCollections(r.Context())
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, collections)
+	}
+}
+
+func getCollection(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collection, err := app.CollectionSvc.GetCollection(r.Context(), chi.URLParam(r, "collectionId"))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, collection)
+	}
+}
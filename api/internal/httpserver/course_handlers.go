@@ -0,0 +1,100 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/contracts"
+)
+
+func createCourse(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.CreateCourseDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := app.CourseSvc.CreateCourse(r.Context(), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func updateCourse(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.CreateCourseDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := app.CourseSvc.UpdateCourse(r.Context(), chi.URLParam(r, "courseId"), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func getCourses(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		courses, err := app.CourseSvc.GetAllCourses(r.Context())
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, courses)
+	}
+}
+
+func getCourse(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		course, err := app.CourseSvc.GetCourse(r.Context(), chi.URLParam(r, "courseId"))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, course)
+	}
+}
+
+func enrollInCourse(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.EnrollDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+		setLogUser(r, dto.UserId)
+
+		resp, err := app.CourseSvc.Enroll(r.Context(), chi.URLParam(r, "courseId"), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func courseProgress(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := app.CourseSvc.GetProgress(r.Context(), chi.URLParam(r, "courseId"), chi.URLParam(r, "userId"))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,128 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/services"
+)
+
+// errorKinds maps each service error kind to its status and body code, in
+// the order they are checked.
+var errorKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{services.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{services.ErrNotFound, http.StatusNotFound, "not_found"},
+	{services.ErrConflict, http.StatusConflict, "conflict"},
+	{services.ErrLessonOpen, http.StatusConflict, "lesson_open"},
+	{services.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{services.ErrRateLimited, http.StatusTooManyRequests, "rate_limited"},
+	{services.ErrKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	{services.ErrUnavailable, http.StatusServiceUnavailable, "unavailable"},
+	{errBodyTooLarge.Kind, http.StatusRequestEntityTooLarge, "body_too_large"},
+}
+
+// writeError replies with the status and body matching err's kind. Only
+// service errors and validation errors have their message shown; anything
+// else is logged, with the request's ids, and reported as an internal error.
+func writeError(app *app.Application, w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	body := contracts.ErrorResponse{Code: "internal", Message: "internal error"}
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) {
+			status, body.Code, body.Message = k.status, k.code, http.StatusText(k.status)
+			break
+		}
+	}
+
+	var svcErr *services.Error
+	switch {
+	case errors.As(err, &svcErr):
+		body.Message, body.Fields, body.OpenLessonId = err.Error(), svcErr.Fields, svcErr.OpenLessonId
+	case status == http.StatusBadRequest:
+		body.Message = err.Error()
+	}
+	if status >= http.StatusInternalServerError {
+		app.Logger().ErrorContext(r.Context(), "request failed", "err", err)
+	}
+
+	app.WriteErrorJSON(w, status, body)
+}
+
+// maxBodyBytes caps request bodies; the largest legitimate ones are song
+// lyrics.
+const maxBodyBytes = 1 << 20
+
+// errBodyTooLarge is returned for bodies over maxBodyBytes.
+var errBodyTooLarge = &services.Error{
+	Kind:    errors.New("body too large"),
+	Message: fmt.Sprintf("body must not exceed %d bytes", maxBodyBytes),
+}
+
+// decodeBody reads a single JSON object into dst, rejecting unknown fields,
+// and runs dst's own validation. Any failure is a validation error naming
+// the offending fields.
+func decodeBody(w http.ResponseWriter, r *http.Request, dst any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if dec.More() {
+		return &services.Error{Kind: services.ErrValidation, Message: "body must contain a single JSON object"}
+	}
+
+	if v, ok := dst.(contracts.Validator); ok {
+		if fields := v.Validate(); len(fields) > 0 {
+			return invalidFields(fields)
+		}
+	}
+	return nil
+}
+
+// decodeError describes why a body could not be decoded, naming the field
+// when the decoder knows it.
+func decodeError(err error) error {
+	var (
+		tooLarge  *http.MaxBytesError
+		typeErr   *json.UnmarshalTypeError
+		syntaxErr *json.SyntaxError
+	)
+	switch {
+	case errors.As(err, &tooLarge):
+		return errBodyTooLarge
+	case errors.Is(err, io.EOF):
+		return &services.Error{Kind: services.ErrValidation, Message: "body is required", Err: err}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return invalidFields([]contracts.FieldError{{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()}})
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &services.Error{Kind: services.ErrValidation, Message: "body is not valid JSON", Err: err}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return invalidFields([]contracts.FieldError{{Field: strings.Trim(field, `"`), Message: "is not allowed"}})
+	}
+	return &services.Error{Kind: services.ErrValidation, Message: fmt.Sprintf("invalid body: %v", err), Err: err}
+}
+
+// invalidFields reports several bad body fields at once.
+func invalidFields(fields []contracts.FieldError) error {
+	msgs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		msgs = append(msgs, f.Field+" "+f.Message)
+	}
+	return &services.Error{Kind: services.ErrValidation, Message: strings.Join(msgs, "; "), Fields: fields}
+}
+
+// invalidParam reports a bad query parameter.
+func invalidParam(key, message string) error {
+	return invalidFields([]contracts.FieldError{{Field: key, Message: message}})
+}
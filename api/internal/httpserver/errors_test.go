@@ -0,0 +1,60 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/services"
+)
+
+func TestDecodeBody(t *testing.T) {
+	cases := []struct {
+		name   string
+		body   string
+		kind   error
+		fields []string // offending fields, in order
+	}{
+		{"valid", `{"lessonId":"L1","itemIndex":0,"type":"fillblanks","userInput":"try"}`, nil, nil},
+		{"empty", ``, services.ErrValidation, nil},
+		{"malformed", `{"lessonId":`, services.ErrValidation, nil},
+		{"unknown field", `{"lessonId":"L1","type":"arrange","extra":1}`, services.ErrValidation, []string{"extra"}},
+		{"wrong type", `{"lessonId":"L1","itemIndex":"zero","type":"arrange"}`, services.ErrValidation, []string{"itemIndex"}},
+		{"trailing data", `{"lessonId":"L1","type":"arrange"}{}`, services.ErrValidation, nil},
+		{"invalid fields", `{"itemIndex":-1,"type":"sing"}`, services.ErrValidation, []string{"lessonId", "itemIndex", "type"}},
+		{"too large", `{"userInput":"` + strings.Repeat("a", maxBodyBytes) + `"}`, errBodyTooLarge.Kind, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/answers", strings.NewReader(tc.body))
+			var dto contracts.SubmitAnswerDto
+			err := decodeBody(httptest.NewRecorder(), r, &dto)
+
+			if tc.kind == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tc.kind) {
+				t.Fatalf("got %v, want kind %v", err, tc.kind)
+			}
+			var svcErr *services.Error
+			if !errors.As(err, &svcErr) {
+				t.Fatalf("got %T, want *services.Error", err)
+			}
+			var fields []string
+			for _, f := range svcErr.Fields {
+				fields = append(fields, f.Field)
+			}
+			if tc.fields != nil && !slices.Equal(fields, tc.fields) {
+				t.Errorf("fields = %v, want %v", fields, tc.fields)
+			}
+		})
+	}
+}
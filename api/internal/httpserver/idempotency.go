@@ -0,0 +1,82 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.tomerab1/todo-api/internal/app"
+)
+
+// maxIdempotencyKeyLen bounds the Idempotency-Key header.
+const maxIdempotencyKeyLen = 255
+
+// idempotent makes a create endpoint safe to retry. A request carrying an
+// Idempotency-Key runs once per key and client address, so two clients
+// picking the same key do not see each other's replies. Later requests with
+// the same key and body get the stored reply back, flagged by
+// Idempotent-Replayed. Requests without the header pass straight through.
+func idempotent(app *app.Application, operation string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				writeError(app, w, r, invalidParam("Idempotency-Key", "must not exceed 255 characters"))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			if err != nil {
+				writeError(app, w, r, decodeError(err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.New()
+			sum.Write([]byte(operation))
+			sum.Write([]byte{0})
+			sum.Write(body)
+			fingerprint := hex.EncodeToString(sum.Sum(nil))
+
+			rec, err := app.IdempotencySvc.Begin(r.Context(), clientAddr(app, r), key, fingerprint)
+			if err != nil {
+				writeError(app, w, r, err)
+				return
+			}
+			if rec.Done() {
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(rec.Status)
+				w.Write(rec.Body)
+				return
+			}
+
+			rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			app.IdempotencySvc.Finish(context.WithoutCancel(r.Context()), rec, rw.status, rw.body.Bytes())
+		})
+	}
+}
+
+// recordingWriter keeps a copy of the reply it passes on.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(p []byte) (int, error) {
+	rw.body.Write(p)
+	return rw.ResponseWriter.Write(p)
+}
@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	c := newContractClient(t)
+	post := func(key, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		c.handler.ServeHTTP(rec, r)
+		return rec
+	}
+	userId := func(rec *httptest.ResponseRecorder) string {
+		t.Helper()
+		var got struct {
+			Data struct {
+				Id string `json:"id"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode %s: %v", rec.Body, err)
+		}
+		return got.Data.Id
+	}
+
+	first := post("k1", `{"name":"Ada"}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first: status %d: %s", first.Code, first.Body)
+	}
+
+	retry := post("k1", `{"name":"Ada"}`)
+	if retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry: status %d, replayed %q", retry.Code, retry.Header().Get("Idempotent-Replayed"))
+	}
+	if userId(retry) != userId(first) {
+		t.Errorf("retry created %s, want replay of %s", userId(retry), userId(first))
+	}
+
+	if reused := post("k1", `{"name":"Grace"}`); reused.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reused key: status %d, want 422: %s", reused.Code, reused.Body)
+	}
+
+	second := post("k2", `{"name":"Ada"}`)
+	if second.Code != http.StatusCreated || second.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("new key: status %d, replayed %q", second.Code, second.Header().Get("Idempotent-Replayed"))
+	}
+	if userId(second) == userId(first) {
+		t.Errorf("new key replayed %s", userId(first))
+	}
+
+	if plain := post("", `{"name":"Ada"}`); userId(plain) == userId(first) || userId(plain) == userId(second) {
+		t.Errorf("request without a key replayed %s", userId(plain))
+	}
+}
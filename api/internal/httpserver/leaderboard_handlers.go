@@ -0,0 +1,34 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/contracts"
+)
+
+func getLeaderboard(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		query := contracts.LeaderboardQuery{
+			Week:   q.Get("week"),
+			SongId: q.Get("songId"),
+			UserId: q.Get("userId"),
+			Cursor: q.Get("cursor"),
+		}
+		var err error
+		if query.Limit, err = queryInt(r, "limit"); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		board, next, err := app.LeaderboardSvc.GetLeaderboard(r.Context(), chi.URLParam(r, "kind"), query)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		writePage(app, w, r, board, next)
+	}
+}
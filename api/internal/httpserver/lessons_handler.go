@@ -1,8 +1,6 @@
 package httpserver
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -14,39 +12,44 @@ import (
 func createLesson(app *app.Application) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req contracts.CreateLessonDto
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			app.WriteErrorJSON(w, http.StatusInternalServerError, fmt.Sprintf("failed to parse lesson: %v", err))
+		if err := decodeBody(w, r, &req); err != nil {
+			writeError(app, w, r, err)
 			return
 		}
-		out, err := app.LessonSvc.CreateLesson(r.Context(), contracts.CreateLessonDto{UserId: req.UserId})
+		setLogUser(r, req.UserId)
+		if req.Seed != nil && !isAdmin(app, r) {
+			writeError(app, w, r, &services.Error{Kind: services.ErrForbidden, Message: "seed is restricted to admins"})
+			return
+		}
+		out, err := app.LessonSvc.CreateLesson(r.Context(), req)
 		if err != nil {
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("failed to create lesson: %v", err))
+			writeError(app, w, r, err)
 			return
 		}
 
-		app.WriteJSON(w, http.StatusCreated, out)
+		status := http.StatusCreated
+		if out.Resumed {
+			status = http.StatusOK
+		}
+		app.WriteJSON(w, status, out)
 	}
 }
 
 func submitAnswer(app *app.Application) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var dto contracts.SubmitAnswerDto
-		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err))
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
 			return
 		}
 
-		correct, err := app.LessonSvc.SubmitAnswer(r.Context(), dto.LessonId, dto.ItemIndex, dto.Type, dto.UserInput)
+		resp, err := app.LessonSvc.SubmitAnswer(r.Context(), dto.LessonId, dto.ItemIndex, dto.Type, dto.UserInput)
 		if err != nil {
-			if err == services.ErrDuplicateAnswer {
-				app.WriteErrorJSON(w, http.StatusConflict, "duplicate submission")
-				return
-			}
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("failed to submit answer: %v", err))
+			writeError(app, w, r, err)
 			return
 		}
 
-		app.WriteJSON(w, http.StatusOK, contracts.SubmitAnswerResponse{Ok: true, Correct: correct})
+		app.WriteJSON(w, http.StatusOK, resp)
 	}
 }
 
@@ -56,7 +59,7 @@ func lessonSummary(app *app.Application) http.HandlerFunc {
 		lessonId := chi.URLParam(r, "lessonId")
 		total, correct, wrong, acc, scheduled, err := app.LessonSvc.GetSummary(r.Context(), lessonId)
 		if err != nil {
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("failed to get summary: %v", err))
+			writeError(app, w, r, err)
 			return
 		}
 		resp := contracts.LessonSummaryResponse{
@@ -70,3 +73,78 @@ func lessonSummary(app *app.Application) http.HandlerFunc {
 		app.WriteJSON(w, http.StatusOK, resp)
 	}
 }
+
+func getLesson(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lesson, err := app.LessonSvc.GetLesson(r.Context(), chi.URLParam(r, "lessonId"))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, lesson)
+	}
+}
+
+func retryLesson(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.RetryLessonDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+		setLogUser(r, dto.UserId)
+		out, err := app.LessonSvc.RetryLesson(r.Context(), chi.URLParam(r, "lessonId"), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		status := http.StatusCreated
+		if out.Resumed {
+			status = http.StatusOK
+		}
+		app.WriteJSON(w, status, out)
+	}
+}
+
+func userLessons(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := contracts.ListLessonsQuery{
+			Status: r.URL.Query().Get("status"),
+			Cursor: r.URL.Query().Get("cursor"),
+		}
+		var err error
+		if query.From, err = queryTime(r, "from"); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+		if query.To, err = queryTime(r, "to"); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+		if query.Limit, err = queryInt(r, "limit"); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		lessons, next, err := app.LessonSvc.ListLessons(r.Context(), chi.URLParam(r, "userId"), query)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		writePage(app, w, r, lessons, next)
+	}
+}
+
+func abandonLesson(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := app.LessonSvc.AbandonLesson(r.Context(), chi.URLParam(r, "lessonId")); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIdHeader carries the request id: taken from the client when it
+// sends a sane one, generated otherwise, and always echoed back.
+const requestIdHeader = "X-Request-Id"
+
+var requestIdRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+type requestInfoKey struct{}
+
+// requestInfo collects what handlers learn about a request that the access
+// log should show.
+type requestInfo struct {
+	user string
+}
+
+// setLogUser names the user a request acts for, when it comes from the body
+// rather than the {userId} path parameter.
+func setLogUser(r *http.Request, userId string) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		info.user = userId
+	}
+}
+
+// requestLogger assigns every request an id, puts it and a server span in
+// the request context, and logs and counts every request once it is served.
+func requestLogger(app *app.Application) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(requestIdHeader)
+			if !requestIdRe.MatchString(id) {
+				id = telemetry.NewRequestId()
+			}
+			w.Header().Set(requestIdHeader, id)
+
+			info := &requestInfo{}
+			ctx := telemetry.WithRequestId(r.Context(), id)
+			ctx = context.WithValue(ctx, requestInfoKey{}, info)
+			ctx = telemetry.Extract(ctx, r.Header)
+			ctx, span := app.Tracer.Start(ctx, r.Method, trace.SpanKindServer,
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			latency := time.Since(start)
+			route, pattern := r.URL.Path, "unmatched"
+			user := info.user
+			if rctx := chi.RouteContext(ctx); rctx != nil {
+				if p := rctx.RoutePattern(); p != "" {
+					route, pattern = p, p
+				}
+				if user == "" {
+					user = rctx.URLParam("userId")
+				}
+			}
+			// Metrics are labelled by pattern only: raw paths of unmatched
+			// requests would make a series per path
+			app.Metrics.ObserveRequest(r.Method, pattern, status, latency)
+
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(
+				attribute.String("http.route", route),
+				attribute.Int("http.response.status_code", status),
+			)
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+			span.End()
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.Int("status", status),
+				slog.Duration("latency", latency),
+			}
+			if user != "" {
+				attrs = append(attrs, slog.String("user", user))
+			}
+			app.Logger().LogAttrs(ctx, slog.LevelInfo, "request", attrs...)
+		})
+	}
+}
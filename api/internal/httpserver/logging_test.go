@@ -0,0 +1,137 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.tomerab1/todo-api/internal/config"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+	"github.tomerab1/todo-api/internal/services"
+	"github.tomerab1/todo-api/internal/telemetry"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// memExporter keeps the exported spans past the tracer's shutdown, which
+// would otherwise clear them.
+type memExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (memExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestRequestLogger(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(telemetry.NewContextHandler(slog.NewJSONHandler(&logs, nil))))
+
+	c := newContractClient(t)
+	exporter := memExporter{tracetest.NewInMemoryExporter()}
+	tracer := telemetry.NewTracer(exporter, "test")
+	c.app.Tracer = tracer
+	c.handler = New(c.app, config.Default())
+
+	const traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"name":"Ada"}`))
+	r.Header.Set(requestIdHeader, "req-1")
+	r.Header.Set("traceparent", "00-"+traceId+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, r)
+	if got := rec.Header().Get(requestIdHeader); got != "req-1" {
+		t.Errorf("%s = %q, want the client's id", requestIdHeader, got)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/api/v1/users/nobody/stats", nil)
+	r.Header.Set(requestIdHeader, "bad id\n")
+	rec = httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, r)
+	generated := rec.Header().Get(requestIdHeader)
+	if generated == "" || generated == "bad id\n" {
+		t.Errorf("%s = %q, want a generated id", requestIdHeader, generated)
+	}
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+		if entry["msg"] == "request" {
+			lines = append(lines, entry)
+		}
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %d request lines, want 2:\n%s", len(lines), logs.String())
+	}
+	first, second := lines[0], lines[1]
+	if first["requestId"] != "req-1" || first["route"] != "/api/v1/users" ||
+		first["status"] != float64(http.StatusCreated) || first["user"] != "user-1" || first["traceId"] != traceId {
+		t.Errorf("first request logged as %v", first)
+	}
+	if second["requestId"] != generated || second["route"] != "/api/v1/users/{userId}/stats" || second["user"] != "nobody" {
+		t.Errorf("second request logged as %v", second)
+	}
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range exporter.GetSpans() {
+		names = append(names, s.Name)
+		if s.Name == "POST /api/v1/users" && (s.SpanContext.TraceID().String() != traceId || s.Parent.SpanID().String() != "00f067aa0ba902b7") {
+			t.Errorf("span %s did not continue the client's trace: %+v", s.Name, s)
+		}
+	}
+	want := []string{"POST /api/v1/users", "GET /api/v1/users/{userId}/stats"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("spans = %v, want %v", names, want)
+	}
+}
+
+// failingUserRepo fails every write, as an unreachable database would.
+type failingUserRepo struct {
+	repositories.UserRepoIface
+}
+
+func (failingUserRepo) Create(context.Context, *models.User) (string, error) {
+	return "", errors.New("connection reset")
+}
+
+func TestInternalErrorLogged(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(telemetry.NewContextHandler(slog.NewJSONHandler(&logs, nil))))
+
+	c := newContractClient(t)
+	c.app.UserSvc = services.NewUserService(failingUserRepo{}, slog.Default())
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"name":"Ada"}`))
+	r.Header.Set(requestIdHeader, "req-500")
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, r)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", rec.Code)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+		if entry["msg"] == "request failed" {
+			if entry["requestId"] != "req-500" || entry["err"] != "connection reset" {
+				t.Errorf("failure logged as %v, want its request id and error", entry)
+			}
+			return
+		}
+	}
+	t.Errorf("no request failed line in:\n%s", logs.String())
+}
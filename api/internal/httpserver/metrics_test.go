@@ -0,0 +1,54 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.tomerab1/todo-api/internal/config"
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/telemetry"
+)
+
+func TestMetrics(t *testing.T) {
+	c := newContractClient(t)
+	c.app.Metrics = telemetry.NewMetrics()
+	c.app.LessonSvc.WithMetrics(c.app.Metrics)
+	c.handler = New(c.app, config.Default())
+
+	user := c.call("POST", "/users", "/api/v1/users", contracts.CreateUserDto{Name: "Ada"}, http.StatusCreated)
+	userId := lookup(user, "data", "id").(string)
+	// One line gives a short lesson.
+	c.header.Set("X-Admin-Token", testAdminToken)
+	c.call("POST", "/songs", "/api/v1/songs", contracts.CreateSongDto{Title: "Short", Artist: "A", Lyrics: "one two three four\n"}, http.StatusCreated)
+	c.header.Del("X-Admin-Token")
+	lesson := c.call("POST", "/lessons", "/api/v1/lessons", contracts.CreateLessonDto{UserId: userId}, http.StatusCreated)
+	items := lookup(lesson, "data", "items").([]any)
+	arrange := slices.IndexFunc(items, func(it any) bool { return lookup(it, "type") == "arrange" })
+	if arrange < 0 {
+		t.Fatal("lesson has no arrange item")
+	}
+	answer := contracts.SubmitAnswerDto{LessonId: lookup(lesson, "data", "lessonId").(string), ItemIndex: arrange, Type: "arrange"}
+	c.call("POST", "/answers", "/api/v1/answers", answer, http.StatusOK)
+	c.handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere/42", nil))
+
+	rec := httptest.NewRecorder()
+	c.app.Metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	scrape := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="POST",route="/api/v1/users",status="201"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{method="POST",route="/api/v1/lessons"} 1`,
+		`http_request_duration_seconds_bucket{method="POST",route="/api/v1/lessons",le="+Inf"} 1`,
+		"lessons_created_total 1",
+		"lessons_short_total 1",
+		`answers_total{correct="unknown",type="arrange"} 1`,
+	} {
+		if !strings.Contains(scrape, want+"\n") {
+			t.Errorf("scrape lacks %q:\n%s", want, scrape)
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package httpserver
 
-import "net/http"
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/services"
+)
 
 func commonHeadersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -8,3 +14,22 @@ func commonHeadersMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// isAdmin reports whether the request carries the configured admin token.
+func isAdmin(app *app.Application, r *http.Request) bool {
+	token := r.Header.Get("X-Admin-Token")
+	return app.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(app.AdminToken)) == 1
+}
+
+// adminOnly answers 403 to requests without the admin token.
+func adminOnly(app *app.Application) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isAdmin(app, r) {
+				writeError(app, w, r, &services.Error{Kind: services.ErrForbidden, Message: "restricted to admins"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/config"
+)
+
+func TestAdminOnlyRoutes(t *testing.T) {
+	routes := []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/songs"},
+		{http.MethodPut, "/api/v1/songs/s1/metadata"},
+		{http.MethodGet, "/api/v1/songs/s1/analytics"},
+		{http.MethodPost, "/api/v1/collections"},
+		{http.MethodPut, "/api/v1/collections/c1"},
+		{http.MethodPost, "/api/v1/courses"},
+		{http.MethodPut, "/api/v1/courses/c1"},
+	}
+	for _, token := range []string{"", "secret"} {
+		h := New(&app.Application{AdminToken: token}, config.Default())
+		for _, rt := range routes {
+			for _, sent := range []string{"", "wrong"} {
+				req := httptest.NewRequest(rt.method, rt.path, strings.NewReader(`{}`))
+				req.Header.Set("X-Admin-Token", sent)
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				if rec.Code != http.StatusForbidden {
+					t.Errorf("%s %s with token %q (configured %q) = %d, want 403", rt.method, rt.path, sent, token, rec.Code)
+				}
+			}
+		}
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	a := &app.Application{AdminToken: "secret"}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if isAdmin(a, req) {
+		t.Error("request without a token is admin")
+	}
+	req.Header.Set("X-Admin-Token", "secret")
+	if !isAdmin(a, req) {
+		t.Error("request with the token is not admin")
+	}
+	if isAdmin(&app.Application{}, req) {
+		t.Error("an empty configured token must disable admin access")
+	}
+}
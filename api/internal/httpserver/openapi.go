@@ -0,0 +1,23 @@
+package httpserver
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.tomerab1/todo-api/internal/app"
+)
+
+// openAPISpec documents every route registered in New. Contract tests check
+// handler responses against it, so it must change together with the
+// handlers and contracts.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// getOpenAPI serves the spec as is; it is the document, not a data envelope.
+func getOpenAPI(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write(openAPISpec)
+	}
+}
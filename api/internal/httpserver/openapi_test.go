@@ -0,0 +1,497 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/config"
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/repositories/repotest"
+)
+
+// loadSpec decodes the embedded OpenAPI document.
+func loadSpec(t *testing.T) map[string]any {
+	t.Helper()
+	var spec map[string]any
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+	return spec
+}
+
+// lookup follows a slash-separated path through decoded JSON.
+func lookup(doc any, path ...string) any {
+	for _, key := range path {
+		m, ok := doc.(map[string]any)
+		if !ok {
+			return nil
+		}
+		doc = m[key]
+	}
+	return doc
+}
+
+// resolve follows a local $ref such as "#/components/schemas/User".
+func resolve(spec map[string]any, node map[string]any) map[string]any {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node
+		}
+		next, _ := lookup(spec, strings.Split(strings.TrimPrefix(ref, "#/"), "/")...).(map[string]any)
+		if next == nil {
+			panic("unresolved $ref " + ref)
+		}
+		node = next
+	}
+}
+
+// validate checks v against the subset of JSON Schema the spec uses and
+// returns one message per violation.
+func validate(spec map[string]any, schema map[string]any, v any, at string) []string {
+	schema = resolve(spec, schema)
+	var errs []string
+	fail := func(format string, args ...any) {
+		errs = append(errs, at+": "+fmt.Sprintf(format, args...))
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, v) {
+		fail("%v is not one of %v", v, enum)
+	}
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("want object, got %T", v)
+			break
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for _, req := range asStrings(schema["required"]) {
+			if _, ok := obj[req]; !ok {
+				fail("missing required %q", req)
+			}
+		}
+		for key, val := range obj {
+			prop, ok := props[key].(map[string]any)
+			if !ok {
+				if schema["additionalProperties"] == false {
+					fail("undocumented property %q", key)
+				}
+				continue
+			}
+			errs = append(errs, validate(spec, prop, val, at+"."+key)...)
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			fail("want array, got %T", v)
+			break
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range arr {
+			errs = append(errs, validate(spec, items, item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			fail("want string, got %T", v)
+			break
+		}
+		layout := map[any]string{"date-time": time.RFC3339, "date": time.DateOnly}[schema["format"]]
+		if _, err := time.Parse(layout, s); layout != "" && err != nil {
+			fail("%q is not a %s", s, schema["format"])
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok {
+			fail("want %s, got %T", schema["type"], v)
+			break
+		}
+		if schema["type"] == "integer" && n != math.Trunc(n) {
+			fail("%v is not an integer", n)
+		}
+		if lo, ok := schema["minimum"].(float64); ok && n < lo {
+			fail("%v is below %v", n, lo)
+		}
+		if hi, ok := schema["maximum"].(float64); ok && n > hi {
+			fail("%v is above %v", n, hi)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("want boolean, got %T", v)
+		}
+	}
+	return errs
+}
+
+func asStrings(v any) []string {
+	var out []string
+	list, _ := v.([]any)
+	for _, s := range list {
+		out = append(out, s.(string))
+	}
+	return out
+}
+
+// specRoute turns a chi pattern such as "/api/v1/users/" into the spec's
+// "/users".
+func specRoute(pattern string) string {
+	route := strings.TrimPrefix(pattern, "/api/v1")
+	if route != "/" {
+		route = strings.TrimSuffix(route, "/")
+	}
+	return route
+}
+
+func TestOpenAPICoversEveryRoute(t *testing.T) {
+	spec := loadSpec(t)
+	paths := spec["paths"].(map[string]any)
+
+	served := map[string]bool{}
+	err := chi.Walk(New(&app.Application{}, config.Default()), func(method, pattern string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if pattern == "/api/*" {
+			return nil // the legacy alias of /api/v1
+		}
+		route := specRoute(pattern)
+		served[strings.ToLower(method)+" "+route] = true
+		if lookup(paths, route, strings.ToLower(method)) == nil {
+			t.Errorf("%s %s is not documented", method, route)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+
+	for route, ops := range paths {
+		for method := range ops.(map[string]any) {
+			if !served[method+" "+route] {
+				t.Errorf("%s %s is documented but not routed", strings.ToUpper(method), route)
+			}
+		}
+	}
+}
+
+// TestOpenAPIRequestSchemas checks that every documented request body has
+// exactly the JSON fields of its DTO.
+func TestOpenAPIRequestSchemas(t *testing.T) {
+	spec := loadSpec(t)
+	dtos := []any{
+		contracts.CreateUserDto{},
+		contracts.CreateSongDto{},
+		contracts.UpdateSongMetadataDto{},
+		contracts.CreateCollectionDto{},
+		contracts.CreateCourseDto{},
+		contracts.EnrollDto{},
+		contracts.CreateLessonDto{},
+		contracts.SubmitAnswerDto{},
+		contracts.UpdateProgressSettingsDto{},
+		contracts.CreateGroupDto{},
+		contracts.AddGroupMemberDto{},
+		contracts.CreateAssignmentDto{},
+		contracts.CreateChallengeDto{},
+		contracts.ChallengeActionDto{},
+	}
+
+	for _, dto := range dtos {
+		typ := reflect.TypeOf(dto)
+		schema, _ := lookup(spec, "components", "schemas", typ.Name(), "properties").(map[string]any)
+		if schema == nil {
+			t.Errorf("%s has no schema", typ.Name())
+			continue
+		}
+		var want, got []string
+		for i := range typ.NumField() {
+			want = append(want, strings.Split(typ.Field(i).Tag.Get("json"), ",")[0])
+		}
+		for name := range schema {
+			got = append(got, name)
+		}
+		slices.Sort(want)
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: schema has %v, DTO has %v", typ.Name(), got, want)
+		}
+	}
+}
+
+// contractClient sends requests to the real router and checks each
+// response against the operation the spec documents for it.
+type contractClient struct {
+	t       *testing.T
+	spec    map[string]any
+	app     *app.Application
+	handler http.Handler
+	header  http.Header     // sent with every request
+	called  map[string]bool // "get /users" for every operation called
+}
+
+// call sends body to url, which must match route, and returns the decoded
+// response body.
+func (c *contractClient) call(method, route, url string, body any, wantStatus int) map[string]any {
+	c.t.Helper()
+	var reqBody io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatalf("marshal: %v", err)
+		}
+		reqBody = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, url, reqBody)
+	maps.Copy(req.Header, c.header)
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+	c.called[strings.ToLower(method)+" "+route] = true
+
+	if rec.Code != wantStatus {
+		c.t.Fatalf("%s %s: status %d, want %d: %s", method, url, rec.Code, wantStatus, rec.Body)
+	}
+	op, _ := lookup(c.spec, "paths", route, strings.ToLower(method)).(map[string]any)
+	if op == nil {
+		c.t.Fatalf("%s %s is not documented", method, route)
+	}
+	resp, _ := lookup(op, "responses", strconv.Itoa(rec.Code)).(map[string]any)
+	if resp == nil {
+		c.t.Fatalf("%s %s: status %d is not documented", method, route, rec.Code)
+	}
+	resp = resolve(c.spec, resp)
+	schema, _ := lookup(resp, "content", "application/json", "schema").(map[string]any)
+	if schema == nil {
+		if rec.Body.Len() > 0 {
+			c.t.Errorf("%s %s: undocumented body %s", method, route, rec.Body)
+		}
+		return nil
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		c.t.Fatalf("%s %s: decode: %v", method, route, err)
+	}
+	for _, msg := range validate(c.spec, schema, got, "body") {
+		c.t.Errorf("%s %s %d: %s", method, route, rec.Code, msg)
+	}
+	return got
+}
+
+// testAdminToken is the admin token of the contract client's server.
+const testAdminToken = "test-admin"
+
+func newContractClient(t *testing.T) *contractClient {
+	repos := repotest.New()
+	cfg := config.Default()
+	cfg.AdminToken = testAdminToken
+	a := app.Wire(slog.Default(), cfg, app.Repos{
+		Users:       repos.Users,
+		Songs:       repos.Songs,
+		Collections: repos.Collections,
+		Courses:     repos.Courses,
+		Enrollments: repos.Enrollments,
+		Lessons:     repos.Lessons,
+		Mastery:     repos.Mastery,
+		Progress:    repos.Progress,
+		Boards:      repos.Boards,
+		Groups:      repos.Groups,
+		Assignments: repos.Assignments,
+		Challenges:  repos.Challenges,
+		Idempotency: repos.Idempotency,
+	}, nil)
+	return &contractClient{
+		t:       t,
+		spec:    loadSpec(t),
+		app:     a,
+		handler: New(a, cfg),
+		header:  http.Header{},
+		called:  map[string]bool{},
+	}
+}
+
+// id returns the id field of a created resource.
+func id(body map[string]any) string {
+	return lookup(body, "data", "id").(string)
+}
+
+func TestHandlersMatchOpenAPI(t *testing.T) {
+	c := newContractClient(t)
+
+	user := c.call("POST", "/users", "/api/v1/users", contracts.CreateUserDto{Name: "Ada"}, http.StatusCreated)
+	userId := id(user)
+	opponentId := id(c.call("POST", "/users", "/api/v1/users", contracts.CreateUserDto{Name: "Bob"}, http.StatusCreated))
+	studentId := id(c.call("POST", "/users", "/api/v1/users", contracts.CreateUserDto{Name: "Cy"}, http.StatusCreated))
+	c.call("GET", "/users", "/api/v1/users", nil, http.StatusOK)
+
+	lyrics := "When you try your best but you don't succeed\n" +
+		"When you get what you want but not what you need\n" +
+		"When you feel so tired but you can't sleep\n" +
+		"Stuck in reverse\n" +
+		"And the tears come streaming down your face\n" +
+		"When you lose something you can't replace\n"
+	createSong := contracts.CreateSongDto{Title: "Fix You", Artist: "Coldplay", Lyrics: lyrics}
+	c.call("POST", "/songs", "/api/v1/songs", createSong, http.StatusForbidden)
+	c.header.Set("X-Admin-Token", testAdminToken)
+	song := c.call("POST", "/songs", "/api/v1/songs", createSong, http.StatusCreated)
+	c.header.Del("X-Admin-Token")
+	if got := lookup(song, "data", "lineCount"); got != float64(6) {
+		t.Errorf("lineCount = %v, want 6", got)
+	}
+	songId := id(song)
+	c.call("GET", "/songs", "/api/v1/songs", nil, http.StatusOK)
+	metadata := contracts.UpdateSongMetadataDto{Tags: []string{"ballad"}, Language: "en"}
+	collection := contracts.CreateCollectionDto{Name: "Ballads", SongIds: []string{songId}}
+	c.call("PUT", "/songs/{songId}/metadata", "/api/v1/songs/"+songId+"/metadata", metadata, http.StatusForbidden)
+	c.call("POST", "/collections", "/api/v1/collections", collection, http.StatusForbidden)
+
+	c.header.Set("X-Admin-Token", testAdminToken)
+	c.call("PUT", "/songs/{songId}/metadata", "/api/v1/songs/"+songId+"/metadata", metadata, http.StatusOK)
+	collectionId := id(c.call("POST", "/collections", "/api/v1/collections", collection, http.StatusCreated))
+	collection.Description = "Slow ones"
+	c.call("PUT", "/collections/{collectionId}", "/api/v1/collections/"+collectionId, collection, http.StatusOK)
+	c.header.Del("X-Admin-Token")
+
+	c.call("PUT", "/collections/{collectionId}", "/api/v1/collections/"+collectionId, collection, http.StatusForbidden)
+	c.call("GET", "/collections", "/api/v1/collections", nil, http.StatusOK)
+	c.call("GET", "/collections/{collectionId}", "/api/v1/collections/"+collectionId, nil, http.StatusOK)
+
+	course := contracts.CreateCourseDto{Name: "Coldplay 101", SongIds: []string{songId}}
+	c.call("POST", "/courses", "/api/v1/courses", course, http.StatusForbidden)
+	c.header.Set("X-Admin-Token", testAdminToken)
+	courseId := id(c.call("POST", "/courses", "/api/v1/courses", course, http.StatusCreated))
+	c.header.Del("X-Admin-Token")
+	c.call("GET", "/courses", "/api/v1/courses", nil, http.StatusOK)
+	c.call("GET", "/courses/{courseId}", "/api/v1/courses/"+courseId, nil, http.StatusOK)
+	course.UnlockAccuracy = 70
+	c.call("PUT", "/courses/{courseId}", "/api/v1/courses/"+courseId, course, http.StatusForbidden)
+	c.header.Set("X-Admin-Token", testAdminToken)
+	c.call("PUT", "/courses/{courseId}", "/api/v1/courses/"+courseId, course, http.StatusOK)
+	c.header.Del("X-Admin-Token")
+	c.call("POST", "/courses/{courseId}/enrollments", "/api/v1/courses/"+courseId+"/enrollments", contracts.EnrollDto{UserId: userId}, http.StatusCreated)
+	c.call("GET", "/courses/{courseId}/enrollments/{userId}", "/api/v1/courses/"+courseId+"/enrollments/"+userId, nil, http.StatusOK)
+
+	lesson := c.call("POST", "/lessons", "/api/v1/lessons", contracts.CreateLessonDto{UserId: userId}, http.StatusCreated)
+	lessonId := lookup(lesson, "data", "lessonId").(string)
+	c.call("POST", "/lessons", "/api/v1/lessons", contracts.CreateLessonDto{UserId: userId, Resume: true}, http.StatusOK)
+
+	items := lookup(lesson, "data", "items").([]any)
+	arrange := slices.IndexFunc(items, func(it any) bool { return lookup(it, "type") == "arrange" })
+	if arrange < 0 {
+		t.Fatal("lesson has no arrange item")
+	}
+	answer := contracts.SubmitAnswerDto{LessonId: lessonId, ItemIndex: arrange, Type: "arrange"}
+	c.call("POST", "/answers", "/api/v1/answers", answer, http.StatusOK)
+
+	c.call("GET", "/lessons/{lessonId}", "/api/v1/lessons/"+lessonId, nil, http.StatusOK)
+	c.call("GET", "/lessons/{lessonId}/summary", "/api/v1/lessons/"+lessonId+"/summary", nil, http.StatusOK)
+	retryRoute := "/lessons/{lessonId}/retry"
+	open := c.call("POST", retryRoute, "/api/v1/lessons/"+lessonId+"/retry", contracts.RetryLessonDto{UserId: userId}, http.StatusConflict)
+	if got := lookup(open, "error", "openLessonId"); got != lessonId {
+		t.Errorf("openLessonId = %v, want %s", got, lessonId)
+	}
+	c.call("POST", retryRoute, "/api/v1/lessons/"+lessonId+"/retry", contracts.RetryLessonDto{UserId: userId, Resume: true}, http.StatusOK)
+	retry := c.call("POST", retryRoute, "/api/v1/lessons/"+lessonId+"/retry", contracts.RetryLessonDto{UserId: userId, Restart: true}, http.StatusCreated)
+	retryId := lookup(retry, "data", "lessonId").(string)
+	open = c.call("POST", "/lessons", "/api/v1/lessons", contracts.CreateLessonDto{UserId: userId}, http.StatusConflict)
+	if got := lookup(open, "error", "openLessonId"); got != retryId {
+		t.Errorf("openLessonId = %v, want %s", got, retryId)
+	}
+	c.call("POST", "/lessons/{lessonId}/abandon", "/api/v1/lessons/"+retryId+"/abandon", nil, http.StatusNoContent)
+	c.call("POST", "/lessons", "/api/v1/lessons", contracts.CreateLessonDto{UserId: userId}, http.StatusCreated)
+	c.call("POST", "/lessons", "/api/v1/lessons", contracts.CreateLessonDto{UserId: userId, Restart: true}, http.StatusCreated)
+
+	c.call("GET", "/users/{userId}/lessons", "/api/v1/users/"+userId+"/lessons", nil, http.StatusOK)
+	c.call("GET", "/users/{userId}/stats", "/api/v1/users/"+userId+"/stats", nil, http.StatusOK)
+	c.call("GET", "/users/{userId}/words", "/api/v1/users/"+userId+"/words", nil, http.StatusOK)
+	c.call("GET", "/users/{userId}/progress", "/api/v1/users/"+userId+"/progress", nil, http.StatusOK)
+	c.call("PUT", "/users/{userId}/progress/settings", "/api/v1/users/"+userId+"/progress/settings",
+		contracts.UpdateProgressSettingsDto{Timezone: "Asia/Jerusalem", DailyGoalXP: 30}, http.StatusOK)
+	c.call("GET", "/songs/{songId}/analytics", "/api/v1/songs/"+songId+"/analytics", nil, http.StatusForbidden)
+	c.header.Set("X-Admin-Token", testAdminToken)
+	c.call("GET", "/songs/{songId}/analytics", "/api/v1/songs/"+songId+"/analytics", nil, http.StatusOK)
+	c.header.Del("X-Admin-Token")
+
+	groupId := id(c.call("POST", "/groups", "/api/v1/groups", contracts.CreateGroupDto{Name: "5B", TeacherId: userId}, http.StatusCreated))
+	if got := lookup(c.call("GET", "/groups/{groupId}", "/api/v1/groups/"+groupId, nil, http.StatusOK), "data", "teacherId"); got != nil {
+		t.Errorf("group shows teacherId %v to a non-admin", got)
+	}
+	c.header.Set("X-Admin-Token", testAdminToken)
+	if got := lookup(c.call("GET", "/groups/{groupId}", "/api/v1/groups/"+groupId, nil, http.StatusOK), "data", "teacherId"); got != userId {
+		t.Errorf("group shows teacherId %v to an admin, want %s", got, userId)
+	}
+	c.header.Del("X-Admin-Token")
+	c.call("POST", "/groups/{groupId}/students", "/api/v1/groups/"+groupId+"/students", contracts.AddGroupMemberDto{TeacherId: userId, UserId: studentId}, http.StatusCreated)
+	assignment := contracts.CreateAssignmentDto{TeacherId: userId, SongId: songId, DueAt: time.Now().Add(24 * time.Hour)}
+	c.call("POST", "/groups/{groupId}/assignments", "/api/v1/groups/"+groupId+"/assignments", assignment, http.StatusCreated)
+	c.call("GET", "/groups/{groupId}/assignments", "/api/v1/groups/"+groupId+"/assignments?teacherId="+userId, nil, http.StatusOK)
+	c.call("GET", "/groups/{groupId}/dashboard", "/api/v1/groups/"+groupId+"/dashboard?teacherId="+userId, nil, http.StatusOK)
+
+	challenge := contracts.CreateChallengeDto{ChallengerId: userId, OpponentId: opponentId, SongId: songId}
+	challengeId := id(c.call("POST", "/challenges", "/api/v1/challenges", challenge, http.StatusCreated))
+	c.call("POST", "/challenges/{challengeId}/accept", "/api/v1/challenges/"+challengeId+"/accept", contracts.ChallengeActionDto{UserId: opponentId}, http.StatusOK)
+	c.call("GET", "/challenges/{challengeId}/result", "/api/v1/challenges/"+challengeId+"/result", nil, http.StatusOK)
+	declinedId := id(c.call("POST", "/challenges", "/api/v1/challenges", challenge, http.StatusCreated))
+	c.call("POST", "/challenges/{challengeId}/decline", "/api/v1/challenges/"+declinedId+"/decline", contracts.ChallengeActionDto{UserId: opponentId}, http.StatusOK)
+	c.call("GET", "/users/{userId}/challenges", "/api/v1/users/"+userId+"/challenges", nil, http.StatusOK)
+
+	c.call("GET", "/leaderboards/{kind}", "/api/v1/leaderboards/alltime?userId="+userId, nil, http.StatusOK)
+	c.call("GET", "/leaderboards/{kind}", "/api/v1/leaderboards/song?songId="+songId, nil, http.StatusOK)
+
+	// Error replies share one schema.
+	c.call("POST", "/users", "/api/v1/users", contracts.CreateUserDto{}, http.StatusBadRequest)
+	c.call("POST", "/answers", "/api/v1/answers", map[string]any{"lessonId": lessonId, "type": "arrange", "bogus": true}, http.StatusBadRequest)
+	c.call("GET", "/lessons/{lessonId}", "/api/v1/lessons/missing", nil, http.StatusNotFound)
+	c.call("GET", "/users/{userId}/stats", "/api/v1/users/missing/stats", nil, http.StatusNotFound)
+	c.call("POST", "/lessons", "/api/v1/lessons", contracts.CreateLessonDto{UserId: "missing"}, http.StatusNotFound)
+	c.call("GET", "/songs", "/api/v1/songs?limit=-1", nil, http.StatusBadRequest)
+	c.call("GET", "/leaderboards/{kind}", "/api/v1/leaderboards/weekly?week=last", nil, http.StatusBadRequest)
+	c.call("GET", "/groups/{groupId}/dashboard", "/api/v1/groups/"+groupId+"/dashboard?teacherId="+studentId, nil, http.StatusForbidden)
+	c.call("POST", "/groups/{groupId}/students", "/api/v1/groups/"+groupId+"/students", contracts.AddGroupMemberDto{TeacherId: userId, UserId: studentId}, http.StatusConflict)
+	c.call("POST", "/groups/{groupId}/students", "/api/v1/groups/"+groupId+"/students", contracts.AddGroupMemberDto{TeacherId: studentId, UserId: opponentId}, http.StatusForbidden)
+	c.call("GET", "/groups/{groupId}/assignments", "/api/v1/groups/"+groupId+"/assignments?teacherId="+studentId, nil, http.StatusForbidden)
+
+	// The spec describes itself.
+	c.call("GET", "/openapi.json", "/api/v1/openapi.json", nil, http.StatusOK)
+
+	for route, ops := range c.spec["paths"].(map[string]any) {
+		for method := range ops.(map[string]any) {
+			if !c.called[method+" "+route] {
+				t.Errorf("%s %s is never called", strings.ToUpper(method), route)
+			}
+		}
+	}
+}
+
+func TestLegacyPrefixMirrorsV1(t *testing.T) {
+	c := newContractClient(t)
+	c.call("POST", "/users", "/api/v1/users", contracts.CreateUserDto{Name: "Ada"}, http.StatusCreated)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		c.handler.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		return rec
+	}
+	v1, legacy := get("/api/v1/users?limit=10"), get("/api/users?limit=10")
+
+	if legacy.Code != v1.Code || legacy.Body.String() != v1.Body.String() {
+		t.Errorf("legacy reply %d %s differs from v1 %d %s", legacy.Code, legacy.Body, v1.Code, v1.Body)
+	}
+	if got := legacy.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("legacy Deprecation = %q, want true", got)
+	}
+	if got := v1.Header().Get("Deprecation"); got != "" {
+		t.Errorf("v1 Deprecation = %q, want none", got)
+	}
+}
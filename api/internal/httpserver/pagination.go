@@ -0,0 +1,54 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/contracts"
+)
+
+// queryInt reads an optional integer query parameter.
+func queryInt(r *http.Request, key string) (int, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, invalidParam(key, "must be a non-negative integer")
+	}
+	return n, nil
+}
+
+// queryTime reads an optional RFC 3339 timestamp or YYYY-MM-DD date (UTC
+// midnight) query parameter.
+func queryTime(r *http.Request, key string) (time.Time, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, invalidParam(key, "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+}
+
+// writePage replies with one page of a list. The cursor of the next page
+// goes both into meta and into a Link header; on the last page meta has no
+// cursor and there is no Link.
+func writePage(app *app.Application, w http.ResponseWriter, r *http.Request, data any, next string) {
+	if next != "" {
+		u := *r.URL
+		q := u.Query()
+		q.Set("cursor", next)
+		u.RawQuery = q.Encode()
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI()))
+	}
+	app.WritePage(w, http.StatusOK, data, contracts.Meta{NextCursor: next})
+}
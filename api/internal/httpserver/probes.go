@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/contracts"
+)
+
+// readinessTimeout bounds each dependency check of /readyz.
+const readinessTimeout = 2 * time.Second
+
+// Liveness answers /healthz: the process is up and serving. It checks no
+// dependency, so that a database outage, which a restart would not fix, does
+// not get the instance restarted.
+func Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+}
+
+// Readiness answers /readyz with the state of every dependency, and 503 when
+// any is down so that load balancers stop sending the instance traffic. The
+// probe is public, so failures are logged and only reported by code.
+func Readiness(app *app.Application) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := contracts.ReadinessResponse{Status: "ok", Checks: map[string]contracts.DependencyStatus{}}
+		var (
+			mu sync.Mutex
+			wg sync.WaitGroup
+		)
+		for _, check := range app.Checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+				defer cancel()
+				start := time.Now()
+				err := check.Run(ctx)
+
+				st := contracts.DependencyStatus{Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+				if err != nil {
+					st.Status, st.Code = "down", "unreachable"
+					if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) {
+						st.Code = "timeout"
+					}
+					app.Logger().WarnContext(r.Context(), "readiness check failed", "check", check.Name, "code", st.Code, "err", err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				resp.Checks[check.Name] = st
+				if err != nil {
+					resp.Status = "unavailable"
+				}
+			}()
+		}
+		wg.Wait()
+
+		status := http.StatusOK
+		if resp.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		writeProbe(w, status, resp)
+	})
+}
+
+func writeProbe(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,71 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/contracts"
+)
+
+func TestReadiness(t *testing.T) {
+	up := app.Check{Name: "up", Run: func(context.Context) error { return nil }}
+	down := app.Check{Name: "down", Run: func(context.Context) error { return errors.New("connection refused") }}
+	hung := app.Check{Name: "hung", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	cases := []struct {
+		name     string
+		checks   []app.Check
+		status   int
+		statuses map[string]string
+		codes    map[string]string
+	}{
+		{"all up", []app.Check{up}, http.StatusOK, map[string]string{"up": "ok"}, map[string]string{"up": ""}},
+		{"one down", []app.Check{up, down}, http.StatusServiceUnavailable,
+			map[string]string{"up": "ok", "down": "down"}, map[string]string{"up": "", "down": "unreachable"}},
+		{"timeout", []app.Check{hung}, http.StatusServiceUnavailable, map[string]string{"hung": "down"}, map[string]string{"hung": "timeout"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			Readiness(&app.Application{Checks: tc.checks}).ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+			if elapsed := time.Since(start); elapsed > readinessTimeout+time.Second {
+				t.Errorf("took %v, want at most the check timeout", elapsed)
+			}
+
+			if rec.Code != tc.status {
+				t.Errorf("status %d, want %d", rec.Code, tc.status)
+			}
+			var body contracts.ReadinessResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+			for name, want := range tc.statuses {
+				if got := body.Checks[name].Status; got != want {
+					t.Errorf("%s: status %q, want %q", name, got, want)
+				}
+			}
+			for name, want := range tc.codes {
+				if got := body.Checks[name].Code; got != want {
+					t.Errorf("%s: code %q, want %q", name, got, want)
+				}
+			}
+			if strings.Contains(rec.Body.String(), "connection refused") {
+				t.Errorf("reply leaks the driver error: %s", rec.Body)
+			}
+			if tc.status == http.StatusOK && body.Status != "ok" || tc.status != http.StatusOK && body.Status != "unavailable" {
+				t.Errorf("overall status %q for reply %d", body.Status, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package httpserver
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/services"
+)
+
+// rateLimit limits requests of a route group per client address. The API
+// has no authenticated identity to key on: a userId in the path or body is
+// whatever the client sends. Over the limit it replies 429 with a Retry-After
+// header. Groups without a configured limit pass through.
+func rateLimit(app *app.Application, group string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if app.RateLimitSvc == nil {
+			return next
+		}
+		limit, ok := app.RateLimitSvc.Limit(group)
+		if !ok {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := app.RateLimitSvc.Take(r.Context(), group, clientAddr(app, r))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(d.Remaining, 0)))
+
+			if !d.Allowed {
+				seconds := max(int(math.Ceil(d.RetryAfter.Seconds())), 1)
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				writeError(app, w, r, &services.Error{
+					Kind:    services.ErrRateLimited,
+					Message: "too many requests, retry in " + strconv.Itoa(seconds) + "s",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
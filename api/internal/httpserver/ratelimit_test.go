@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.tomerab1/todo-api/internal/config"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+	"github.tomerab1/todo-api/internal/services"
+)
+
+func TestRateLimit(t *testing.T) {
+	c := newContractClient(t)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limits := map[string]models.RateLimit{
+		"api":     {Rate: 100, Burst: 100},
+		"lessons": {Rate: 0.5, Burst: 2}, // one request every 2s
+	}
+	c.app.RateLimitSvc = services.NewRateLimitService(
+		repositories.NewRateLimitRepoMemory(), limits, slog.New(slog.NewTextHandler(io.Discard, nil)),
+	).WithClock(func() time.Time { return now })
+	c.handler = New(c.app, config.Default())
+
+	send := func(addr, auth, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/lessons", strings.NewReader(body))
+		r.RemoteAddr = addr + ":1234"
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		c.handler.ServeHTTP(rec, r)
+		return rec
+	}
+	limited := func(rec *httptest.ResponseRecorder) bool {
+		t.Helper()
+		if rec.Code != http.StatusTooManyRequests {
+			return false
+		}
+		if !strings.Contains(rec.Body.String(), `"rate_limited"`) {
+			t.Errorf("429 body %s lacks the rate_limited code", rec.Body)
+		}
+		return true
+	}
+
+	// Per address: two at once, then wait.
+	for i := range 2 {
+		if rec := send("10.0.0.1", "", `{"userId":"missing"}`); limited(rec) {
+			t.Fatalf("request %d limited within the burst", i+1)
+		}
+	}
+	rec := send("10.0.0.1", "", `{}`)
+	if !limited(rec) {
+		t.Fatalf("third request: status %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if rec := send("10.0.0.2", "", `{}`); limited(rec) {
+		t.Error("another address was limited")
+	}
+	now = now.Add(2 * time.Second)
+	if rec := send("10.0.0.1", "", `{}`); limited(rec) {
+		t.Error("still limited after the bucket refilled")
+	}
+
+	// Only the address counts: neither credentials nor a body userId carry
+	// a bucket to another address, as the API authenticates no one.
+	for _, addr := range []string{"10.0.1.1", "10.0.1.2", "10.0.1.3"} {
+		if rec := send(addr, "Bearer ada", `{"userId":"grace"}`); limited(rec) {
+			t.Errorf("request from %s limited by requests from other addresses", addr)
+		}
+	}
+
+	// Behind a trusted proxy each forwarded client gets its own bucket.
+	c.app.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.9.0.0/16")}
+	viaProxy := func(client string) *httptest.ResponseRecorder {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/lessons", strings.NewReader(`{}`))
+		r.RemoteAddr = "10.9.0.1:443"
+		r.Header.Set("X-Forwarded-For", client)
+		rec := httptest.NewRecorder()
+		c.handler.ServeHTTP(rec, r)
+		return rec
+	}
+	viaProxy("203.0.113.1")
+	viaProxy("203.0.113.1")
+	if rec := viaProxy("203.0.113.1"); !limited(rec) {
+		t.Errorf("forwarded client over the limit: status %d, want 429", rec.Code)
+	}
+	if rec := viaProxy("203.0.113.2"); limited(rec) {
+		t.Error("another client behind the same proxy was limited")
+	}
+
+	// The body still reaches the handler.
+	if rec := send("10.0.3.1", "", `{"userId":"missing"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("handler got status %d, want 404 for the unknown user: %s", rec.Code, rec.Body)
+	}
+}
@@ -1,45 +1,107 @@
 package httpserver
 
 import (
-	"time"
+	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.tomerab1/todo-api/internal/app"
+	"github.tomerab1/todo-api/internal/config"
 )
 
-func New(app *app.Application) *chi.Mux {
+func New(app *app.Application, cfg *config.Config) *chi.Mux {
 	r := chi.NewRouter()
 
+	r.Use(requestLogger(app))
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(5 * time.Second))
+	r.Use(middleware.Timeout(cfg.RequestTimeout))
 	r.Use(commonHeadersMiddleware)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"},
+		AllowedOrigins:   cfg.CORSOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Admin-Token", "Idempotency-Key", "X-Request-Id", "traceparent"},
+		ExposedHeaders:   []string{"Link", "Deprecation", "Idempotent-Replayed", "X-Request-Id", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
 	api := chi.NewRouter()
+	api.Use(rateLimit(app, "api"))
+
+	api.Get("/openapi.json", getOpenAPI(app))
 
 	api.Route("/users", func(r chi.Router) {
-		r.Post("/", createUser(app))
+		r.With(idempotent(app, "createUser")).Post("/", createUser(app))
 		r.Get("/", getUsers(app))
+		r.Get("/{userId}/stats", userStats(app))
+		r.Get("/{userId}/words", userWords(app))
+		r.Get("/{userId}/lessons", userLessons(app))
+		r.Get("/{userId}/progress", userProgress(app))
+		r.Put("/{userId}/progress/settings", updateProgressSettings(app))
+		r.Get("/{userId}/challenges", userChallenges(app))
 	})
 
 	api.Route("/songs", func(r chi.Router) {
-		r.Post("/", createSong(app))
+		r.With(adminOnly(app), idempotent(app, "createSong")).Post("/", createSong(app))
 		r.Get("/", getSongs(app))
+		r.With(adminOnly(app)).Put("/{songId}/metadata", updateSongMetadata(app))
+		r.With(adminOnly(app)).Get("/{songId}/analytics", songAnalytics(app))
+	})
+
+	api.Route("/collections", func(r chi.Router) {
+		r.With(adminOnly(app)).Post("/", createCollection(app))
+		r.Get("/", getCollections(app))
+		r.Get("/{collectionId}", getCollection(app))
+		r.With(adminOnly(app)).Put("/{collectionId}", updateCollection(app))
+	})
+
+	api.Route("/courses", func(r chi.Router) {
+		r.With(adminOnly(app)).Post("/", createCourse(app))
+		r.Get("/", getCourses(app))
+		r.Get("/{courseId}", getCourse(app))
+		r.With(adminOnly(app)).Put("/{courseId}", updateCourse(app))
+		r.Post("/{courseId}/enrollments", enrollInCourse(app))
+		r.Get("/{courseId}/enrollments/{userId}", courseProgress(app))
+	})
+
+	api.Route("/groups", func(r chi.Router) {
+		r.Post("/", createGroup(app))
+		r.Get("/{groupId}", getGroup(app))
+		r.Post("/{groupId}/students", addGroupStudent(app))
+		r.Post("/{groupId}/assignments", createAssignment(app))
+		r.Get("/{groupId}/assignments", getAssignments(app))
+		r.Get("/{groupId}/dashboard", groupDashboard(app))
 	})
 
-	api.Post("/lessons", createLesson(app))
-	api.Post("/answers", submitAnswer(app))
+	api.Route("/challenges", func(r chi.Router) {
+		r.Post("/", createChallenge(app))
+		r.Post("/{challengeId}/accept", acceptChallenge(app))
+		r.Post("/{challengeId}/decline", declineChallenge(app))
+		r.Get("/{challengeId}/result", challengeResult(app))
+	})
+
+	api.Get("/leaderboards/{kind}", getLeaderboard(app))
+
+	api.With(rateLimit(app, "lessons"), idempotent(app, "createLesson")).Post("/lessons", createLesson(app))
+	api.With(rateLimit(app, "answers")).Post("/answers", submitAnswer(app))
+	api.Get("/lessons/{lessonId}", getLesson(app))
+	api.With(rateLimit(app, "lessons")).Post("/lessons/{lessonId}/retry", retryLesson(app))
+	api.Post("/lessons/{lessonId}/abandon", abandonLesson(app))
 	api.Get("/lessons/{lessonId}/summary", lessonSummary(app))
 
-	r.Mount("/api", api)
+	r.Mount("/api/v1", api)
+	r.Mount("/api", legacyAPI(api))
 	return r
 }
+
+// legacyAPI serves the unversioned /api prefix the UI was built against.
+// It answers exactly like /api/v1 but flags every response as deprecated so
+// clients know to move.
+func legacyAPI(api http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", `</api/v1>; rel="successor-version"`)
+		api.ServeHTTP(w, r)
+	})
+}
@@ -1,10 +1,9 @@
 package httpserver
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
 	"github.tomerab1/todo-api/internal/app"
 	"github.tomerab1/todo-api/internal/contracts"
 )
@@ -12,14 +11,14 @@ import (
 func createSong(app *app.Application) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var dto contracts.CreateSongDto
-		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err))
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
 			return
 		}
 
 		resp, err := app.SongSvc.CreateSong(r.Context(), dto)
 		if err != nil {
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("failed to create song: %v", err))
+			writeError(app, w, r, err)
 			return
 		}
 
@@ -29,12 +28,67 @@ func createSong(app *app.Application) http.HandlerFunc {
 
 func getSongs(app *app.Application) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		songs, err := app.SongSvc.GetAllSongs(r.Context())
+		q := r.URL.Query()
+		query := contracts.ListSongsQuery{
+			Query:      q.Get("q"),
+			Artist:     q.Get("artist"),
+			Tag:        q.Get("tag"),
+			Genre:      q.Get("genre"),
+			Language:   q.Get("language"),
+			Difficulty: q.Get("difficulty"),
+			Sort:       q.Get("sort"),
+			Cursor:     q.Get("cursor"),
+		}
+		var err error
+		if query.MinLines, err = queryInt(r, "minLines"); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+		if query.MaxLines, err = queryInt(r, "maxLines"); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+		if query.Limit, err = queryInt(r, "limit"); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		songs, next, err := app.SongSvc.ListSongs(r.Context(), query)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		writePage(app, w, r, songs, next)
+	}
+}
+
+func updateSongMetadata(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.UpdateSongMetadataDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		resp, err := app.SongSvc.UpdateMetadata(r.Context(), chi.URLParam(r, "songId"), dto)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func songAnalytics(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := app.StatsSvc.GetSongAnalytics(r.Context(), chi.URLParam(r, "songId"))
 		if err != nil {
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("failed to get songs: %v", err))
+			writeError(app, w, r, err)
 			return
 		}
 
-        app.WriteJSON(w, http.StatusOK, songs)
+		app.WriteJSON(w, http.StatusOK, resp)
 	}
 }
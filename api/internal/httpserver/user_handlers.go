@@ -1,10 +1,9 @@
 package httpserver
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
 	"github.tomerab1/todo-api/internal/app"
 	"github.tomerab1/todo-api/internal/contracts"
 )
@@ -12,29 +11,102 @@ import (
 func createUser(app *app.Application) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var dto contracts.CreateUserDto
-		if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err))
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
 			return
 		}
 
-		resp, err := app.UserSvc.CreateUser(r.Context(), dto)
+		id, err := app.UserSvc.CreateUser(r.Context(), dto)
 		if err != nil {
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("failed to create user: %v", err))
+			writeError(app, w, r, err)
 			return
 		}
+		setLogUser(r, id)
 
-		app.WriteJSON(w, http.StatusCreated, resp)
+		app.WriteJSON(w, http.StatusCreated, contracts.CreateUserResponse{Id: id})
 	}
 }
 
 func getUsers(app *app.Application) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		users, err := app.UserSvc.GetAllUsers(r.Context())
+		q := r.URL.Query()
+		query := contracts.ListUsersQuery{
+			Query:  q.Get("q"),
+			Sort:   q.Get("sort"),
+			Cursor: q.Get("cursor"),
+		}
+		var err error
+		if query.Limit, err = queryInt(r, "limit"); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		users, next, err := app.UserSvc.ListUsers(r.Context(), query, isAdmin(app, r))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		writePage(app, w, r, users, next)
+	}
+}
+
+func userStats(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := app.StatsSvc.GetUserStats(r.Context(), chi.URLParam(r, "userId"), r.URL.Query().Get("tz"))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, stats)
+	}
+}
+
+func userWords(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, err := queryInt(r, "limit")
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		words, err := app.StatsSvc.GetUserWords(r.Context(), chi.URLParam(r, "userId"), r.URL.Query().Get("sort"), limit)
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, words)
+	}
+}
+
+func userProgress(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		progress, err := app.ProgressSvc.GetProgress(r.Context(), chi.URLParam(r, "userId"))
+		if err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		app.WriteJSON(w, http.StatusOK, progress)
+	}
+}
+
+func updateProgressSettings(app *app.Application) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dto contracts.UpdateProgressSettingsDto
+		if err := decodeBody(w, r, &dto); err != nil {
+			writeError(app, w, r, err)
+			return
+		}
+
+		progress, err := app.ProgressSvc.UpdateSettings(r.Context(), chi.URLParam(r, "userId"), dto)
 		if err != nil {
-			app.WriteErrorJSON(w, http.StatusBadRequest, fmt.Sprintf("failed to get users: %v", err))
+			writeError(app, w, r, err)
 			return
 		}
 
-        app.WriteJSON(w, http.StatusOK, users)
+		app.WriteJSON(w, http.StatusOK, progress)
 	}
 }
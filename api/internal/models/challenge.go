@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+type ChallengeStatus = string
+
+const (
+	ChallengeStatusPending   ChallengeStatus = "pending"   // waiting for the opponent to accept
+	ChallengeStatusAccepted  ChallengeStatus = "accepted"  // both may play
+	ChallengeStatusDeclined  ChallengeStatus = "declined"  // the opponent said no
+	ChallengeStatusCompleted ChallengeStatus = "completed" // both finished, winner decided
+)
+
+type ChallengeRole = string
+
+const (
+	ChallengeRoleChallenger ChallengeRole = "challenger"
+	ChallengeRoleOpponent   ChallengeRole = "opponent"
+)
+
+// Challenge pits two users against each other on one song. Both lessons are
+// generated from Seed and LessonSize, so both players get the same blanks in
+// the same order with the same options.
+type Challenge struct {
+	Id           string          `bson:"_id,omitempty"`
+	ChallengerId string          `bson:"challenger_id"`
+	OpponentId   string          `bson:"opponent_id"`
+	SongId       string          `bson:"song_id"`
+	Seed         int64           `bson:"seed"`
+	LessonSize   int             `bson:"lesson_size,omitempty"` // 0 on challenges older than sizes
+	Status       ChallengeStatus `bson:"status"`
+	Challenger   ChallengeEntry  `bson:"challenger"`
+	Opponent     ChallengeEntry  `bson:"opponent"`
+	WinnerId     string          `bson:"winner_id,omitempty"` // empty on a draw
+	CreatedAt    time.Time       `bson:"created_at"`
+	AcceptedAt   time.Time       `bson:"accepted_at,omitempty"`
+	DeclinedAt   time.Time       `bson:"declined_at,omitempty"`
+	CompletedAt  time.Time       `bson:"completed_at,omitempty"`
+}
+
+// ChallengeEntry is one player's lesson and, once finished, its result.
+type ChallengeEntry struct {
+	LessonId        string    `bson:"lesson_id,omitempty"`
+	Finished        bool      `bson:"finished"`
+	Forfeited       bool      `bson:"forfeited,omitempty"` // abandoned the lesson instead of finishing it
+	Accuracy        float64   `bson:"accuracy"`
+	Correct         int       `bson:"correct"`
+	Wrong           int       `bson:"wrong"`
+	DurationSeconds float64   `bson:"duration_seconds"`
+	FinishedAt      time.Time `bson:"finished_at,omitempty"`
+}
+
+// RoleOf returns the role userId plays in the challenge, or "" for outsiders.
+func (c *Challenge) RoleOf(userId string) ChallengeRole {
+	switch userId {
+	case c.ChallengerId:
+		return ChallengeRoleChallenger
+	case c.OpponentId:
+		return ChallengeRoleOpponent
+	}
+	return ""
+}
+
+func (c *Challenge) Entry(role ChallengeRole) *ChallengeEntry {
+	if role == ChallengeRoleChallenger {
+		return &c.Challenger
+	}
+	return &c.Opponent
+}
+
+// Winner compares two finished entries: a forfeit loses, then higher
+// accuracy wins, then the faster lesson. Returns "" on a draw.
+func (c *Challenge) Winner() string {
+	a, b := c.Challenger, c.Opponent
+	switch {
+	case a.Forfeited && b.Forfeited:
+		return ""
+	case b.Forfeited:
+		return c.ChallengerId
+	case a.Forfeited:
+		return c.OpponentId
+	case a.Accuracy > b.Accuracy:
+		return c.ChallengerId
+	case b.Accuracy > a.Accuracy:
+		return c.OpponentId
+	case a.DurationSeconds < b.DurationSeconds:
+		return c.ChallengerId
+	case b.DurationSeconds < a.DurationSeconds:
+		return c.OpponentId
+	}
+	return ""
+}
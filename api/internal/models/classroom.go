@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Group is a class: one teacher and the students in it. Members are tracked
+// on the users themselves (User.Memberships).
+type Group struct {
+	Id        string    `bson:"_id,omitempty"`
+	Name      string    `bson:"name"`
+	TeacherId string    `bson:"teacher_id"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// Assignment asks the students of a group to practice a song, reaching
+// MinAccuracy (percent) in a completed lesson by DueAt.
+type Assignment struct {
+	Id          string    `bson:"_id,omitempty"`
+	GroupId     string    `bson:"group_id"`
+	SongId      string    `bson:"song_id"`
+	MinAccuracy float64   `bson:"min_accuracy"`
+	DueAt       time.Time `bson:"due_at"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+// AssignmentResult is one student's progress on an assignment, updated on
+// every completed assignment lesson.
+type AssignmentResult struct {
+	Id           string    `bson:"_id"`
+	AssignmentId string    `bson:"assignment_id"`
+	UserId       string    `bson:"user_id"`
+	Attempts     int       `bson:"attempts"`
+	BestAccuracy float64   `bson:"best_accuracy"`
+	PassedAt     time.Time `bson:"passed_at,omitempty"` // first lesson reaching MinAccuracy
+	UpdatedAt    time.Time `bson:"updated_at"`
+}
+
+func AssignmentResultId(assignmentId, userId string) string {
+	return assignmentId + ":" + userId
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultUnlockAccuracy is the lesson accuracy (percent) needed on a course
+// song to unlock the next one.
+const DefaultUnlockAccuracy = 80.0
+
+// Course is an ordered list of songs learners work through one at a time.
+type Course struct {
+	Id             string   `bson:"_id,omitempty" json:"id"`
+	Name           string   `bson:"name" json:"name"`
+	Description    string   `bson:"description,omitempty" json:"description,omitempty"`
+	SongIds        []string `bson:"song_ids" json:"songIds"`
+	UnlockAccuracy float64  `bson:"unlock_accuracy" json:"unlockAccuracy"`
+}
+
+// Enrollment tracks a user's progression through a course. Progress is kept
+// per song id, so reordering, adding or removing course songs keeps what the
+// learner already achieved on each song.
+type Enrollment struct {
+	Id           string             `bson:"_id,omitempty"`
+	UserId       string             `bson:"user_id"`
+	CourseId     string             `bson:"course_id"`
+	BestAccuracy map[string]float64 `bson:"best_by_song"`    // per song id
+	Passed       []string           `bson:"passed_song_ids"` // songs that reached the unlock accuracy
+	Completed    bool               `bson:"completed"`
+	EnrolledAt   time.Time          `bson:"enrolled_at"`
+	UpdatedAt    time.Time          `bson:"updated_at"`
+
+	// Enrollments stored before progress was keyed by song counted unlocked
+	// songs and kept accuracies by course position. Fit moves them onto the
+	// song ids of the course.
+	LegacyUnlocked int       `bson:"unlocked,omitempty"`
+	LegacyBest     []float64 `bson:"best_accuracy,omitempty"`
+}
+
+// EnrollmentId is the key of a user's enrollment in a course; a user enrolls
+// at most once per course.
+func EnrollmentId(userId, courseId string) string {
+	return userId + ":" + courseId
+}
+
+// HasPassed reports whether the learner reached the unlock accuracy on a
+// song.
+func (e *Enrollment) HasPassed(songId string) bool {
+	return slices.Contains(e.Passed, songId)
+}
+
+// Position is the index of the song the learner is currently working on: the
+// first course song not yet passed, or the last one once all are.
+func (e *Enrollment) Position(course *Course) int {
+	for i, songId := range course.SongIds {
+		if !e.HasPassed(songId) {
+			return i
+		}
+	}
+	return max(len(course.SongIds)-1, 0)
+}
+
+// RecordResult stores the accuracy of a finished lesson on a course song and
+// passes the song when it reaches the course threshold. Songs beyond the
+// current one, e.g. moved there since the lesson started, only keep their
+// accuracy.
+func (e *Enrollment) RecordResult(course *Course, songId string, accuracy float64) {
+	e.Fit(course)
+	step := slices.Index(course.SongIds, songId)
+	if step < 0 {
+		return
+	}
+	e.BestAccuracy[songId] = max(e.BestAccuracy[songId], accuracy)
+	if accuracy >= course.UnlockAccuracy && step <= e.Position(course) && !e.HasPassed(songId) {
+		e.Passed = append(e.Passed, songId)
+	}
+	e.Completed = e.passedAll(course)
+}
+
+// Fit brings progress in line with the course: legacy positional progress is
+// mapped onto the current song order, and Completed follows the song list.
+func (e *Enrollment) Fit(course *Course) {
+	if e.BestAccuracy == nil {
+		e.BestAccuracy = map[string]float64{}
+	}
+	if e.LegacyUnlocked > 0 || e.LegacyBest != nil {
+		for i, songId := range course.SongIds {
+			if i < len(e.LegacyBest) {
+				e.BestAccuracy[songId] = max(e.BestAccuracy[songId], e.LegacyBest[i])
+			}
+			if (i < e.LegacyUnlocked-1 || e.Completed) && !e.HasPassed(songId) {
+				e.Passed = append(e.Passed, songId)
+			}
+		}
+		e.LegacyUnlocked, e.LegacyBest = 0, nil
+	}
+	e.Completed = e.passedAll(course)
+}
+
+func (e *Enrollment) passedAll(course *Course) bool {
+	for _, songId := range course.SongIds {
+		if !e.HasPassed(songId) {
+			return false
+		}
+	}
+	return len(course.SongIds) > 0
+}
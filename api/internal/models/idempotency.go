@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// IdempotencyRecord remembers the reply to the first request sent with an
+// Idempotency-Key, so that retries of it get the same reply instead of
+// repeating its effects.
+type IdempotencyRecord struct {
+	Id          string    `bson:"_id"`         // see IdempotencyId
+	Fingerprint string    `bson:"fingerprint"` // hash of the operation and body
+	Status      int       `bson:"status"`      // 0 while the first request is in flight
+	Body        []byte    `bson:"body,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// IdempotencyId scopes a key to its caller, so callers cannot replay each
+// other's replies.
+func IdempotencyId(caller, key string) string {
+	return caller + ":" + key
+}
+
+// Done reports whether the first request finished and its reply is stored.
+func (r *IdempotencyRecord) Done() bool {
+	return r.Status != 0
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type LeaderboardKind = string
+
+const (
+	LeaderboardWeeklyXP  LeaderboardKind = "weekly"
+	LeaderboardAllTimeXP LeaderboardKind = "alltime"
+	LeaderboardSong      LeaderboardKind = "song" // best lesson accuracy per song
+)
+
+// LeaderboardEntry is one user's score on one board. Scope separates boards
+// of the same kind: the ISO week for weekly boards, the song id for song
+// boards, empty for all-time.
+//
+// Ranking is by Score descending, then ReachedAt ascending (whoever got there
+// first), then UserId, so ties always resolve the same way.
+type LeaderboardEntry struct {
+	Id        string          `bson:"_id"`
+	Kind      LeaderboardKind `bson:"kind"`
+	Scope     string          `bson:"scope"`
+	UserId    string          `bson:"user_id"`
+	Score     float64         `bson:"score"`
+	ReachedAt time.Time       `bson:"reached_at"`
+}
+
+func LeaderboardEntryId(kind LeaderboardKind, scope, userId string) string {
+	return kind + ":" + scope + ":" + userId
+}
+
+// WeekScope returns the ISO week of t in UTC, e.g. "2025-W03".
+func WeekScope(t time.Time) string {
+	year, week := t.UTC().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
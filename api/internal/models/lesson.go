@@ -10,13 +10,46 @@ const (
 	LessonTypeArrange    LessonType = "arrange"
 )
 
+type LessonStatus = string
+
+const (
+	LessonStatusCreated    LessonStatus = "created"
+	LessonStatusInProgress LessonStatus = "in_progress"
+	LessonStatusCompleted  LessonStatus = "completed"
+	LessonStatusAbandoned  LessonStatus = "abandoned"
+	LessonStatusExpired    LessonStatus = "expired"
+)
+
+// OpenLessonStatuses are the states a lesson can still be answered in.
+var OpenLessonStatuses = []LessonStatus{LessonStatusCreated, LessonStatusInProgress}
+
+// IsLessonOpen reports whether a lesson in status still accepts answers.
+// Lessons stored before statuses existed have none and stay open.
+func IsLessonOpen(status LessonStatus) bool {
+	return status == "" || status == LessonStatusCreated || status == LessonStatusInProgress
+}
+
 type Lesson struct {
-	Id        string       `bson:"_id,omitempty"  json:"lessonId"`
-	UserId    string       `bson:"user_id"        json:"-"`
-	SongId    string       `bson:"song_id"        json:"-"`
-	Items     []LessonItem `bson:"items"          json:"items"`
-    Answers   []LessonAnswer `bson:"answers"       json:"-"`
-	CreatedAt time.Time    `bson:"created_at"     json:"-"`
+	Id           string         `bson:"_id,omitempty"  json:"lessonId"`
+	UserId       string         `bson:"user_id"        json:"-"`
+	SongId       string         `bson:"song_id"        json:"-"`
+	Seed         *int64         `bson:"seed,omitempty" json:"-"` // drives song pick and items; nil on lessons older than seeds
+	Size         int            `bson:"size,omitempty" json:"-"` // item count Seed was generated with; 0 on lessons older than sizes
+	CourseId     string         `bson:"course_id,omitempty" json:"-"`
+	CourseStep   int            `bson:"course_step,omitempty" json:"-"` // position of SongId in the course when the lesson was created
+	RetryOf      string         `bson:"retry_of,omitempty" json:"-"`    // lesson whose items this one replays
+	AssignmentId string         `bson:"assignment_id,omitempty" json:"-"`
+	ChallengeId  string         `bson:"challenge_id,omitempty" json:"-"`
+	Difficulty   float64        `bson:"difficulty,omitempty" json:"-"` // song difficulty when the lesson was made
+	Items        []LessonItem   `bson:"items"          json:"items"`
+	Answers      []LessonAnswer `bson:"answers"       json:"-"`
+	CreatedAt    time.Time      `bson:"created_at"     json:"-"`
+	UpdatedAt    time.Time      `bson:"updated_at,omitempty" json:"-"` // time of the last answer
+	Status       LessonStatus   `bson:"status,omitempty" json:"-"`
+	StartedAt    time.Time      `bson:"started_at,omitempty" json:"-"`
+	CompletedAt  time.Time      `bson:"completed_at,omitempty" json:"-"`
+	AbandonedAt  time.Time      `bson:"abandoned_at,omitempty" json:"-"`
+	ExpiredAt    time.Time      `bson:"expired_at,omitempty" json:"-"`
 }
 
 type LessonItem struct {
@@ -27,9 +60,27 @@ type LessonItem struct {
 	CorrectWord  string     `bson:"correct_word" json:"correct_word"`
 }
 
+// LessonAnswer is a graded answer. It carries enough of the item it answers
+// (song, line, expected word) to be reprocessed without the lesson.
 type LessonAnswer struct {
-    ItemIndex int    `bson:"item_index"`
-    Type      string `bson:"type"`          // persist only if fillblanks
-    UserInput string `bson:"user_input"`    // chosen word
-    Correct   bool   `bson:"correct"`
+	ItemIndex    int       `bson:"item_index"`
+	Type         string    `bson:"type"` // persist only if fillblanks
+	SongId       string    `bson:"song_id"`
+	LineIndex    int       `bson:"line_index"`
+	ExpectedWord string    `bson:"expected_word"` // the hidden word the learner had to recall
+	UserInput    string    `bson:"user_input"`    // chosen word
+	Correct      bool      `bson:"correct"`
+	AnsweredAt   time.Time `bson:"answered_at"`
+}
+
+// ExpectedWordOf returns the word an answer should have been, falling back to
+// the lesson item for answers stored before ExpectedWord existed.
+func (l *Lesson) ExpectedWordOf(a LessonAnswer) string {
+	if a.ExpectedWord != "" {
+		return a.ExpectedWord
+	}
+	if a.ItemIndex >= 0 && a.ItemIndex < len(l.Items) {
+		return l.Items[a.ItemIndex].CorrectWord
+	}
+	return ""
 }
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	DefaultDailyGoalXP = 50
+
+	XPPerFillBlank        = 10 // correct fillblanks answer
+	XPPerLessonCompletion = 20
+	XPPerArrangeItem      = 5 // arrange outcomes are not graded server-side, so they pay out on completion
+)
+
+// DifficultyMultiplier scales XP with song difficulty, from 1x on the
+// easiest songs to 2x on the hardest. Unknown difficulty counts as easiest.
+func DifficultyMultiplier(difficulty float64) float64 {
+	d := math.Max(MinDifficulty, math.Min(MaxDifficulty, difficulty))
+	return 1 + (d-MinDifficulty)*0.25
+}
+
+type ProgressEventType = string
+
+const (
+	ProgressEventXP              ProgressEventType = "xp"
+	ProgressEventLessonCompleted ProgressEventType = "lesson_completed"
+	ProgressEventDailyGoalMet    ProgressEventType = "daily_goal_met"
+	ProgressEventStreakExtended  ProgressEventType = "streak_extended"
+)
+
+// ProgressEvent is something the UI can celebrate.
+type ProgressEvent struct {
+	Type   ProgressEventType
+	XP     int
+	Streak int
+}
+
+// UserProgress holds a user's XP, daily goal and streak. Days are calendar
+// days in the user's Timezone. The streak counts consecutive days on which
+// the daily goal was met.
+type UserProgress struct {
+	Id            string    `bson:"_id"` // user id
+	TotalXP       int       `bson:"total_xp"`
+	Timezone      string    `bson:"timezone"`
+	DailyGoalXP   int       `bson:"daily_goal_xp"`
+	Day           string    `bson:"day"` // YYYY-MM-DD that DayXP belongs to
+	DayXP         int       `bson:"day_xp"`
+	Streak        int       `bson:"streak"`
+	LongestStreak int       `bson:"longest_streak"`
+	LastGoalDay   string    `bson:"last_goal_day"`
+	Version       int       `bson:"version"` // optimistic concurrency
+	UpdatedAt     time.Time `bson:"updated_at"`
+}
+
+func NewUserProgress(userId string) *UserProgress {
+	return &UserProgress{Id: userId, Timezone: "UTC", DailyGoalXP: DefaultDailyGoalXP}
+}
+
+// Location returns the user's timezone, falling back to UTC.
+func (p *UserProgress) Location() *time.Location {
+	if loc, err := time.LoadLocation(p.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// XPOn returns the XP earned on the day containing t.
+func (p *UserProgress) XPOn(t time.Time) int {
+	if p.Day != t.In(p.Location()).Format(time.DateOnly) {
+		return 0
+	}
+	return p.DayXP
+}
+
+// StreakOn returns the streak still alive at t: it survives until the end of
+// the day after the last day the goal was met.
+func (p *UserProgress) StreakOn(t time.Time) int {
+	local := t.In(p.Location())
+	today := local.Format(time.DateOnly)
+	yesterday := local.AddDate(0, 0, -1).Format(time.DateOnly)
+	if p.LastGoalDay == today || p.LastGoalDay == yesterday {
+		return p.Streak
+	}
+	return 0
+}
+
+// Award adds xp earned at time at and returns the resulting events.
+func (p *UserProgress) Award(xp int, at time.Time) []ProgressEvent {
+	if xp <= 0 {
+		return nil
+	}
+	local := at.In(p.Location())
+	today := local.Format(time.DateOnly)
+	if p.Day != today {
+		p.Day = today
+		p.DayXP = 0
+	}
+
+	p.TotalXP += xp
+	p.DayXP += xp
+	p.UpdatedAt = at
+	events := []ProgressEvent{{Type: ProgressEventXP, XP: xp}}
+
+	if p.DayXP >= p.DailyGoalXP && p.LastGoalDay != today {
+		p.Streak = p.StreakOn(at) + 1
+		p.LongestStreak = max(p.LongestStreak, p.Streak)
+		p.LastGoalDay = today
+		events = append(events,
+			ProgressEvent{Type: ProgressEventDailyGoalMet, XP: p.DayXP},
+			ProgressEvent{Type: ProgressEventStreakExtended, Streak: p.Streak},
+		)
+	}
+	return events
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit lets a client make Burst requests at once, then Rate requests
+// per second on average.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RefillTime is how long an empty bucket takes to fill up again; a bucket
+// idle for that long is as good as new and can be forgotten.
+func (l RateLimit) RefillTime() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// TokenBucket is the state of one client's RateLimit: each request takes a
+// token, and tokens come back at the limit's rate up to its burst.
+type TokenBucket struct {
+	Id        string    `bson:"_id"` // route group and client
+	Tokens    float64   `bson:"tokens"`
+	UpdatedAt time.Time `bson:"updated_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// RateDecision says whether a request may go ahead.
+type RateDecision struct {
+	Allowed    bool
+	Remaining  int           // requests left right away
+	RetryAfter time.Duration // until the next token, when not allowed
+}
+
+// Take refills the bucket for the time since its last use and takes a
+// token if there is one. A zero bucket starts full.
+func (b *TokenBucket) Take(limit RateLimit, now time.Time) RateDecision {
+	tokens := float64(limit.Burst)
+	if !b.UpdatedAt.IsZero() {
+		elapsed := max(now.Sub(b.UpdatedAt).Seconds(), 0)
+		tokens = min(float64(limit.Burst), b.Tokens+elapsed*limit.Rate)
+	}
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	b.Tokens, b.UpdatedAt, b.ExpiresAt = tokens, now, now.Add(limit.RefillTime())
+	return limit.Decide(allowed, tokens)
+}
+
+// Decide describes the outcome of a take that left tokens in the bucket.
+// Stores that run Take's arithmetic themselves use it to report the same
+// decision.
+func (l RateLimit) Decide(allowed bool, tokens float64) RateDecision {
+	d := RateDecision{Allowed: allowed, Remaining: int(math.Floor(tokens))}
+	if !allowed {
+		d.RetryAfter = time.Duration((1 - tokens) / l.Rate * float64(time.Second))
+	}
+	return d
+}
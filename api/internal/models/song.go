@@ -1,8 +1,59 @@
 package models
 
 type Song struct {
-	Id     string     `bson:"_id,omitempty" json:"id"`
-	Title  string     `bson:"title" json:"title"`
-	Artist string     `bson:"artist" json:"artist"`
-	Lyrics [][]string `bson:"lyrics" json:"lyrics"`
+	Id                 string     `bson:"_id,omitempty" json:"id"`
+	Title              string     `bson:"title" json:"title"`
+	Artist             string     `bson:"artist" json:"artist"`
+	Lyrics             [][]string `bson:"lyrics" json:"lyrics"`
+	Tags               []string   `bson:"tags,omitempty" json:"tags,omitempty"`
+	Genres             []string   `bson:"genres,omitempty" json:"genres,omitempty"`
+	Language           string     `bson:"language,omitempty" json:"language,omitempty"`
+	LineCount          int        `bson:"line_count" json:"lineCount"`
+	LyricsText         string     `bson:"lyrics_text,omitempty" json:"-"` // flattened lyrics for the text index
+	AdminDifficulty    int        `bson:"admin_difficulty,omitempty" json:"adminDifficulty,omitempty"`
+	ComputedDifficulty float64    `bson:"computed_difficulty" json:"computedDifficulty"`
+	Difficulty         float64    `bson:"difficulty" json:"difficulty"` // admin value when set, computed otherwise
+}
+
+// Difficulty runs from MinDifficulty (easiest) to MaxDifficulty (hardest).
+const (
+	MinDifficulty = 1
+	MaxDifficulty = 5
+)
+
+// EffectiveDifficulty prefers the admin-set difficulty over the computed one.
+func (s *Song) EffectiveDifficulty() float64 {
+	if s.AdminDifficulty > 0 {
+		return float64(s.AdminDifficulty)
+	}
+	return s.ComputedDifficulty
+}
+
+type DifficultyBand = string
+
+const (
+	DifficultyBeginner     DifficultyBand = "beginner"
+	DifficultyIntermediate DifficultyBand = "intermediate"
+	DifficultyAdvanced     DifficultyBand = "advanced"
+)
+
+// DifficultyRange returns the [min, max) difficulty covered by band.
+func DifficultyRange(band DifficultyBand) (float64, float64, bool) {
+	switch band {
+	case DifficultyBeginner:
+		return 1, 2.5, true
+	case DifficultyIntermediate:
+		return 2.5, 3.5, true
+	case DifficultyAdvanced:
+		return 3.5, MaxDifficulty + 1, true
+	}
+	return 0, 0, false
+}
+
+// Collection is an admin-curated set of songs, e.g. "Beginner English pop".
+type Collection struct {
+	Id          string   `bson:"_id,omitempty" json:"id"`
+	Name        string   `bson:"name" json:"name"`
+	Description string   `bson:"description,omitempty" json:"description,omitempty"`
+	SongIds     []string `bson:"song_ids" json:"songIds"`
 }
@@ -1,6 +1,33 @@
 package models
 
+import "time"
+
 type User struct {
-	Id   string `bson:"_id,omitempty" json:"id"`
-	Name string `bson:"name" json:"name"`
+	Id          string       `bson:"_id,omitempty" json:"id"`
+	Name        string       `bson:"name" json:"name"`
+	Memberships []Membership `bson:"memberships,omitempty" json:"memberships,omitempty"`
+}
+
+type GroupRole = string
+
+const (
+	GroupRoleTeacher GroupRole = "teacher"
+	GroupRoleStudent GroupRole = "student"
+)
+
+// Membership places a user in a group with a role.
+type Membership struct {
+	GroupId  string    `bson:"group_id" json:"groupId"`
+	Role     GroupRole `bson:"role" json:"role"`
+	JoinedAt time.Time `bson:"joined_at" json:"joinedAt"`
+}
+
+// MembershipOf returns the user's membership in a group, or nil.
+func (u *User) MembershipOf(groupId string) *Membership {
+	for i := range u.Memberships {
+		if u.Memberships[i].GroupId == groupId {
+			return &u.Memberships[i]
+		}
+	}
+	return nil
 }
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// MasteryHalfLife is how long it takes an unpractised word to lose half its
+// mastery score.
+const MasteryHalfLife = 7 * 24 * time.Hour
+
+// WordMastery tracks how well a user knows one word across all songs. Word is
+// the normalized form (utils.NormalizeWord), so "Try" and "try" share a row.
+type WordMastery struct {
+	Id           string    `bson:"_id,omitempty"`
+	UserId       string    `bson:"user_id"`
+	Word         string    `bson:"word"`
+	SeenCount    int       `bson:"seen_count"`
+	CorrectCount int       `bson:"correct_count"`
+	LastSeenAt   time.Time `bson:"last_seen_at"`
+	Score        float64   `bson:"score"` // 0..1 as of LastSeenAt, see ScoreAt
+	SongIds      []string  `bson:"song_ids"`
+}
+
+func WordMasteryId(userId, word string) string {
+	return userId + ":" + word
+}
+
+// ScoreAt returns the mastery score decayed from LastSeenAt to t.
+func (m *WordMastery) ScoreAt(t time.Time) float64 {
+	if m.LastSeenAt.IsZero() || !t.After(m.LastSeenAt) {
+		return m.Score
+	}
+	halfLives := float64(t.Sub(m.LastSeenAt)) / float64(MasteryHalfLife)
+	return m.Score * math.Pow(0.5, halfLives)
+}
+
+// Record applies one graded answer given at time at. A correct answer closes
+// 30% of the gap to full mastery, a wrong one halves the score.
+func (m *WordMastery) Record(correct bool, songId string, at time.Time) {
+	score := m.ScoreAt(at)
+	if correct {
+		score += (1 - score) * 0.3
+		m.CorrectCount++
+	} else {
+		score *= 0.5
+	}
+	m.Score = score
+	m.SeenCount++
+	if at.After(m.LastSeenAt) {
+		m.LastSeenAt = at
+	}
+	if songId != "" && !slices.Contains(m.SongIds, songId) {
+		m.SongIds = append(m.SongIds, songId)
+	}
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type AssignmentRepoIface interface {
+	Create(ctx context.Context, assignment *models.Assignment) (*models.Assignment, error)
+	FindById(ctx context.Context, id string) (*models.Assignment, error)
+	FindByGroup(ctx context.Context, groupId string) ([]*models.Assignment, error)
+	// RecordResult counts a completed lesson towards a student's assignment
+	// result. passed marks the lesson as reaching the required accuracy.
+	RecordResult(ctx context.Context, assignmentId, userId string, accuracy float64, passed bool, at time.Time) error
+	FindResults(ctx context.Context, assignmentIds []string) ([]*models.AssignmentResult, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+// AssignmentRepoMongoImpl keeps assignments and the per-student results in
+// two collections.
+type AssignmentRepoMongoImpl struct {
+	coll        *mongo.Collection
+	resultsColl *mongo.Collection
+	logger      *slog.Logger
+}
+
+func NewAssignmentRepoMongo(
+	coll *mongo.Collection,
+	resultsColl *mongo.Collection,
+	logger *slog.Logger,
+) AssignmentRepoIface {
+	return &AssignmentRepoMongoImpl{
+		coll:        coll,
+		resultsColl: resultsColl,
+		logger:      logger,
+	}
+}
+
+func (repo *AssignmentRepoMongoImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "due_at", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("assignmentRepo: create indexes: %w", err)
+	}
+	_, err = repo.resultsColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "assignment_id", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("assignmentRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
+func (repo *AssignmentRepoMongoImpl) Create(
+	ctx context.Context,
+	assignment *models.Assignment,
+) (*models.Assignment, error) {
+	if assignment.Id == "" {
+		assignment.Id = primitive.NewObjectID().Hex()
+	}
+
+	_, err := repo.coll.InsertOne(ctx, assignment)
+	if err != nil {
+		return nil, fmt.Errorf("assignmentRepo: %w: %w", ErrInsertFailed, dbError(err))
+	}
+
+	return assignment, nil
+}
+
+func (repo *AssignmentRepoMongoImpl) FindById(
+	ctx context.Context,
+	id string,
+) (*models.Assignment, error) {
+	var assignment models.Assignment
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&assignment); err != nil {
+		return nil, fmt.Errorf("assignmentRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	return &assignment, nil
+}
+
+func (repo *AssignmentRepoMongoImpl) FindByGroup(
+	ctx context.Context,
+	groupId string,
+) ([]*models.Assignment, error) {
+	cursor, err := repo.coll.Find(ctx,
+		bson.M{"group_id": groupId},
+		options.Find().SetSort(bson.D{{Key: "due_at", Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("assignmentRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	assignments := make([]*models.Assignment, 0)
+	if err := cursor.All(ctx, &assignments); err != nil {
+		return nil, fmt.Errorf("assignmentRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	return assignments, nil
+}
+
+func (repo *AssignmentRepoMongoImpl) RecordResult(
+	ctx context.Context,
+	assignmentId string,
+	userId string,
+	accuracy float64,
+	passed bool,
+	at time.Time,
+) error {
+	update := bson.M{
+		"$inc":         bson.M{"attempts": 1},
+		"$max":         bson.M{"best_accuracy": accuracy},
+		"$set":         bson.M{"updated_at": at},
+		"$setOnInsert": bson.M{"assignment_id": assignmentId, "user_id": userId},
+	}
+	if passed {
+		// $min keeps the first passing time; a missing field counts as unset
+		update["$min"] = bson.M{"passed_at": at}
+	}
+	_, err := repo.resultsColl.UpdateOne(ctx,
+		bson.M{"_id": models.AssignmentResultId(assignmentId, userId)},
+		update,
+		options.UpdateOne().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("assignmentRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return nil
+}
+
+func (repo *AssignmentRepoMongoImpl) FindResults(
+	ctx context.Context,
+	assignmentIds []string,
+) ([]*models.AssignmentResult, error) {
+	cursor, err := repo.resultsColl.Find(ctx, bson.M{"assignment_id": bson.M{"$in": assignmentIds}})
+	if err != nil {
+		return nil, fmt.Errorf("assignmentRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]*models.AssignmentResult, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("assignmentRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	return results, nil
+}
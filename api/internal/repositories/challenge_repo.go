@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ChallengeRepoIface interface {
+	Create(ctx context.Context, challenge *models.Challenge) (*models.Challenge, error)
+	FindById(ctx context.Context, id string) (*models.Challenge, error)
+	// FindByUser returns the latest challenges a user sent or received.
+	FindByUser(ctx context.Context, userId string, limit int) ([]*models.Challenge, error)
+	// SetStatus moves a challenge from one of the from statuses to to and
+	// reports whether it did.
+	SetStatus(ctx context.Context, id string, from []models.ChallengeStatus, to models.ChallengeStatus, at time.Time) (bool, error)
+	// ClaimLesson binds a player's lesson to the challenge; false when the
+	// player already has one.
+	ClaimLesson(ctx context.Context, id string, role models.ChallengeRole, lessonId string) (bool, error)
+	// RecordResult stores the result of a player's lesson once; false when
+	// it was recorded before.
+	RecordResult(ctx context.Context, id string, role models.ChallengeRole, entry models.ChallengeEntry) (bool, error)
+	// Complete closes an accepted challenge whose players both finished.
+	Complete(ctx context.Context, id string, winnerId string, at time.Time) (bool, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type ChallengeRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewChallengeRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) ChallengeRepoIface {
+	return &ChallengeRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+func (repo *ChallengeRepoMongoImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "challenger_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "opponent_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("challengeRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
+func (repo *ChallengeRepoMongoImpl) Create(
+	ctx context.Context,
+	challenge *models.Challenge,
+) (*models.Challenge, error) {
+	if challenge.Id == "" {
+		challenge.Id = primitive.NewObjectID().Hex()
+	}
+
+	_, err := repo.coll.InsertOne(ctx, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("challengeRepo: %w: %w", ErrInsertFailed, dbError(err))
+	}
+
+	return challenge, nil
+}
+
+func (repo *ChallengeRepoMongoImpl) FindById(
+	ctx context.Context,
+	id string,
+) (*models.Challenge, error) {
+	var challenge models.Challenge
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&challenge); err != nil {
+		return nil, fmt.Errorf("challengeRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	return &challenge, nil
+}
+
+func (repo *ChallengeRepoMongoImpl) FindByUser(
+	ctx context.Context,
+	userId string,
+	limit int,
+) ([]*models.Challenge, error) {
+	cursor, err := repo.coll.Find(ctx,
+		bson.M{"$or": bson.A{bson.M{"challenger_id": userId}, bson.M{"opponent_id": userId}}},
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetLimit(int64(clampLimit(limit))),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("challengeRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	challenges := make([]*models.Challenge, 0)
+	if err := cursor.All(ctx, &challenges); err != nil {
+		return nil, fmt.Errorf("challengeRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	return challenges, nil
+}
+
+var challengeStatusTimestamps = map[models.ChallengeStatus]string{
+	models.ChallengeStatusAccepted:  "accepted_at",
+	models.ChallengeStatusDeclined:  "declined_at",
+	models.ChallengeStatusCompleted: "completed_at",
+}
+
+func (repo *ChallengeRepoMongoImpl) SetStatus(
+	ctx context.Context,
+	id string,
+	from []models.ChallengeStatus,
+	to models.ChallengeStatus,
+	at time.Time,
+) (bool, error) {
+	set := bson.M{"status": to}
+	if field, ok := challengeStatusTimestamps[to]; ok {
+		set[field] = at
+	}
+	res, err := repo.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": from}},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return false, fmt.Errorf("challengeRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (repo *ChallengeRepoMongoImpl) ClaimLesson(
+	ctx context.Context,
+	id string,
+	role models.ChallengeRole,
+	lessonId string,
+) (bool, error) {
+	res, err := repo.coll.UpdateOne(ctx,
+		bson.M{"_id": id, role + ".lesson_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{role + ".lesson_id": lessonId}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("challengeRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (repo *ChallengeRepoMongoImpl) RecordResult(
+	ctx context.Context,
+	id string,
+	role models.ChallengeRole,
+	entry models.ChallengeEntry,
+) (bool, error) {
+	res, err := repo.coll.UpdateOne(ctx,
+		bson.M{
+			"_id":               id,
+			"status":            bson.M{"$in": bson.A{models.ChallengeStatusPending, models.ChallengeStatusAccepted}},
+			role + ".lesson_id": entry.LessonId,
+			role + ".finished":  false,
+		},
+		bson.M{"$set": bson.M{role: entry}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("challengeRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (repo *ChallengeRepoMongoImpl) Complete(
+	ctx context.Context,
+	id string,
+	winnerId string,
+	at time.Time,
+) (bool, error) {
+	res, err := repo.coll.UpdateOne(ctx,
+		bson.M{
+			"_id":                 id,
+			"status":              models.ChallengeStatusAccepted,
+			"challenger.finished": true,
+			"opponent.finished":   true,
+		},
+		bson.M{"$set": bson.M{
+			"status":       models.ChallengeStatusCompleted,
+			"winner_id":    winnerId,
+			"completed_at": at,
+		}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("challengeRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return res.ModifiedCount > 0, nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type CollectionRepoIface interface {
+	Create(ctx context.Context, collection *models.Collection) (*models.Collection, error)
+	FindAll(ctx context.Context) ([]*models.Collection, error)
+	FindById(ctx context.Context, id string) (*models.Collection, error)
+	Replace(ctx context.Context, collection *models.Collection) error
+}
+
+type CollectionRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewCollectionRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) CollectionRepoIface {
+	return &CollectionRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+func (repo *CollectionRepoMongoImpl) Create(
+	ctx context.Context,
+	collection *models.Collection,
+) (*models.Collection, error) {
+	if collection.Id == "" {
+		collection.Id = primitive.NewObjectID().Hex()
+	}
+
+	_, err := repo.coll.InsertOne(ctx, collection)
+	if err != nil {
+		return nil, fmt.Errorf("collectionRepo: %w: %w", ErrInsertFailed, dbError(err))
+	}
+
+	return collection, nil
+}
+
+func (repo *CollectionRepoMongoImpl) FindAll(
+	ctx context.Context,
+) ([]*models.Collection, error) {
+	cursor, err := repo.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("collectionRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	var collections []*models.Collection
+	if err := cursor.All(ctx, &collections); err != nil {
+		return nil, fmt.Errorf("collectionRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	return collections, nil
+}
+
+func (repo *CollectionRepoMongoImpl) FindById(
+	ctx context.Context,
+	id string,
+) (*models.Collection, error) {
+	var collection models.Collection
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("collectionRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	return &collection, nil
+}
+
+func (repo *CollectionRepoMongoImpl) Replace(
+	ctx context.Context,
+	collection *models.Collection,
+) error {
+	res, err := repo.coll.ReplaceOne(ctx, bson.M{"_id": collection.Id}, collection)
+	if err != nil {
+		return fmt.Errorf("collectionRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("collectionRepo: %w: collection %s", ErrNotFound, collection.Id)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type CourseRepoIface interface {
+	Create(ctx context.Context, course *models.Course) (*models.Course, error)
+	FindAll(ctx context.Context) ([]*models.Course, error)
+	FindById(ctx context.Context, id string) (*models.Course, error)
+	Replace(ctx context.Context, course *models.Course) error
+}
+
+type CourseRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewCourseRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) CourseRepoIface {
+	return &CourseRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+func (repo *CourseRepoMongoImpl) Create(
+	ctx context.Context,
+	course *models.Course,
+) (*models.Course, error) {
+	if course.Id == "" {
+		course.Id = primitive.NewObjectID().Hex()
+	}
+
+	_, err := repo.coll.InsertOne(ctx, course)
+	if err != nil {
+		return nil, fmt.Errorf("courseRepo: %w: %w", ErrInsertFailed, dbError(err))
+	}
+
+	return course, nil
+}
+
+func (repo *CourseRepoMongoImpl) FindAll(
+	ctx context.Context,
+) ([]*models.Course, error) {
+	cursor, err := repo.coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("courseRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	var courses []*models.Course
+	if err := cursor.All(ctx, &courses); err != nil {
+		return nil, fmt.Errorf("courseRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	return courses, nil
+}
+
+func (repo *CourseRepoMongoImpl) FindById(
+	ctx context.Context,
+	id string,
+) (*models.Course, error) {
+	var course models.Course
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&course); err != nil {
+		return nil, fmt.Errorf("courseRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	return &course, nil
+}
+
+func (repo *CourseRepoMongoImpl) Replace(
+	ctx context.Context,
+	course *models.Course,
+) error {
+	res, err := repo.coll.ReplaceOne(ctx, bson.M{"_id": course.Id}, course)
+	if err != nil {
+		return fmt.Errorf("courseRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("courseRepo: %w: course %s", ErrNotFound, course.Id)
+	}
+
+	return nil
+}
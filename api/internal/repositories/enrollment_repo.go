@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type EnrollmentRepoIface interface {
+	Create(ctx context.Context, enrollment *models.Enrollment) error
+	Find(ctx context.Context, userId, courseId string) (*models.Enrollment, error)
+	// FindLatestActive returns the most recently updated, not yet completed
+	// enrollment of a user, or nil when there is none.
+	FindLatestActive(ctx context.Context, userId string) (*models.Enrollment, error)
+	Update(ctx context.Context, enrollment *models.Enrollment) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+type EnrollmentRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewEnrollmentRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) EnrollmentRepoIface {
+	return &EnrollmentRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+func (repo *EnrollmentRepoMongoImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "completed", Value: 1}, {Key: "updated_at", Value: -1}},
+	})
+	if err != nil {
+		return fmt.Errorf("enrollmentRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
+func (repo *EnrollmentRepoMongoImpl) Create(
+	ctx context.Context,
+	enrollment *models.Enrollment,
+) error {
+	enrollment.Id = models.EnrollmentId(enrollment.UserId, enrollment.CourseId)
+
+	_, err := repo.coll.InsertOne(ctx, enrollment)
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("enrollmentRepo: %w", ErrAlreadyExists)
+	}
+	if err != nil {
+		return fmt.Errorf("enrollmentRepo: %w: %w", ErrInsertFailed, dbError(err))
+	}
+
+	return nil
+}
+
+func (repo *EnrollmentRepoMongoImpl) Find(
+	ctx context.Context,
+	userId string,
+	courseId string,
+) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := repo.coll.FindOne(ctx, bson.M{"_id": models.EnrollmentId(userId, courseId)}).Decode(&enrollment)
+	if err != nil {
+		return nil, fmt.Errorf("enrollmentRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	return &enrollment, nil
+}
+
+func (repo *EnrollmentRepoMongoImpl) FindLatestActive(
+	ctx context.Context,
+	userId string,
+) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := repo.coll.FindOne(ctx,
+		bson.M{"user_id": userId, "completed": false},
+		options.FindOne().SetSort(bson.D{{Key: "updated_at", Value: -1}}),
+	).Decode(&enrollment)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("enrollmentRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	return &enrollment, nil
+}
+
+func (repo *EnrollmentRepoMongoImpl) Update(
+	ctx context.Context,
+	enrollment *models.Enrollment,
+) error {
+	_, err := repo.coll.ReplaceOne(ctx, bson.M{"_id": enrollment.Id}, enrollment)
+	if err != nil {
+		return fmt.Errorf("enrollmentRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+
+	return nil
+}
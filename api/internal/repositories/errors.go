@@ -1,6 +1,22 @@
 package repositories
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Error kinds. Every repository error that callers may want to act on wraps
+// one of these, so services and handlers can tell a missing document from a
+// storage outage with errors.Is.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrInvalid     = errors.New("invalid input")
+	ErrUnavailable = errors.New("storage unavailable")
+)
 
 var (
 	ErrOidConvFailed = errors.New("failed to convert inserted ID to ObjectID")
@@ -9,4 +25,20 @@ var (
 	ErrDeleteFailed  = errors.New("failed to delete")
 	ErrFindOneFailed = errors.New("failed to find")
 	ErrFindAllFailed = errors.New("failed to find all")
+	ErrAlreadyExists = fmt.Errorf("%w: already exists", ErrConflict)
+	ErrInvalidCursor = fmt.Errorf("%w: invalid cursor", ErrInvalid)
+	ErrInvalidSort   = fmt.Errorf("%w: invalid sort", ErrInvalid)
 )
+
+// dbError tags a driver error with its kind.
+func dbError(err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case mongo.IsTimeout(err), mongo.IsNetworkError(err), errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
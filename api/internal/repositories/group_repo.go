@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type GroupRepoIface interface {
+	Create(ctx context.Context, group *models.Group) (*models.Group, error)
+	FindById(ctx context.Context, id string) (*models.Group, error)
+}
+
+type GroupRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewGroupRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) GroupRepoIface {
+	return &GroupRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+func (repo *GroupRepoMongoImpl) Create(
+	ctx context.Context,
+	group *models.Group,
+) (*models.Group, error) {
+	if group.Id == "" {
+		group.Id = primitive.NewObjectID().Hex()
+	}
+
+	_, err := repo.coll.InsertOne(ctx, group)
+	if err != nil {
+		return nil, fmt.Errorf("groupRepo: %w: %w", ErrInsertFailed, dbError(err))
+	}
+
+	return group, nil
+}
+
+func (repo *GroupRepoMongoImpl) FindById(
+	ctx context.Context,
+	id string,
+) (*models.Group, error) {
+	var group models.Group
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&group); err != nil {
+		return nil, fmt.Errorf("groupRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	return &group, nil
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type IdempotencyRepoIface interface {
+	// Reserve stores rec unless an unexpired record with the same id exists,
+	// in which case it returns that record and stores nothing.
+	Reserve(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	// Complete stores the reply of a reserved request, kept until expiresAt.
+	Complete(ctx context.Context, id string, status int, body []byte, expiresAt time.Time) error
+	// Release drops a reservation whose request failed, so it can be retried.
+	Release(ctx context.Context, id string) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+type IdempotencyRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewIdempotencyRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) IdempotencyRepoIface {
+	return &IdempotencyRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+// EnsureIndexes lets MongoDB delete records once they expire. The sweep runs
+// about once a minute, so Reserve also ignores expired records itself.
+func (repo *IdempotencyRepoMongoImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("idempotencyRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
+func (repo *IdempotencyRepoMongoImpl) Reserve(
+	ctx context.Context,
+	rec *models.IdempotencyRecord,
+) (*models.IdempotencyRecord, error) {
+	// Replace an expired record the TTL sweep has not removed yet; the
+	// unique _id turns a live one into a duplicate key error.
+	_, err := repo.coll.ReplaceOne(ctx,
+		bson.M{"_id": rec.Id, "expires_at": bson.M{"$lte": rec.CreatedAt}},
+		rec,
+		options.Replace().SetUpsert(true),
+	)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("idempotencyRepo: %w: %w", ErrInsertFailed, dbError(err))
+	}
+
+	var existing models.IdempotencyRecord
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": rec.Id}).Decode(&existing); err != nil {
+		return nil, fmt.Errorf("idempotencyRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+	return &existing, nil
+}
+
+func (repo *IdempotencyRepoMongoImpl) Complete(
+	ctx context.Context,
+	id string,
+	status int,
+	body []byte,
+	expiresAt time.Time,
+) error {
+	_, err := repo.coll.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"status": status, "body": body, "expires_at": expiresAt}},
+	)
+	if err != nil {
+		return fmt.Errorf("idempotencyRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return nil
+}
+
+func (repo *IdempotencyRepoMongoImpl) Release(ctx context.Context, id string) error {
+	_, err := repo.coll.DeleteOne(ctx, bson.M{"_id": id, "status": 0})
+	if err != nil {
+		return fmt.Errorf("idempotencyRepo: %w: %w", ErrDeleteFailed, dbError(err))
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type LeaderboardRepoIface interface {
+	// AddScore increments a user's score on a board.
+	AddScore(ctx context.Context, kind models.LeaderboardKind, scope, userId string, delta float64, at time.Time) error
+	// RecordBest raises a user's score on a board to score if it is higher.
+	RecordBest(ctx context.Context, kind models.LeaderboardKind, scope, userId string, score float64, at time.Time) error
+	Page(ctx context.Context, filter LeaderboardFilter) ([]*models.LeaderboardEntry, int, string, error)
+	// Rank returns a user's 1-based rank and entry, or 0 and nil when the user
+	// is not on the board.
+	Rank(ctx context.Context, kind models.LeaderboardKind, scope, userId string) (int, *models.LeaderboardEntry, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type LeaderboardFilter struct {
+	Kind   models.LeaderboardKind
+	Scope  string
+	Cursor string
+	Limit  int
+}
+
+type LeaderboardRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewLeaderboardRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) LeaderboardRepoIface {
+	return &LeaderboardRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+var leaderboardOrder = bson.D{
+	{Key: "score", Value: -1},
+	{Key: "reached_at", Value: 1},
+	{Key: "user_id", Value: 1},
+}
+
+func (repo *LeaderboardRepoMongoImpl) EnsureIndexes(ctx context.Context) error {
+	keys := bson.D{{Key: "kind", Value: 1}, {Key: "scope", Value: 1}}
+	keys = append(keys, leaderboardOrder...)
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys})
+	if err != nil {
+		return fmt.Errorf("leaderboardRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
+func (repo *LeaderboardRepoMongoImpl) AddScore(
+	ctx context.Context,
+	kind models.LeaderboardKind,
+	scope string,
+	userId string,
+	delta float64,
+	at time.Time,
+) error {
+	_, err := repo.coll.UpdateOne(ctx,
+		bson.M{"_id": models.LeaderboardEntryId(kind, scope, userId)},
+		bson.M{
+			"$inc":         bson.M{"score": delta},
+			"$set":         bson.M{"reached_at": at},
+			"$setOnInsert": bson.M{"kind": kind, "scope": scope, "user_id": userId},
+		},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("leaderboardRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return nil
+}
+
+func (repo *LeaderboardRepoMongoImpl) RecordBest(
+	ctx context.Context,
+	kind models.LeaderboardKind,
+	scope string,
+	userId string,
+	score float64,
+	at time.Time,
+) error {
+	// only matches when the new score beats the stored one; an upsert against
+	// an existing, better entry collides on _id and is a no-op
+	_, err := repo.coll.UpdateOne(ctx,
+		bson.M{"_id": models.LeaderboardEntryId(kind, scope, userId), "score": bson.M{"$lt": score}},
+		bson.M{
+			"$set":         bson.M{"score": score, "reached_at": at},
+			"$setOnInsert": bson.M{"kind": kind, "scope": scope, "user_id": userId},
+		},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("leaderboardRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return nil
+}
+
+// Page returns one page of ranked entries, the rank of the first entry and
+// the cursor of the next page ("" on the last page). Boards page by offset:
+// scores keep moving while a client pages, and ranks must stay contiguous.
+func (repo *LeaderboardRepoMongoImpl) Page(
+	ctx context.Context,
+	filter LeaderboardFilter,
+) ([]*models.LeaderboardEntry, int, string, error) {
+	board := filter.Kind + ":" + filter.Scope
+	offset := 0
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil || c.Sort != board {
+			return nil, 0, "", ErrInvalidCursor
+		}
+		n, ok := c.Value.(float64) // JSON numbers decode as float64
+		if !ok || n < 0 {
+			return nil, 0, "", ErrInvalidCursor
+		}
+		offset = int(n)
+	}
+	limit := clampLimit(filter.Limit)
+
+	opts := options.Find().
+		SetSort(leaderboardOrder).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit + 1))
+	cursor, err := repo.coll.Find(ctx, bson.M{"kind": filter.Kind, "scope": filter.Scope}, opts)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("leaderboardRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]*models.LeaderboardEntry, 0, limit+1)
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, "", fmt.Errorf("leaderboardRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	next := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[len(entries)-1]
+		next = encodeCursor(pageCursor{Sort: board, Value: offset + limit, Id: last.UserId})
+	}
+	return entries, offset + 1, next, nil
+}
+
+func (repo *LeaderboardRepoMongoImpl) Rank(
+	ctx context.Context,
+	kind models.LeaderboardKind,
+	scope string,
+	userId string,
+) (int, *models.LeaderboardEntry, error) {
+	var entry models.LeaderboardEntry
+	err := repo.coll.FindOne(ctx, bson.M{"_id": models.LeaderboardEntryId(kind, scope, userId)}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("leaderboardRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	// everyone ordered strictly before the entry, following leaderboardOrder
+	ahead, err := repo.coll.CountDocuments(ctx, bson.M{
+		"kind":  kind,
+		"scope": scope,
+		"$or": bson.A{
+			bson.M{"score": bson.M{"$gt": entry.Score}},
+			bson.M{"score": entry.Score, "reached_at": bson.M{"$lt": entry.ReachedAt}},
+			bson.M{"score": entry.Score, "reached_at": entry.ReachedAt, "user_id": bson.M{"$lt": entry.UserId}},
+		},
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("leaderboardRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	return int(ahead) + 1, &entry, nil
+}
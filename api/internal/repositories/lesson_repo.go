@@ -2,23 +2,58 @@
 package repositories
 
 import (
-    "context"
-    "fmt"
-    "log/slog"
-    "time"
-
-    "github.tomerab1/todo-api/internal/models"
-    "go.mongodb.org/mongo-driver/bson"
-    "go.mongodb.org/mongo-driver/bson/primitive"
-    "go.mongodb.org/mongo-driver/v2/mongo"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type LessonRepoIface interface {
-    Create(ctx context.Context, userId string, lesson *models.Lesson) (*models.Lesson, error)
-    GetById(ctx context.Context, id string) (*models.Lesson, error)
-    AddAnswer(ctx context.Context, lessonId string, ans models.LessonAnswer) error
+	Create(ctx context.Context, userId string, lesson *models.Lesson) (*models.Lesson, error)
+	GetById(ctx context.Context, id string) (*models.Lesson, error)
+	FindByUser(ctx context.Context, filter LessonFilter) ([]*models.Lesson, string, error)
+	AddAnswer(ctx context.Context, lessonId string, ans models.LessonAnswer) error
+	// SetStatus moves a lesson to status `to` if it currently is in one of
+	// `from`, and reports whether it did.
+	SetStatus(ctx context.Context, lessonId string, from []models.LessonStatus, to models.LessonStatus, at time.Time) (bool, error)
+	// FindLatestOpen returns the user's most recent practice lesson that can
+	// still be answered, or nil. Challenge lessons are left out of this and
+	// the next two methods: only the player closes them.
+	FindLatestOpen(ctx context.Context, userId string) (*models.Lesson, error)
+	// AbandonOpen abandons the user's open practice lessons other than keepId.
+	AbandonOpen(ctx context.Context, userId string, keepId string, at time.Time) (int64, error)
+	// ExpireStale expires, at time at, open practice lessons with no activity
+	// since before.
+	ExpireStale(ctx context.Context, before time.Time, at time.Time) (int64, error)
+	UserStats(ctx context.Context, userId string, timezone string) (*UserLessonStats, error)
+	SongAnswerBreakdown(ctx context.Context, songId string) ([]AnswerBreakdownRow, error)
+	// BackfillAnswers completes answers stored before they carried the expected
+	// word, song and line, using the lesson's items. It returns the number of
+	// lessons updated.
+	BackfillAnswers(ctx context.Context) (int64, error)
+	CountAnswersToBackfill(ctx context.Context) (int64, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+// LessonFilter selects a user's lessons, newest first.
+type LessonFilter struct {
+	UserId string
+	Status models.LessonStatus // empty for any
+	From   time.Time           // inclusive, zero for no bound
+	To     time.Time           // exclusive, zero for no bound
+	Cursor string
+	Limit  int
 }
 
+const lessonHistorySort = "-createdAt"
+
 type LessonRepoMongoDb struct {
 	coll   *mongo.Collection
 	logger *slog.Logger
@@ -28,6 +63,18 @@ func NewLessonRepo(coll *mongo.Collection, logger *slog.Logger) LessonRepoIface
 	return &LessonRepoMongoDb{coll: coll, logger: logger}
 }
 
+func (repo *LessonRepoMongoDb) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "song_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("lessonRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
 func (repo *LessonRepoMongoDb) Create(
 	ctx context.Context,
 	userId string,
@@ -43,43 +90,261 @@ func (repo *LessonRepoMongoDb) Create(
 	if lesson.CreatedAt.IsZero() {
 		lesson.CreatedAt = time.Now().UTC()
 	}
+	if lesson.Status == "" {
+		lesson.Status = models.LessonStatusCreated
+	}
 	_, err := repo.coll.InsertOne(ctx, lesson)
 	if err != nil {
-		return nil, fmt.Errorf("lessonRepo: insert failed: %w", err)
+		return nil, fmt.Errorf("lessonRepo: insert failed: %w", dbError(err))
 	}
 	return lesson, nil
 }
 
 func (repo *LessonRepoMongoDb) GetById(
-    ctx context.Context,
-    id string,
+	ctx context.Context,
+	id string,
 ) (*models.Lesson, error) {
-    var out models.Lesson
-    err := repo.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&out)
-    if err != nil {
-        return nil, fmt.Errorf("lessonRepo: find failed: %w", err)
-    }
-    return &out, nil
+	var out models.Lesson
+	err := repo.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&out)
+	if err != nil {
+		return nil, fmt.Errorf("lessonRepo: find failed: %w", dbError(err))
+	}
+	return &out, nil
+}
+
+// FindByUser returns one page of a user's lessons and the cursor for the next
+// page, which is empty on the last page.
+func (repo *LessonRepoMongoDb) FindByUser(
+	ctx context.Context,
+	filter LessonFilter,
+) ([]*models.Lesson, string, error) {
+	limit := clampLimit(filter.Limit)
+
+	query := bson.M{"user_id": filter.UserId}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	created := bson.M{}
+	if !filter.From.IsZero() {
+		created["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		created["$lt"] = filter.To
+	}
+	if len(created) > 0 {
+		query["created_at"] = created
+	}
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		ms, ok := c.Value.(float64)
+		if c.Sort != lessonHistorySort || !ok {
+			return nil, "", ErrInvalidCursor
+		}
+		// the cursor carries milliseconds, mongo's own date precision
+		c.Value = time.UnixMilli(int64(ms)).UTC()
+		query["$and"] = bson.A{keysetFilter("created_at", -1, c)}
+	}
+
+	opts := options.Find().SetSort(sortDoc("created_at", -1)).SetLimit(int64(limit + 1))
+	cursor, err := repo.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("lessonRepo: find failed: %w", dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	lessons := make([]*models.Lesson, 0, limit)
+	if err := cursor.All(ctx, &lessons); err != nil {
+		return nil, "", fmt.Errorf("lessonRepo: find failed: %w", dbError(err))
+	}
+
+	next := ""
+	if len(lessons) > limit {
+		lessons = lessons[:limit]
+		last := lessons[len(lessons)-1]
+		next = encodeCursor(pageCursor{Sort: lessonHistorySort, Value: last.CreatedAt.UnixMilli(), Id: last.Id})
+	}
+	return lessons, next, nil
 }
 
 func (repo *LessonRepoMongoDb) AddAnswer(
-    ctx context.Context,
-    lessonId string,
-    ans models.LessonAnswer,
+	ctx context.Context,
+	lessonId string,
+	ans models.LessonAnswer,
 ) error {
-    // Ensure answers field is an array (convert null -> [])
-    _, _ = repo.coll.UpdateOne(ctx,
-        bson.M{"_id": lessonId, "answers": bson.M{"$type": "null"}},
-        bson.M{"$set": bson.M{"answers": bson.A{}}},
-    )
-    filter := bson.M{"_id": lessonId, "answers.item_index": bson.M{"$ne": ans.ItemIndex}}
-    update := bson.M{"$push": bson.M{"answers": ans}}
-    res, err := repo.coll.UpdateOne(ctx, filter, update)
-    if err != nil {
-        return fmt.Errorf("lessonRepo: add answer failed: %w", err)
-    }
-    if res.MatchedCount == 0 {
-        return fmt.Errorf("lessonRepo: duplicate answer or lesson not found")
-    }
-    return nil
+	// Ensure answers field is an array (convert null -> [])
+	_, _ = repo.coll.UpdateOne(ctx,
+		bson.M{"_id": lessonId, "answers": bson.M{"$type": "null"}},
+		bson.M{"$set": bson.M{"answers": bson.A{}}},
+	)
+	filter := bson.M{
+		"_id":                lessonId,
+		"answers.item_index": bson.M{"$ne": ans.ItemIndex},
+		"status":             openStatusFilter(),
+	}
+	update := bson.M{
+		"$push": bson.M{"answers": ans},
+		"$set":  bson.M{"updated_at": ans.AnsweredAt, "status": models.LessonStatusInProgress},
+		"$min":  bson.M{"started_at": ans.AnsweredAt},
+	}
+	res, err := repo.coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("lessonRepo: add answer failed: %w", dbError(err))
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("lessonRepo: %w: duplicate answer or closed lesson", ErrConflict)
+	}
+	return nil
+}
+
+var legacyAnswerFilter = bson.M{"answers": bson.M{"$elemMatch": bson.M{"expected_word": bson.M{"$exists": false}}}}
+
+func (repo *LessonRepoMongoDb) CountAnswersToBackfill(ctx context.Context) (int64, error) {
+	n, err := repo.coll.CountDocuments(ctx, legacyAnswerFilter)
+	if err != nil {
+		return 0, fmt.Errorf("lessonRepo: count failed: %w", dbError(err))
+	}
+	return n, nil
+}
+
+func (repo *LessonRepoMongoDb) BackfillAnswers(ctx context.Context) (int64, error) {
+	item := func(field string) bson.M {
+		return bson.M{"$arrayElemAt": bson.A{"$items." + field, "$$a.item_index"}}
+	}
+	keep := func(field string, fallback any) bson.M {
+		return bson.M{"$ifNull": bson.A{"$$a." + field, fallback}}
+	}
+	// answered_at was never recorded; the lesson's last activity is the best
+	// approximation left
+	pipeline := bson.A{bson.M{"$set": bson.M{"answers": bson.M{"$map": bson.M{
+		"input": "$answers",
+		"as":    "a",
+		"in": bson.M{"$mergeObjects": bson.A{"$$a", bson.M{
+			"expected_word": keep("expected_word", item("correct_word")),
+			"line_index":    keep("line_index", item("line_index")),
+			"song_id":       keep("song_id", "$song_id"),
+			"answered_at":   keep("answered_at", bson.M{"$ifNull": bson.A{"$updated_at", "$created_at"}}),
+		}}},
+	}}}}}
+
+	res, err := repo.coll.UpdateMany(ctx, legacyAnswerFilter, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("lessonRepo: backfill failed: %w", dbError(err))
+	}
+	return res.ModifiedCount, nil
+}
+
+// lessonStatusTimestamps names the field stamped when a lesson enters a status.
+var lessonStatusTimestamps = map[models.LessonStatus]string{
+	models.LessonStatusInProgress: "started_at",
+	models.LessonStatusCompleted:  "completed_at",
+	models.LessonStatusAbandoned:  "abandoned_at",
+	models.LessonStatusExpired:    "expired_at",
+}
+
+// statusIn matches lessons in one of statuses. Lessons stored before
+// statuses existed have none and count as created.
+func statusIn(statuses []models.LessonStatus) bson.M {
+	in := bson.A{}
+	for _, s := range statuses {
+		in = append(in, s)
+	}
+	if slices.Contains(statuses, models.LessonStatusCreated) {
+		in = append(in, nil)
+	}
+	return bson.M{"$in": in}
+}
+
+func openStatusFilter() bson.M {
+	return statusIn(models.OpenLessonStatuses)
+}
+
+// notChallenge matches lessons played outside any challenge.
+func notChallenge() bson.M {
+	return bson.M{"$in": bson.A{nil, ""}}
+}
+
+func statusUpdate(to models.LessonStatus, at time.Time) bson.M {
+	set := bson.M{"status": to}
+	if field, ok := lessonStatusTimestamps[to]; ok {
+		set[field] = at
+	}
+	return bson.M{"$set": set}
+}
+
+func (repo *LessonRepoMongoDb) SetStatus(
+	ctx context.Context,
+	lessonId string,
+	from []models.LessonStatus,
+	to models.LessonStatus,
+	at time.Time,
+) (bool, error) {
+	res, err := repo.coll.UpdateOne(ctx,
+		bson.M{"_id": lessonId, "status": statusIn(from)},
+		statusUpdate(to, at),
+	)
+	if err != nil {
+		return false, fmt.Errorf("lessonRepo: set status failed: %w", dbError(err))
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (repo *LessonRepoMongoDb) FindLatestOpen(
+	ctx context.Context,
+	userId string,
+) (*models.Lesson, error) {
+	var out models.Lesson
+	err := repo.coll.FindOne(ctx,
+		bson.M{"user_id": userId, "status": openStatusFilter(), "challenge_id": notChallenge()},
+		options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	).Decode(&out)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lessonRepo: find failed: %w", dbError(err))
+	}
+	return &out, nil
+}
+
+func (repo *LessonRepoMongoDb) AbandonOpen(
+	ctx context.Context,
+	userId string,
+	keepId string,
+	at time.Time,
+) (int64, error) {
+	res, err := repo.coll.UpdateMany(ctx,
+		bson.M{"user_id": userId, "_id": bson.M{"$ne": keepId}, "status": openStatusFilter(), "challenge_id": notChallenge()},
+		statusUpdate(models.LessonStatusAbandoned, at),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("lessonRepo: abandon failed: %w", dbError(err))
+	}
+	return res.ModifiedCount, nil
+}
+
+func (repo *LessonRepoMongoDb) ExpireStale(
+	ctx context.Context,
+	before time.Time,
+	at time.Time,
+) (int64, error) {
+	// updated_at tracks the last answer; untouched lessons only have created_at
+	filter := bson.M{
+		"status":       openStatusFilter(),
+		"challenge_id": notChallenge(),
+		"$or": bson.A{
+			bson.M{"updated_at": bson.M{"$lt": before}},
+			bson.M{"updated_at": bson.M{"$exists": false}, "created_at": bson.M{"$lt": before}},
+		},
+	}
+	res, err := repo.coll.UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"status":     models.LessonStatusExpired,
+		"expired_at": at,
+	}})
+	if err != nil {
+		return 0, fmt.Errorf("lessonRepo: expire failed: %w", dbError(err))
+	}
+	return res.ModifiedCount, nil
 }
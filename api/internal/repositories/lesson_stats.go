@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// UserLessonStats aggregates every lesson of a user. Answer counts cover
+// fillblanks only, the one exercise type whose answers are persisted.
+// MissedWord counts misses by the expected word exactly as stored; the
+// service folds them with utils.NormalizeWord, the form word mastery uses.
+type UserLessonStats struct {
+	Totals     LessonStatsBucket   `bson:"totals"`
+	Daily      []LessonStatsBucket `bson:"daily"`
+	Songs      []LessonStatsBucket `bson:"songs"`
+	MissedWord []WordCount         `bson:"missed"`
+}
+
+// LessonStatsBucket is one group of lessons: a day ("2006-01-02"), a song id,
+// or everything for the totals.
+type LessonStatsBucket struct {
+	Key         string `bson:"_id"`
+	Lessons     int    `bson:"lessons"`
+	Answers     int    `bson:"answers"`
+	Correct     int    `bson:"correct"`
+	TimeSpentMs int64  `bson:"time_spent_ms"`
+}
+
+type WordCount struct {
+	Word  string `bson:"_id"`
+	Count int    `bson:"count"`
+}
+
+// UserStats runs the whole aggregation in mongo; day boundaries follow
+// timezone (an IANA name).
+func (repo *LessonRepoMongoDb) UserStats(
+	ctx context.Context,
+	userId string,
+	timezone string,
+) (*UserLessonStats, error) {
+	isFill := func(v string) bson.M {
+		return bson.M{"$eq": bson.A{v + ".type", "fillblanks"}}
+	}
+	sums := bson.M{
+		"lessons":       bson.M{"$sum": 1},
+		"answers":       bson.M{"$sum": "$fill_answered"},
+		"correct":       bson.M{"$sum": "$fill_correct"},
+		"time_spent_ms": bson.M{"$sum": "$time_spent_ms"},
+	}
+	group := func(key any) bson.M {
+		g := bson.M{"_id": key}
+		for k, v := range sums {
+			g[k] = v
+		}
+		return bson.M{"$group": g}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"user_id": userId}},
+		bson.M{"$addFields": bson.M{
+			"answers": bson.M{"$ifNull": bson.A{"$answers", bson.A{}}},
+		}},
+		bson.M{"$addFields": bson.M{
+			"fill_answered": bson.M{"$size": bson.M{"$filter": bson.M{
+				"input": "$answers", "as": "a", "cond": isFill("$$a"),
+			}}},
+			"fill_correct": bson.M{"$size": bson.M{"$filter": bson.M{
+				"input": "$answers", "as": "a",
+				"cond": bson.M{"$and": bson.A{isFill("$$a"), "$$a.correct"}},
+			}}},
+			"day": bson.M{"$dateToString": bson.M{
+				"format": "%Y-%m-%d", "date": "$created_at", "timezone": timezone,
+			}},
+			// time from creation to the last answer; lessons nobody answered count as zero
+			"time_spent_ms": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$updated_at", "$created_at"}},
+				bson.M{"$subtract": bson.A{"$updated_at", "$created_at"}},
+				0,
+			}},
+		}},
+		bson.M{"$facet": bson.M{
+			"totals": bson.A{group(nil)},
+			"daily":  bson.A{group("$day"), bson.M{"$sort": bson.M{"_id": 1}}},
+			"songs":  bson.A{group("$song_id"), bson.M{"$sort": bson.D{{Key: "answers", Value: -1}, {Key: "_id", Value: 1}}}},
+			"missed": bson.A{
+				bson.M{"$unwind": "$answers"},
+				bson.M{"$match": bson.M{"answers.type": "fillblanks", "answers.correct": false}},
+				bson.M{"$group": bson.M{
+					"_id": bson.M{
+						"$arrayElemAt": bson.A{"$items.correct_word", "$answers.item_index"},
+					},
+					"count": bson.M{"$sum": 1},
+				}},
+			},
+		}},
+		bson.M{"$set": bson.M{"totals": bson.M{"$ifNull": bson.A{bson.M{"$first": "$totals"}, bson.M{}}}}},
+	}
+
+	cursor, err := repo.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("lessonRepo: stats aggregation failed: %w", dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	var out []UserLessonStats
+	if err := cursor.All(ctx, &out); err != nil {
+		return nil, fmt.Errorf("lessonRepo: stats aggregation failed: %w", dbError(err))
+	}
+	if len(out) == 0 {
+		return &UserLessonStats{}, nil
+	}
+	return &out[0], nil
+}
+
+// AnswerBreakdownRow counts answers to one blank (line and expected word)
+// that picked Choice.
+type AnswerBreakdownRow struct {
+	LineIndex int    `bson:"line_index"`
+	Word      string `bson:"word"`
+	Choice    string `bson:"choice"`
+	Correct   bool   `bson:"correct"`
+	Count     int    `bson:"count"`
+}
+
+// SongAnswerBreakdown groups every fillblanks answer given on a song, across
+// all users, by line, expected word and chosen option.
+func (repo *LessonRepoMongoDb) SongAnswerBreakdown(
+	ctx context.Context,
+	songId string,
+) ([]AnswerBreakdownRow, error) {
+	fromItem := func(field string) bson.M {
+		return bson.M{"$arrayElemAt": bson.A{"$items." + field, "$answers.item_index"}}
+	}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"song_id": songId}},
+		bson.M{"$unwind": "$answers"},
+		bson.M{"$match": bson.M{"answers.type": "fillblanks"}},
+		bson.M{"$group": bson.M{
+			"_id": bson.M{
+				"line_index": bson.M{"$ifNull": bson.A{"$answers.line_index", fromItem("line_index")}},
+				"word":       bson.M{"$toLower": bson.M{"$ifNull": bson.A{"$answers.expected_word", fromItem("correct_word")}}},
+				"choice":     bson.M{"$toLower": "$answers.user_input"},
+				"correct":    "$answers.correct",
+			},
+			"count": bson.M{"$sum": 1},
+		}},
+		bson.M{"$replaceWith": bson.M{"$mergeObjects": bson.A{"$_id", bson.M{"count": "$count"}}}},
+		bson.M{"$sort": bson.D{{Key: "line_index", Value: 1}, {Key: "word", Value: 1}, {Key: "count", Value: -1}, {Key: "choice", Value: 1}}},
+	}
+
+	cursor, err := repo.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("lessonRepo: song analytics aggregation failed: %w", dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	rows := make([]AnswerBreakdownRow, 0)
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("lessonRepo: song analytics aggregation failed: %w", dbError(err))
+	}
+	return rows, nil
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// pageCursor is the opaque position handed back to clients as "nextCursor".
+// It records the sort key it was produced for, so a cursor cannot be replayed
+// against a different ordering.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	Value any    `json:"v"`
+	Id    string `json:"id"`
+}
+
+func encodeCursor(c pageCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Id == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// sortSpec resolves a public sort key ("title", "-title", ...) against the
+// allowed keys and returns the document field and direction.
+func sortSpec(sort string, allowed map[string]string, def string) (key string, field string, dir int, err error) {
+	if sort == "" {
+		sort = def
+	}
+	dir = 1
+	key = sort
+	if strings.HasPrefix(key, "-") {
+		dir = -1
+		key = key[1:]
+	}
+	field, ok := allowed[key]
+	if !ok {
+		return "", "", 0, fmt.Errorf("%w: %q", ErrInvalidSort, sort)
+	}
+	return sort, field, dir, nil
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	return min(limit, MaxPageLimit)
+}
+
+// keysetFilter returns the condition selecting documents strictly after the
+// cursor position for a (field, _id) ordering.
+func keysetFilter(field string, dir int, c *pageCursor) bson.M {
+	op := "$gt"
+	if dir < 0 {
+		op = "$lt"
+	}
+	if field == "_id" {
+		return bson.M{"_id": bson.M{op: c.Id}}
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: c.Value}},
+		bson.M{field: c.Value, "_id": bson.M{op: c.Id}},
+	}}
+}
+
+func sortDoc(field string, dir int) bson.D {
+	if field == "_id" {
+		return bson.D{{Key: "_id", Value: dir}}
+	}
+	return bson.D{{Key: field, Value: dir}, {Key: "_id", Value: dir}}
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	in := pageCursor{Sort: "-title", Value: "Hey Jude", Id: "s1"}
+	out, err := decodeCursor(encodeCursor(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *out != in {
+		t.Errorf("decoded %+v, want %+v", *out, in)
+	}
+
+	for _, bad := range []string{"not base64!", encodeCursor(pageCursor{Sort: "title"}), "e30"} {
+		if _, err := decodeCursor(bad); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%q) = %v, want ErrInvalidCursor", bad, err)
+		}
+	}
+}
+
+func TestSortSpec(t *testing.T) {
+	allowed := map[string]string{"title": "title", "id": "_id"}
+
+	key, field, dir, err := sortSpec("", allowed, "title")
+	if err != nil || key != "title" || field != "title" || dir != 1 {
+		t.Errorf("default sort = %q %q %d %v", key, field, dir, err)
+	}
+	key, field, dir, err = sortSpec("-id", allowed, "title")
+	if err != nil || key != "-id" || field != "_id" || dir != -1 {
+		t.Errorf("-id = %q %q %d %v", key, field, dir, err)
+	}
+	if _, _, _, err := sortSpec("artist", allowed, "title"); !errors.Is(err, ErrInvalidSort) {
+		t.Errorf("unknown sort = %v, want ErrInvalidSort", err)
+	}
+}
+
+func TestClampLimit(t *testing.T) {
+	for in, want := range map[int]int{-1: DefaultPageLimit, 0: DefaultPageLimit, 10: 10, MaxPageLimit + 1: MaxPageLimit} {
+		if got := clampLimit(in); got != want {
+			t.Errorf("clampLimit(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestKeysetFilter(t *testing.T) {
+	c := &pageCursor{Value: "b", Id: "s2"}
+
+	got := keysetFilter("_id", 1, c)
+	if want := (bson.M{"_id": bson.M{"$gt": "s2"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("_id ascending = %v, want %v", got, want)
+	}
+
+	got = keysetFilter("title", -1, c)
+	want := bson.M{"$or": bson.A{
+		bson.M{"title": bson.M{"$lt": "b"}},
+		bson.M{"title": "b", "_id": bson.M{"$lt": "s2"}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("title descending = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type ProgressRepoIface interface {
+	// Find returns a user's progress, or nil when they never earned XP.
+	Find(ctx context.Context, userId string) (*models.UserProgress, error)
+	// Save stores progress if nobody saved it since it was read, and reports
+	// whether it did. A new document is one with Version 0.
+	Save(ctx context.Context, progress *models.UserProgress) (bool, error)
+}
+
+type ProgressRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewProgressRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) ProgressRepoIface {
+	return &ProgressRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+func (repo *ProgressRepoMongoImpl) Find(
+	ctx context.Context,
+	userId string,
+) (*models.UserProgress, error) {
+	var progress models.UserProgress
+	err := repo.coll.FindOne(ctx, bson.M{"_id": userId}).Decode(&progress)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("progressRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	return &progress, nil
+}
+
+func (repo *ProgressRepoMongoImpl) Save(
+	ctx context.Context,
+	progress *models.UserProgress,
+) (bool, error) {
+	version := progress.Version
+	progress.Version++
+
+	if version == 0 {
+		_, err := repo.coll.InsertOne(ctx, progress)
+		if mongo.IsDuplicateKeyError(err) {
+			progress.Version = version
+			return false, nil
+		}
+		if err != nil {
+			progress.Version = version
+			return false, fmt.Errorf("progressRepo: %w: %w", ErrInsertFailed, dbError(err))
+		}
+		return true, nil
+	}
+
+	res, err := repo.coll.ReplaceOne(ctx, bson.M{"_id": progress.Id, "version": version}, progress)
+	if err != nil {
+		progress.Version = version
+		return false, fmt.Errorf("progressRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	if res.MatchedCount == 0 {
+		progress.Version = version
+		return false, nil
+	}
+	return true, nil
+}
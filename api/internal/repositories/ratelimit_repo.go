@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// RateLimitRepoIface stores token buckets. The memory implementation suits a
+// single instance; instances sharing limits use the MongoDB one.
+type RateLimitRepoIface interface {
+	// Take takes a token from the bucket with the given id, creating it
+	// full if it does not exist, as models.TokenBucket.Take does.
+	Take(ctx context.Context, id string, limit models.RateLimit, now time.Time) (models.RateDecision, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type RateLimitRepoMemory struct {
+	mu        sync.Mutex
+	buckets   map[string]*models.TokenBucket
+	lastSweep time.Time
+}
+
+func NewRateLimitRepoMemory() RateLimitRepoIface {
+	return &RateLimitRepoMemory{buckets: make(map[string]*models.TokenBucket)}
+}
+
+// bucketSweepInterval is how often the memory store forgets full buckets.
+const bucketSweepInterval = time.Minute
+
+func (repo *RateLimitRepoMemory) Take(
+	_ context.Context,
+	id string,
+	limit models.RateLimit,
+	now time.Time,
+) (models.RateDecision, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if now.Sub(repo.lastSweep) >= bucketSweepInterval {
+		for k, b := range repo.buckets {
+			if !now.Before(b.ExpiresAt) {
+				delete(repo.buckets, k)
+			}
+		}
+		repo.lastSweep = now
+	}
+
+	b, ok := repo.buckets[id]
+	if !ok {
+		b = &models.TokenBucket{Id: id}
+		repo.buckets[id] = b
+	}
+	return b.Take(limit, now), nil
+}
+
+func (repo *RateLimitRepoMemory) EnsureIndexes(context.Context) error {
+	return nil
+}
+
+type RateLimitRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewRateLimitRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) RateLimitRepoIface {
+	return &RateLimitRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+// EnsureIndexes lets MongoDB delete buckets that have filled up again.
+func (repo *RateLimitRepoMongoImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("rateLimitRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
+// Take runs models.TokenBucket.Take inside MongoDB as a single pipeline
+// update, so that instances sharing a bucket never both spend its last
+// token.
+func (repo *RateLimitRepoMongoImpl) Take(
+	ctx context.Context,
+	id string,
+	limit models.RateLimit,
+	now time.Time,
+) (models.RateDecision, error) {
+	burst := float64(limit.Burst)
+	elapsedSeconds := bson.M{"$max": bson.A{0, bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}},
+		1000,
+	}}}}
+	refilled := bson.M{"$min": bson.A{burst, bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$tokens", burst}},
+		bson.M{"$multiply": bson.A{elapsedSeconds, limit.Rate}},
+	}}}}
+	hasToken := bson.M{"$gte": bson.A{"$tokens", 1}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": refilled}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed":    hasToken,
+			"tokens":     bson.M{"$cond": bson.A{hasToken, bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			"updated_at": now,
+			"expires_at": now.Add(limit.RefillTime()),
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var out struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	err := repo.coll.FindOneAndUpdate(ctx, bson.M{"_id": id}, pipeline, opts).Decode(&out)
+	if mongo.IsDuplicateKeyError(err) {
+		// Two first requests raced to create the bucket; it exists now.
+		err = repo.coll.FindOneAndUpdate(ctx, bson.M{"_id": id}, pipeline, opts).Decode(&out)
+	}
+	if err != nil {
+		return models.RateDecision{}, fmt.Errorf("rateLimitRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return limit.Decide(out.Allowed, out.Tokens), nil
+}
@@ -0,0 +1,120 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type ChallengeRepo struct {
+	repositories.ChallengeRepoIface
+	mu         sync.Mutex
+	Challenges []*models.Challenge
+}
+
+func (repo *ChallengeRepo) Create(_ context.Context, challenge *models.Challenge) (*models.Challenge, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if challenge.Id == "" {
+		challenge.Id = fmt.Sprintf("challenge-%d", len(repo.Challenges)+1)
+	}
+	cp := *challenge
+	repo.Challenges = append(repo.Challenges, &cp)
+	return challenge, nil
+}
+
+func (repo *ChallengeRepo) find(id string) *models.Challenge {
+	for _, c := range repo.Challenges {
+		if c.Id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (repo *ChallengeRepo) FindById(_ context.Context, id string) (*models.Challenge, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if c := repo.find(id); c != nil {
+		cp := *c
+		return &cp, nil
+	}
+	return nil, notFound("challengeRepo", id)
+}
+
+func (repo *ChallengeRepo) FindByUser(_ context.Context, userId string, limit int) ([]*models.Challenge, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.Challenge, 0)
+	for _, c := range slices.Backward(repo.Challenges) {
+		if (c.ChallengerId == userId || c.OpponentId == userId) && (limit <= 0 || len(out) < limit) {
+			cp := *c
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (repo *ChallengeRepo) SetStatus(_ context.Context, id string, from []models.ChallengeStatus, to models.ChallengeStatus, at time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	c := repo.find(id)
+	if c == nil || !slices.Contains(from, c.Status) {
+		return false, nil
+	}
+	c.Status = to
+	switch to {
+	case models.ChallengeStatusAccepted:
+		c.AcceptedAt = at
+	case models.ChallengeStatusDeclined:
+		c.DeclinedAt = at
+	case models.ChallengeStatusCompleted:
+		c.CompletedAt = at
+	}
+	return true, nil
+}
+
+func (repo *ChallengeRepo) ClaimLesson(_ context.Context, id string, role models.ChallengeRole, lessonId string) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	c := repo.find(id)
+	if c == nil || c.Entry(role).LessonId != "" {
+		return false, nil
+	}
+	c.Entry(role).LessonId = lessonId
+	return true, nil
+}
+
+func (repo *ChallengeRepo) RecordResult(_ context.Context, id string, role models.ChallengeRole, entry models.ChallengeEntry) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	c := repo.find(id)
+	if c == nil || (c.Status != models.ChallengeStatusPending && c.Status != models.ChallengeStatusAccepted) {
+		return false, nil
+	}
+	if e := c.Entry(role); e.LessonId != entry.LessonId || e.Finished {
+		return false, nil
+	}
+	*c.Entry(role) = entry
+	return true, nil
+}
+
+func (repo *ChallengeRepo) Complete(_ context.Context, id string, winnerId string, at time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	c := repo.find(id)
+	if c == nil || c.Status != models.ChallengeStatusAccepted || !c.Challenger.Finished || !c.Opponent.Finished {
+		return false, nil
+	}
+	c.Status, c.WinnerId, c.CompletedAt = models.ChallengeStatusCompleted, winnerId, at
+	return true, nil
+}
+
+func (repo *ChallengeRepo) EnsureIndexes(context.Context) error {
+	return nil
+}
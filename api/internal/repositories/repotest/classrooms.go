@@ -0,0 +1,127 @@
+package repotest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type GroupRepo struct {
+	repositories.GroupRepoIface
+	mu     sync.Mutex
+	Groups []*models.Group
+}
+
+func (repo *GroupRepo) Create(_ context.Context, group *models.Group) (*models.Group, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if group.Id == "" {
+		group.Id = fmt.Sprintf("group-%d", len(repo.Groups)+1)
+	}
+	cp := *group
+	repo.Groups = append(repo.Groups, &cp)
+	return group, nil
+}
+
+func (repo *GroupRepo) FindById(_ context.Context, id string) (*models.Group, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, g := range repo.Groups {
+		if g.Id == id {
+			cp := *g
+			return &cp, nil
+		}
+	}
+	return nil, notFound("groupRepo", id)
+}
+
+type AssignmentRepo struct {
+	repositories.AssignmentRepoIface
+	mu          sync.Mutex
+	Assignments []*models.Assignment
+	Results     map[string]*models.AssignmentResult
+}
+
+func (repo *AssignmentRepo) Create(_ context.Context, assignment *models.Assignment) (*models.Assignment, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if assignment.Id == "" {
+		assignment.Id = fmt.Sprintf("assignment-%d", len(repo.Assignments)+1)
+	}
+	cp := *assignment
+	repo.Assignments = append(repo.Assignments, &cp)
+	return assignment, nil
+}
+
+func (repo *AssignmentRepo) FindById(_ context.Context, id string) (*models.Assignment, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, a := range repo.Assignments {
+		if a.Id == id {
+			cp := *a
+			return &cp, nil
+		}
+	}
+	return nil, notFound("assignmentRepo", id)
+}
+
+func (repo *AssignmentRepo) FindByGroup(_ context.Context, groupId string) ([]*models.Assignment, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.Assignment, 0)
+	for _, a := range repo.Assignments {
+		if a.GroupId == groupId {
+			cp := *a
+			out = append(out, &cp)
+		}
+	}
+	slices.SortFunc(out, func(a, b *models.Assignment) int {
+		return cmp.Or(a.DueAt.Compare(b.DueAt), cmp.Compare(a.Id, b.Id))
+	})
+	return out, nil
+}
+
+func (repo *AssignmentRepo) RecordResult(_ context.Context, assignmentId, userId string, accuracy float64, passed bool, at time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.Results == nil {
+		repo.Results = map[string]*models.AssignmentResult{}
+	}
+	id := models.AssignmentResultId(assignmentId, userId)
+	r := repo.Results[id]
+	if r == nil {
+		r = &models.AssignmentResult{Id: id, AssignmentId: assignmentId, UserId: userId}
+		repo.Results[id] = r
+	}
+	r.Attempts++
+	r.BestAccuracy = max(r.BestAccuracy, accuracy)
+	r.UpdatedAt = at
+	if passed && (r.PassedAt.IsZero() || at.Before(r.PassedAt)) {
+		r.PassedAt = at
+	}
+	return nil
+}
+
+func (repo *AssignmentRepo) FindResults(_ context.Context, assignmentIds []string) ([]*models.AssignmentResult, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.AssignmentResult, 0)
+	for _, r := range repo.Results {
+		if slices.Contains(assignmentIds, r.AssignmentId) {
+			cp := *r
+			out = append(out, &cp)
+		}
+	}
+	slices.SortFunc(out, func(a, b *models.AssignmentResult) int { return cmp.Compare(a.Id, b.Id) })
+	return out, nil
+}
+
+func (repo *AssignmentRepo) EnsureIndexes(context.Context) error {
+	return nil
+}
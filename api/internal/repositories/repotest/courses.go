@@ -0,0 +1,138 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type CourseRepo struct {
+	repositories.CourseRepoIface
+	mu      sync.Mutex
+	Courses []*models.Course
+}
+
+func (repo *CourseRepo) Create(_ context.Context, course *models.Course) (*models.Course, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if course.Id == "" {
+		course.Id = fmt.Sprintf("course-%d", len(repo.Courses)+1)
+	}
+	cp := *course
+	cp.SongIds = slices.Clone(course.SongIds)
+	repo.Courses = append(repo.Courses, &cp)
+	return course, nil
+}
+
+func (repo *CourseRepo) FindAll(context.Context) ([]*models.Course, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.Course, 0, len(repo.Courses))
+	for _, c := range repo.Courses {
+		cp := *c
+		cp.SongIds = slices.Clone(c.SongIds)
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (repo *CourseRepo) FindById(_ context.Context, id string) (*models.Course, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, c := range repo.Courses {
+		if c.Id == id {
+			cp := *c
+			cp.SongIds = slices.Clone(c.SongIds)
+			return &cp, nil
+		}
+	}
+	return nil, notFound("courseRepo", id)
+}
+
+func (repo *CourseRepo) Replace(_ context.Context, course *models.Course) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for i, c := range repo.Courses {
+		if c.Id == course.Id {
+			cp := *course
+			cp.SongIds = slices.Clone(course.SongIds)
+			repo.Courses[i] = &cp
+			return nil
+		}
+	}
+	return fmt.Errorf("courseRepo: %w: course %s", repositories.ErrNotFound, course.Id)
+}
+
+type EnrollmentRepo struct {
+	repositories.EnrollmentRepoIface
+	mu          sync.Mutex
+	Enrollments []*models.Enrollment
+}
+
+func cloneEnrollment(e *models.Enrollment) *models.Enrollment {
+	cp := *e
+	cp.BestAccuracy = maps.Clone(e.BestAccuracy)
+	cp.Passed = slices.Clone(e.Passed)
+	cp.LegacyBest = slices.Clone(e.LegacyBest)
+	return &cp
+}
+
+func (repo *EnrollmentRepo) Create(_ context.Context, enrollment *models.Enrollment) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	enrollment.Id = models.EnrollmentId(enrollment.UserId, enrollment.CourseId)
+	for _, e := range repo.Enrollments {
+		if e.Id == enrollment.Id {
+			return fmt.Errorf("enrollmentRepo: %w", repositories.ErrAlreadyExists)
+		}
+	}
+	repo.Enrollments = append(repo.Enrollments, cloneEnrollment(enrollment))
+	return nil
+}
+
+func (repo *EnrollmentRepo) Find(_ context.Context, userId, courseId string) (*models.Enrollment, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	id := models.EnrollmentId(userId, courseId)
+	for _, e := range repo.Enrollments {
+		if e.Id == id {
+			return cloneEnrollment(e), nil
+		}
+	}
+	return nil, notFound("enrollmentRepo", id)
+}
+
+func (repo *EnrollmentRepo) FindLatestActive(_ context.Context, userId string) (*models.Enrollment, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var latest *models.Enrollment
+	for _, e := range repo.Enrollments {
+		if e.UserId == userId && !e.Completed && (latest == nil || !e.UpdatedAt.Before(latest.UpdatedAt)) {
+			latest = e
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	return cloneEnrollment(latest), nil
+}
+
+func (repo *EnrollmentRepo) Update(_ context.Context, enrollment *models.Enrollment) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for i, e := range repo.Enrollments {
+		if e.Id == enrollment.Id {
+			repo.Enrollments[i] = cloneEnrollment(enrollment)
+		}
+	}
+	return nil
+}
+
+func (repo *EnrollmentRepo) EnsureIndexes(context.Context) error {
+	return nil
+}
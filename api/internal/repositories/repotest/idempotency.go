@@ -0,0 +1,54 @@
+package repotest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type IdempotencyRepo struct {
+	repositories.IdempotencyRepoIface
+	mu      sync.Mutex
+	Records map[string]*models.IdempotencyRecord
+}
+
+func (repo *IdempotencyRepo) Reserve(_ context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if existing, ok := repo.Records[rec.Id]; ok && existing.ExpiresAt.After(rec.CreatedAt) {
+		cp := *existing
+		return &cp, nil
+	}
+	if repo.Records == nil {
+		repo.Records = map[string]*models.IdempotencyRecord{}
+	}
+	cp := *rec
+	repo.Records[rec.Id] = &cp
+	return nil, nil
+}
+
+func (repo *IdempotencyRepo) Complete(_ context.Context, id string, status int, body []byte, expiresAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if rec, ok := repo.Records[id]; ok {
+		rec.Status, rec.Body, rec.ExpiresAt = status, slices.Clone(body), expiresAt
+	}
+	return nil
+}
+
+func (repo *IdempotencyRepo) Release(_ context.Context, id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if rec, ok := repo.Records[id]; ok && !rec.Done() {
+		delete(repo.Records, id)
+	}
+	return nil
+}
+
+func (repo *IdempotencyRepo) EnsureIndexes(context.Context) error {
+	return nil
+}
@@ -0,0 +1,282 @@
+package repotest
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type LessonRepo struct {
+	repositories.LessonRepoIface
+	mu      sync.Mutex
+	Lessons []*models.Lesson
+}
+
+func cloneLesson(l *models.Lesson) *models.Lesson {
+	cp := *l
+	cp.Items = slices.Clone(l.Items)
+	cp.Answers = slices.Clone(l.Answers)
+	return &cp
+}
+
+func (repo *LessonRepo) Create(_ context.Context, userId string, lesson *models.Lesson) (*models.Lesson, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if lesson.Id == "" {
+		lesson.Id = fmt.Sprintf("lesson-%d", len(repo.Lessons)+1)
+	}
+	lesson.UserId = userId
+	if lesson.CreatedAt.IsZero() {
+		lesson.CreatedAt = time.Now().UTC()
+	}
+	if lesson.Status == "" {
+		lesson.Status = models.LessonStatusCreated
+	}
+	repo.Lessons = append(repo.Lessons, cloneLesson(lesson))
+	return lesson, nil
+}
+
+func (repo *LessonRepo) find(id string) *models.Lesson {
+	for _, l := range repo.Lessons {
+		if l.Id == id {
+			return l
+		}
+	}
+	return nil
+}
+
+func (repo *LessonRepo) GetById(_ context.Context, id string) (*models.Lesson, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if l := repo.find(id); l != nil {
+		return cloneLesson(l), nil
+	}
+	return nil, notFound("lessonRepo", id)
+}
+
+func (repo *LessonRepo) FindByUser(_ context.Context, filter repositories.LessonFilter) ([]*models.Lesson, string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.Lesson, 0)
+	for _, l := range slices.Backward(repo.Lessons) {
+		switch {
+		case l.UserId != filter.UserId:
+		case filter.Status != "" && l.Status != filter.Status:
+		case !filter.From.IsZero() && l.CreatedAt.Before(filter.From):
+		case !filter.To.IsZero() && !l.CreatedAt.Before(filter.To):
+		default:
+			out = append(out, cloneLesson(l))
+		}
+	}
+	return out, "", nil
+}
+
+func (repo *LessonRepo) AddAnswer(_ context.Context, lessonId string, ans models.LessonAnswer) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	l := repo.find(lessonId)
+	if l == nil || !models.IsLessonOpen(l.Status) || slices.ContainsFunc(l.Answers, func(a models.LessonAnswer) bool {
+		return a.ItemIndex == ans.ItemIndex
+	}) {
+		return fmt.Errorf("lessonRepo: %w: duplicate answer or closed lesson", repositories.ErrConflict)
+	}
+	l.Answers = append(l.Answers, ans)
+	l.UpdatedAt = ans.AnsweredAt
+	l.Status = models.LessonStatusInProgress
+	if l.StartedAt.IsZero() || ans.AnsweredAt.Before(l.StartedAt) {
+		l.StartedAt = ans.AnsweredAt
+	}
+	return nil
+}
+
+// setStatus moves l to status to, stamping the matching timestamp.
+func setStatus(l *models.Lesson, to models.LessonStatus, at time.Time) {
+	l.Status = to
+	switch to {
+	case models.LessonStatusInProgress:
+		l.StartedAt = at
+	case models.LessonStatusCompleted:
+		l.CompletedAt = at
+	case models.LessonStatusAbandoned:
+		l.AbandonedAt = at
+	case models.LessonStatusExpired:
+		l.ExpiredAt = at
+	}
+}
+
+// inStatus reports whether l is in one of statuses; a lesson without a
+// status counts as created, as in the mongo repository.
+func inStatus(l *models.Lesson, statuses []models.LessonStatus) bool {
+	status := l.Status
+	if status == "" {
+		status = models.LessonStatusCreated
+	}
+	return slices.Contains(statuses, status)
+}
+
+func (repo *LessonRepo) SetStatus(_ context.Context, id string, from []models.LessonStatus, to models.LessonStatus, at time.Time) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	l := repo.find(id)
+	if l == nil || !inStatus(l, from) {
+		return false, nil
+	}
+	setStatus(l, to, at)
+	return true, nil
+}
+
+func (repo *LessonRepo) FindLatestOpen(_ context.Context, userId string) (*models.Lesson, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, l := range slices.Backward(repo.Lessons) {
+		if l.UserId == userId && l.ChallengeId == "" && models.IsLessonOpen(l.Status) {
+			return cloneLesson(l), nil
+		}
+	}
+	return nil, nil
+}
+
+func (repo *LessonRepo) AbandonOpen(_ context.Context, userId string, keepId string, at time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var n int64
+	for _, l := range repo.Lessons {
+		if l.UserId == userId && l.Id != keepId && l.ChallengeId == "" && models.IsLessonOpen(l.Status) {
+			setStatus(l, models.LessonStatusAbandoned, at)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (repo *LessonRepo) ExpireStale(_ context.Context, before time.Time, at time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var n int64
+	for _, l := range repo.Lessons {
+		last := l.UpdatedAt
+		if last.IsZero() {
+			last = l.CreatedAt
+		}
+		if l.ChallengeId == "" && models.IsLessonOpen(l.Status) && last.Before(before) {
+			setStatus(l, models.LessonStatusExpired, at)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// UserStats mirrors the mongo aggregation.
+func (repo *LessonRepo) UserStats(_ context.Context, userId string, timezone string) (*repositories.UserLessonStats, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("lessonRepo: %w: timezone %q", repositories.ErrInvalid, timezone)
+	}
+
+	stats := &repositories.UserLessonStats{}
+	daily := map[string]*repositories.LessonStatsBucket{}
+	songs := map[string]*repositories.LessonStatsBucket{}
+	missed := map[string]int{}
+	add := func(b *repositories.LessonStatsBucket, answers, correct int, spent int64) {
+		b.Lessons++
+		b.Answers += answers
+		b.Correct += correct
+		b.TimeSpentMs += spent
+	}
+	bucket := func(m map[string]*repositories.LessonStatsBucket, key string) *repositories.LessonStatsBucket {
+		if m[key] == nil {
+			m[key] = &repositories.LessonStatsBucket{Key: key}
+		}
+		return m[key]
+	}
+	for _, l := range repo.Lessons {
+		if l.UserId != userId {
+			continue
+		}
+		answers, correct := 0, 0
+		for _, a := range l.Answers {
+			if a.Type != models.LessonTypeFillBlanks {
+				continue
+			}
+			answers++
+			if a.Correct {
+				correct++
+			} else {
+				missed[l.ExpectedWordOf(a)]++
+			}
+		}
+		var spent int64
+		if l.UpdatedAt.After(l.CreatedAt) {
+			spent = l.UpdatedAt.Sub(l.CreatedAt).Milliseconds()
+		}
+		add(&stats.Totals, answers, correct, spent)
+		add(bucket(daily, l.CreatedAt.In(loc).Format(time.DateOnly)), answers, correct, spent)
+		add(bucket(songs, l.SongId), answers, correct, spent)
+	}
+
+	for _, b := range daily {
+		stats.Daily = append(stats.Daily, *b)
+	}
+	slices.SortFunc(stats.Daily, func(a, b repositories.LessonStatsBucket) int { return cmp.Compare(a.Key, b.Key) })
+	for _, b := range songs {
+		stats.Songs = append(stats.Songs, *b)
+	}
+	slices.SortFunc(stats.Songs, func(a, b repositories.LessonStatsBucket) int {
+		return cmp.Or(cmp.Compare(b.Answers, a.Answers), cmp.Compare(a.Key, b.Key))
+	})
+	for word, count := range missed {
+		stats.MissedWord = append(stats.MissedWord, repositories.WordCount{Word: word, Count: count})
+	}
+	return stats, nil
+}
+
+// SongAnswerBreakdown groups fillblanks answers on a song as the mongo
+// aggregation does.
+func (repo *LessonRepo) SongAnswerBreakdown(_ context.Context, songId string) ([]repositories.AnswerBreakdownRow, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	counts := map[repositories.AnswerBreakdownRow]int{}
+	for _, l := range repo.Lessons {
+		if l.SongId != songId {
+			continue
+		}
+		for _, a := range l.Answers {
+			if a.Type != models.LessonTypeFillBlanks {
+				continue
+			}
+			counts[repositories.AnswerBreakdownRow{
+				LineIndex: a.LineIndex,
+				Word:      strings.ToLower(l.ExpectedWordOf(a)),
+				Choice:    strings.ToLower(a.UserInput),
+				Correct:   a.Correct,
+			}]++
+		}
+	}
+	rows := make([]repositories.AnswerBreakdownRow, 0, len(counts))
+	for row, n := range counts {
+		row.Count = n
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b repositories.AnswerBreakdownRow) int {
+		return cmp.Or(
+			cmp.Compare(a.LineIndex, b.LineIndex),
+			cmp.Compare(a.Word, b.Word),
+			cmp.Compare(b.Count, a.Count),
+			cmp.Compare(a.Choice, b.Choice),
+		)
+	})
+	return rows, nil
+}
+
+func (repo *LessonRepo) EnsureIndexes(context.Context) error {
+	return nil
+}
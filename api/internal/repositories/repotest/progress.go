@@ -0,0 +1,169 @@
+package repotest
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type WordMasteryRepo struct {
+	repositories.WordMasteryRepoIface
+	mu      sync.Mutex
+	Mastery map[string]*models.WordMastery
+}
+
+func cloneMastery(m *models.WordMastery) *models.WordMastery {
+	cp := *m
+	cp.SongIds = slices.Clone(m.SongIds)
+	return &cp
+}
+
+func (repo *WordMasteryRepo) Find(_ context.Context, userId, word string) (*models.WordMastery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if m, ok := repo.Mastery[models.WordMasteryId(userId, word)]; ok {
+		return cloneMastery(m), nil
+	}
+	return nil, nil
+}
+
+func (repo *WordMasteryRepo) FindByUser(_ context.Context, userId string) ([]*models.WordMastery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.WordMastery, 0)
+	for _, m := range repo.Mastery {
+		if m.UserId == userId {
+			out = append(out, cloneMastery(m))
+		}
+	}
+	slices.SortFunc(out, func(a, b *models.WordMastery) int { return cmp.Compare(a.Word, b.Word) })
+	return out, nil
+}
+
+func (repo *WordMasteryRepo) Record(_ context.Context, userId, word, songId string, correct bool, at time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.Mastery == nil {
+		repo.Mastery = map[string]*models.WordMastery{}
+	}
+	id := models.WordMasteryId(userId, word)
+	if repo.Mastery[id] == nil {
+		repo.Mastery[id] = &models.WordMastery{Id: id, UserId: userId, Word: word}
+	}
+	repo.Mastery[id].Record(correct, songId, at)
+	return nil
+}
+
+func (repo *WordMasteryRepo) EnsureIndexes(context.Context) error {
+	return nil
+}
+
+type ProgressRepo struct {
+	repositories.ProgressRepoIface
+	mu       sync.Mutex
+	Progress map[string]*models.UserProgress
+}
+
+func (repo *ProgressRepo) Find(_ context.Context, userId string) (*models.UserProgress, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if p, ok := repo.Progress[userId]; ok {
+		cp := *p
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+// Save bumps Version like the mongo repository and refuses stale writes.
+func (repo *ProgressRepo) Save(_ context.Context, progress *models.UserProgress) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.Progress == nil {
+		repo.Progress = map[string]*models.UserProgress{}
+	}
+	stored, ok := repo.Progress[progress.Id]
+	if (ok && stored.Version != progress.Version) || (!ok && progress.Version != 0) {
+		return false, nil
+	}
+	progress.Version++
+	cp := *progress
+	repo.Progress[progress.Id] = &cp
+	return true, nil
+}
+
+type LeaderboardRepo struct {
+	repositories.LeaderboardRepoIface
+	mu      sync.Mutex
+	Entries map[string]*models.LeaderboardEntry
+}
+
+func (repo *LeaderboardRepo) entry(kind models.LeaderboardKind, scope, userId string) *models.LeaderboardEntry {
+	if repo.Entries == nil {
+		repo.Entries = map[string]*models.LeaderboardEntry{}
+	}
+	id := models.LeaderboardEntryId(kind, scope, userId)
+	if repo.Entries[id] == nil {
+		repo.Entries[id] = &models.LeaderboardEntry{Id: id, Kind: kind, Scope: scope, UserId: userId}
+	}
+	return repo.Entries[id]
+}
+
+func (repo *LeaderboardRepo) AddScore(_ context.Context, kind models.LeaderboardKind, scope, userId string, delta float64, at time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	e := repo.entry(kind, scope, userId)
+	e.Score += delta
+	e.ReachedAt = at
+	return nil
+}
+
+func (repo *LeaderboardRepo) RecordBest(_ context.Context, kind models.LeaderboardKind, scope, userId string, score float64, at time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	e := repo.entry(kind, scope, userId)
+	if e.ReachedAt.IsZero() || score > e.Score {
+		e.Score, e.ReachedAt = score, at
+	}
+	return nil
+}
+
+// board returns a board in the order of the mongo leaderboard index.
+func (repo *LeaderboardRepo) board(kind models.LeaderboardKind, scope string) []*models.LeaderboardEntry {
+	out := make([]*models.LeaderboardEntry, 0)
+	for _, e := range repo.Entries {
+		if e.Kind == kind && e.Scope == scope {
+			cp := *e
+			out = append(out, &cp)
+		}
+	}
+	slices.SortFunc(out, func(a, b *models.LeaderboardEntry) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), a.ReachedAt.Compare(b.ReachedAt), cmp.Compare(a.UserId, b.UserId))
+	})
+	return out
+}
+
+func (repo *LeaderboardRepo) Page(_ context.Context, filter repositories.LeaderboardFilter) ([]*models.LeaderboardEntry, int, string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.board(filter.Kind, filter.Scope), 1, "", nil
+}
+
+func (repo *LeaderboardRepo) Rank(_ context.Context, kind models.LeaderboardKind, scope, userId string) (int, *models.LeaderboardEntry, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for i, e := range repo.board(kind, scope) {
+		if e.UserId == userId {
+			return i + 1, e, nil
+		}
+	}
+	return 0, nil, nil
+}
+
+func (repo *LeaderboardRepo) EnsureIndexes(context.Context) error {
+	return nil
+}
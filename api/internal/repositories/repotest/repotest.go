@@ -0,0 +1,54 @@
+// Package repotest provides in-memory repositories for service and handler
+// tests.
+//
+// Each fake embeds its interface, so calling a method no test needed yet
+// panics instead of silently succeeding. Filters are honoured only as far as
+// the tests rely on them; listings come back on a single page.
+package repotest
+
+import (
+	"fmt"
+
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+// Repos holds one of every fake, ready to use.
+type Repos struct {
+	Users       *UserRepo
+	Songs       *SongRepo
+	Collections *CollectionRepo
+	Courses     *CourseRepo
+	Enrollments *EnrollmentRepo
+	Lessons     *LessonRepo
+	Mastery     *WordMasteryRepo
+	Progress    *ProgressRepo
+	Boards      *LeaderboardRepo
+	Groups      *GroupRepo
+	Assignments *AssignmentRepo
+	Challenges  *ChallengeRepo
+	Idempotency *IdempotencyRepo
+}
+
+func New() *Repos {
+	return &Repos{
+		Users:       &UserRepo{},
+		Songs:       &SongRepo{},
+		Collections: &CollectionRepo{},
+		Courses:     &CourseRepo{},
+		Enrollments: &EnrollmentRepo{},
+		Lessons:     &LessonRepo{},
+		Mastery:     &WordMasteryRepo{},
+		Progress:    &ProgressRepo{},
+		Boards:      &LeaderboardRepo{},
+		Groups:      &GroupRepo{},
+		Assignments: &AssignmentRepo{},
+		Challenges:  &ChallengeRepo{},
+		Idempotency: &IdempotencyRepo{},
+	}
+}
+
+// notFound matches what the mongo repositories return for a missing
+// document.
+func notFound(repo, id string) error {
+	return fmt.Errorf("%s: %w: %w: %s", repo, repositories.ErrFindOneFailed, repositories.ErrNotFound, id)
+}
@@ -0,0 +1,185 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type SongRepo struct {
+	repositories.SongRepoIface
+	mu    sync.Mutex
+	Songs []*models.Song
+}
+
+// Add stores songs as they are, keeping any id they already have.
+func (repo *SongRepo) Add(songs ...*models.Song) {
+	for _, s := range songs {
+		repo.Create(context.Background(), s)
+	}
+}
+
+func (repo *SongRepo) Create(_ context.Context, song *models.Song) (*models.Song, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if song.Id == "" {
+		song.Id = fmt.Sprintf("song-%d", len(repo.Songs)+1)
+	}
+	lines := make([]string, 0, len(song.Lyrics))
+	for _, ln := range song.Lyrics {
+		lines = append(lines, strings.Join(ln, " "))
+	}
+	song.LineCount = len(song.Lyrics)
+	song.LyricsText = strings.Join(lines, "\n")
+	song.Difficulty = song.EffectiveDifficulty()
+	cp := *song
+	repo.Songs = append(repo.Songs, &cp)
+	return song, nil
+}
+
+func (repo *SongRepo) find(id string) *models.Song {
+	for _, s := range repo.Songs {
+		if s.Id == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (repo *SongRepo) FindById(_ context.Context, id string) (*models.Song, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if s := repo.find(id); s != nil {
+		cp := *s
+		return &cp, nil
+	}
+	return nil, notFound("songRepo", id)
+}
+
+func (repo *SongRepo) FindAll(context.Context) ([]*models.Song, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.Song, 0, len(repo.Songs))
+	for _, s := range repo.Songs {
+		cp := *s
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+// matches honours the filters lesson creation uses: ids, tag and difficulty.
+func matches(s *models.Song, filter repositories.SongFilter) bool {
+	switch {
+	case filter.Ids != nil && !slices.Contains(filter.Ids, s.Id):
+		return false
+	case filter.Tag != "" && !slices.Contains(s.Tags, strings.ToLower(filter.Tag)):
+		return false
+	case filter.MinDifficulty > 0 && s.Difficulty < filter.MinDifficulty:
+		return false
+	case filter.MaxDifficulty > 0 && s.Difficulty >= filter.MaxDifficulty:
+		return false
+	}
+	return true
+}
+
+func (repo *SongRepo) FindIds(_ context.Context, filter repositories.SongFilter) ([]string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	ids := make([]string, 0, len(repo.Songs))
+	for _, s := range repo.Songs {
+		if matches(s, filter) {
+			ids = append(ids, s.Id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (repo *SongRepo) Search(_ context.Context, filter repositories.SongFilter) ([]*models.Song, string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.Song, 0, len(repo.Songs))
+	for _, s := range repo.Songs {
+		if matches(s, filter) {
+			cp := *s
+			out = append(out, &cp)
+		}
+	}
+	return out, "", nil
+}
+
+func (repo *SongRepo) UpdateMetadata(_ context.Context, id string, meta repositories.SongMetadata) (*models.Song, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	s := repo.find(id)
+	if s == nil {
+		return nil, notFound("songRepo", id)
+	}
+	s.Tags, s.Genres, s.Language = meta.Tags, meta.Genres, meta.Language
+	s.AdminDifficulty = meta.AdminDifficulty
+	s.Difficulty = s.EffectiveDifficulty()
+	cp := *s
+	return &cp, nil
+}
+
+func (repo *SongRepo) EnsureIndexes(context.Context) error {
+	return nil
+}
+
+type CollectionRepo struct {
+	repositories.CollectionRepoIface
+	mu          sync.Mutex
+	Collections []*models.Collection
+}
+
+func (repo *CollectionRepo) Create(_ context.Context, collection *models.Collection) (*models.Collection, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if collection.Id == "" {
+		collection.Id = fmt.Sprintf("collection-%d", len(repo.Collections)+1)
+	}
+	cp := *collection
+	repo.Collections = append(repo.Collections, &cp)
+	return collection, nil
+}
+
+func (repo *CollectionRepo) FindAll(context.Context) ([]*models.Collection, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.Collection, 0, len(repo.Collections))
+	for _, c := range repo.Collections {
+		cp := *c
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (repo *CollectionRepo) FindById(_ context.Context, id string) (*models.Collection, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, c := range repo.Collections {
+		if c.Id == id {
+			cp := *c
+			return &cp, nil
+		}
+	}
+	return nil, notFound("collectionRepo", id)
+}
+
+func (repo *CollectionRepo) Replace(_ context.Context, collection *models.Collection) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for i, c := range repo.Collections {
+		if c.Id == collection.Id {
+			cp := *collection
+			repo.Collections[i] = &cp
+			return nil
+		}
+	}
+	return fmt.Errorf("collectionRepo: %w: collection %s", repositories.ErrNotFound, collection.Id)
+}
@@ -0,0 +1,114 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type UserRepo struct {
+	repositories.UserRepoIface
+	mu    sync.Mutex
+	Users []*models.User
+}
+
+func (repo *UserRepo) Create(_ context.Context, user *models.User) (string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if user.Id == "" {
+		user.Id = fmt.Sprintf("user-%d", len(repo.Users)+1)
+	}
+	repo.Users = append(repo.Users, cloneUser(user))
+	return user.Id, nil
+}
+
+func (repo *UserRepo) find(id string) *models.User {
+	for _, u := range repo.Users {
+		if u.Id == id {
+			return u
+		}
+	}
+	return nil
+}
+
+func (repo *UserRepo) FindOne(_ context.Context, id string) (*models.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if u := repo.find(id); u != nil {
+		return cloneUser(u), nil
+	}
+	return nil, notFound("userRepo", id)
+}
+
+func (repo *UserRepo) FindAll(context.Context) ([]*models.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.User, 0, len(repo.Users))
+	for _, u := range repo.Users {
+		out = append(out, cloneUser(u))
+	}
+	return out, nil
+}
+
+func (repo *UserRepo) FindByIds(_ context.Context, ids []string) ([]*models.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.User, 0, len(ids))
+	for _, u := range repo.Users {
+		if slices.Contains(ids, u.Id) {
+			out = append(out, cloneUser(u))
+		}
+	}
+	return out, nil
+}
+
+// Search honours the name query and returns every match on one page.
+func (repo *UserRepo) Search(_ context.Context, filter repositories.UserFilter) ([]*models.User, string, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.User, 0, len(repo.Users))
+	for _, u := range repo.Users {
+		if strings.Contains(strings.ToLower(u.Name), strings.ToLower(filter.Query)) {
+			out = append(out, cloneUser(u))
+		}
+	}
+	return out, "", nil
+}
+
+func (repo *UserRepo) AddMembership(_ context.Context, userId string, membership models.Membership) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	u := repo.find(userId)
+	if u == nil || u.MembershipOf(membership.GroupId) != nil {
+		return fmt.Errorf("userRepo: %w", repositories.ErrAlreadyExists)
+	}
+	u.Memberships = append(u.Memberships, membership)
+	return nil
+}
+
+func (repo *UserRepo) FindByGroup(_ context.Context, groupId string, role models.GroupRole) ([]*models.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	out := make([]*models.User, 0)
+	for _, u := range repo.Users {
+		if m := u.MembershipOf(groupId); m != nil && m.Role == role {
+			out = append(out, cloneUser(u))
+		}
+	}
+	return out, nil
+}
+
+func (repo *UserRepo) EnsureIndexes(context.Context) error {
+	return nil
+}
+
+func cloneUser(u *models.User) *models.User {
+	cp := *u
+	cp.Memberships = slices.Clone(u.Memberships)
+	return &cp
+}
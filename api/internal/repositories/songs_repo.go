@@ -4,16 +4,63 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"strings"
 
 	"github.tomerab1/todo-api/internal/models"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type SongRepoIface interface {
 	Create(ctx context.Context, song *models.Song) (*models.Song, error)
 	FindAll(ctx context.Context) ([]*models.Song, error)
+	FindById(ctx context.Context, id string) (*models.Song, error)
+	FindIds(ctx context.Context, filter SongFilter) ([]string, error)
+	Search(ctx context.Context, filter SongFilter) ([]*models.Song, string, error)
+	UpdateMetadata(ctx context.Context, id string, meta SongMetadata) (*models.Song, error)
+	// BackfillSearchFields sets line_count and lyrics_text on songs stored
+	// before search and line filters existed. It returns the number of songs
+	// updated.
+	BackfillSearchFields(ctx context.Context) (int64, error)
+	CountSearchFieldsToBackfill(ctx context.Context) (int64, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+// SongFilter narrows and orders a song listing. Zero values mean "no filter".
+type SongFilter struct {
+	Query    string // free text over title, artist and lyrics
+	Artist   string
+	Tag      string
+	Genre    string
+	Language string
+	MinLines int
+	MaxLines int
+	// difficulty window, MinDifficulty inclusive and MaxDifficulty exclusive
+	MinDifficulty float64
+	MaxDifficulty float64
+	Ids           []string // restrict to these songs, e.g. a collection
+	Sort          string   // title, artist, lineCount, difficulty or id; prefix with "-" for descending
+	Cursor        string
+	Limit         int
+}
+
+// SongMetadata is the admin-editable part of a song.
+type SongMetadata struct {
+	Tags            []string
+	Genres          []string
+	Language        string
+	AdminDifficulty int // 0 clears the override
+}
+
+var songSortFields = map[string]string{
+	"id":         "_id",
+	"title":      "title",
+	"artist":     "artist",
+	"lineCount":  "line_count",
+	"difficulty": "difficulty",
 }
 
 type SongRepoMongoImpl struct {
@@ -31,6 +78,31 @@ func NewSongRepoMongo(
 	}
 }
 
+func (repo *SongRepoMongoImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "artist", Value: "text"}, {Key: "lyrics_text", Value: "text"}},
+			Options: options.Index().
+				SetName("songs_text").
+				SetWeights(bson.D{{Key: "title", Value: 10}, {Key: "artist", Value: 5}, {Key: "lyrics_text", Value: 1}}).
+				SetDefaultLanguage("none").
+				// songs carry their own "language" field, keep mongo from reading it
+				SetLanguageOverride("text_language"),
+		},
+		{Keys: bson.D{{Key: "artist", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "line_count", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "difficulty", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "genres", Value: 1}}},
+		{Keys: bson.D{{Key: "language", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("songRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
 func (repo *SongRepoMongoImpl) Create(
 	ctx context.Context,
 	song *models.Song,
@@ -38,10 +110,13 @@ func (repo *SongRepoMongoImpl) Create(
 	if song.Id == "" {
 		song.Id = primitive.NewObjectID().Hex()
 	}
+	song.LineCount = len(song.Lyrics)
+	song.LyricsText = lyricsText(song.Lyrics)
+	song.Difficulty = song.EffectiveDifficulty()
 
 	_, err := repo.coll.InsertOne(ctx, song)
 	if err != nil {
-		return nil, fmt.Errorf("songRepo: %w: %v", ErrInsertFailed, err)
+		return nil, fmt.Errorf("songRepo: %w: %w", ErrInsertFailed, dbError(err))
 	}
 
 	return song, nil
@@ -52,14 +127,244 @@ func (repo *SongRepoMongoImpl) FindAll(
 ) ([]*models.Song, error) {
 	cursor, err := repo.coll.Find(ctx, bson.M{})
 	if err != nil {
-		return nil, fmt.Errorf("songRepo: %w: %v", ErrFindAllFailed, err)
+		return nil, fmt.Errorf("songRepo: %w: %w", ErrFindAllFailed, dbError(err))
 	}
 	defer cursor.Close(ctx)
 
 	var songs []*models.Song
 	if err := cursor.All(ctx, &songs); err != nil {
-		return nil, fmt.Errorf("songRepo: %w: %v", ErrFindAllFailed, err)
+		return nil, fmt.Errorf("songRepo: %w: %w", ErrFindAllFailed, dbError(err))
 	}
 
 	return songs, nil
 }
+
+// Search returns one page of songs matching filter and the cursor for the
+// next page, which is empty on the last page.
+func (repo *SongRepoMongoImpl) Search(
+	ctx context.Context,
+	filter SongFilter,
+) ([]*models.Song, string, error) {
+	sortKey, field, dir, err := sortSpec(filter.Sort, songSortFields, "id")
+	if err != nil {
+		return nil, "", err
+	}
+	limit := clampLimit(filter.Limit)
+
+	conds := songConds(filter)
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if c.Sort != sortKey {
+			return nil, "", ErrInvalidCursor
+		}
+		conds = append(conds, keysetFilter(field, dir, c))
+	}
+
+	query := bson.M{}
+	if len(conds) > 0 {
+		query = bson.M{"$and": conds}
+	}
+
+	opts := options.Find().
+		SetSort(sortDoc(field, dir)).
+		SetLimit(int64(limit + 1)).
+		SetProjection(bson.M{"lyrics_text": 0})
+	cursor, err := repo.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("songRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	songs := make([]*models.Song, 0, limit)
+	if err := cursor.All(ctx, &songs); err != nil {
+		return nil, "", fmt.Errorf("songRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	next := ""
+	if len(songs) > limit {
+		songs = songs[:limit]
+		last := songs[len(songs)-1]
+		next = encodeCursor(pageCursor{Sort: sortKey, Value: songSortValue(last, field), Id: last.Id})
+	}
+	return songs, next, nil
+}
+
+func (repo *SongRepoMongoImpl) FindById(
+	ctx context.Context,
+	id string,
+) (*models.Song, error) {
+	var song models.Song
+	if err := repo.coll.FindOne(ctx, bson.M{"_id": id}).Decode(&song); err != nil {
+		return nil, fmt.Errorf("songRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+	return &song, nil
+}
+
+// FindIds returns the ids of every song matching filter in id order, ignoring
+// paging. Callers pick from it without loading whole songs.
+func (repo *SongRepoMongoImpl) FindIds(
+	ctx context.Context,
+	filter SongFilter,
+) ([]string, error) {
+	query := bson.M{}
+	if conds := songConds(filter); len(conds) > 0 {
+		query = bson.M{"$and": conds}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"_id": 1})
+	cursor, err := repo.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("songRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Id string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("songRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.Id)
+	}
+	return ids, nil
+}
+
+func (repo *SongRepoMongoImpl) UpdateMetadata(
+	ctx context.Context,
+	id string,
+	meta SongMetadata,
+) (*models.Song, error) {
+	song, err := repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	song.Tags = meta.Tags
+	song.Genres = meta.Genres
+	song.Language = meta.Language
+	song.AdminDifficulty = meta.AdminDifficulty
+	song.Difficulty = song.EffectiveDifficulty()
+
+	_, err = repo.coll.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"tags":             song.Tags,
+		"genres":           song.Genres,
+		"language":         song.Language,
+		"admin_difficulty": song.AdminDifficulty,
+		"difficulty":       song.Difficulty,
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("songRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return song, nil
+}
+
+func songConds(filter SongFilter) bson.A {
+	conds := bson.A{}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		conds = append(conds, bson.M{"$text": bson.M{"$search": q}})
+	}
+	if filter.Artist != "" {
+		conds = append(conds, bson.M{"artist": bson.M{"$regex": "^" + regexp.QuoteMeta(filter.Artist) + "$", "$options": "i"}})
+	}
+	if filter.Tag != "" {
+		conds = append(conds, bson.M{"tags": strings.ToLower(filter.Tag)})
+	}
+	if filter.Genre != "" {
+		conds = append(conds, bson.M{"genres": strings.ToLower(filter.Genre)})
+	}
+	if filter.Language != "" {
+		conds = append(conds, bson.M{"language": strings.ToLower(filter.Language)})
+	}
+	if filter.MinLines > 0 {
+		conds = append(conds, bson.M{"line_count": bson.M{"$gte": filter.MinLines}})
+	}
+	if filter.MaxLines > 0 {
+		conds = append(conds, bson.M{"line_count": bson.M{"$lte": filter.MaxLines}})
+	}
+	if filter.MinDifficulty > 0 {
+		conds = append(conds, bson.M{"difficulty": bson.M{"$gte": filter.MinDifficulty}})
+	}
+	if filter.MaxDifficulty > 0 {
+		conds = append(conds, bson.M{"difficulty": bson.M{"$lt": filter.MaxDifficulty}})
+	}
+	if filter.Ids != nil {
+		conds = append(conds, bson.M{"_id": bson.M{"$in": filter.Ids}})
+	}
+	return conds
+}
+
+func songSortValue(song *models.Song, field string) any {
+	switch field {
+	case "title":
+		return song.Title
+	case "artist":
+		return song.Artist
+	case "line_count":
+		return song.LineCount
+	case "difficulty":
+		return song.Difficulty
+	}
+	return song.Id
+}
+
+// legacySearchFilter matches songs missing a field Create derives from the
+// lyrics. lyrics_text is omitted for songs without lyrics, so only songs
+// with lines need it.
+var legacySearchFilter = bson.M{"$or": bson.A{
+	bson.M{"line_count": bson.M{"$exists": false}},
+	bson.M{"lyrics_text": bson.M{"$exists": false}, "lyrics.0": bson.M{"$exists": true}},
+}}
+
+func (repo *SongRepoMongoImpl) CountSearchFieldsToBackfill(ctx context.Context) (int64, error) {
+	n, err := repo.coll.CountDocuments(ctx, legacySearchFilter)
+	if err != nil {
+		return 0, fmt.Errorf("songRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	return n, nil
+}
+
+func (repo *SongRepoMongoImpl) BackfillSearchFields(ctx context.Context) (int64, error) {
+	lyrics := bson.M{"$ifNull": bson.A{"$lyrics", bson.A{}}}
+	lines := bson.M{"$map": bson.M{"input": lyrics, "as": "ln", "in": joinExpr("$$ln", " ")}}
+	pipeline := bson.A{bson.M{"$set": bson.M{
+		"line_count":  bson.M{"$size": lyrics},
+		"lyrics_text": joinExpr(lines, "\n"),
+	}}}
+
+	res, err := repo.coll.UpdateMany(ctx, legacySearchFilter, pipeline)
+	if err != nil {
+		return 0, fmt.Errorf("songRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	return res.ModifiedCount, nil
+}
+
+// joinExpr is strings.Join as an aggregation expression.
+func joinExpr(input any, sep string) bson.M {
+	return bson.M{"$ifNull": bson.A{
+		bson.M{"$reduce": bson.M{
+			"input":        input,
+			"initialValue": nil,
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$$value", nil}},
+				"$$this",
+				bson.M{"$concat": bson.A{"$$value", sep, "$$this"}},
+			}},
+		}},
+		"",
+	}}
+}
+
+// lyricsText flattens lyrics for the text index. BackfillSearchFields
+// computes the same in the database.
+func lyricsText(lines [][]string) string {
+	parts := make([]string, 0, len(lines))
+	for _, ln := range lines {
+		parts = append(parts, strings.Join(ln, " "))
+	}
+	return strings.Join(parts, "\n")
+}
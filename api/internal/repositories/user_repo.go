@@ -4,17 +4,40 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"strings"
 
 	"github.tomerab1/todo-api/internal/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
 type UserRepoIface interface {
 	Create(ctx context.Context, user *models.User) (string, error)
 	FindAll(ctx context.Context) ([]*models.User, error)
 	FindOne(ctx context.Context, uuid string) (*models.User, error)
+	FindByIds(ctx context.Context, ids []string) ([]*models.User, error)
+	Search(ctx context.Context, filter UserFilter) ([]*models.User, string, error)
+	// AddMembership puts a user in a group; ErrAlreadyExists when the user is
+	// in it already.
+	AddMembership(ctx context.Context, userId string, membership models.Membership) error
+	FindByGroup(ctx context.Context, groupId string, role models.GroupRole) ([]*models.User, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+// UserFilter narrows and orders a user listing. Zero values mean "no filter".
+type UserFilter struct {
+	Query  string // case-insensitive substring of the name
+	Sort   string // name or id; prefix with "-" for descending
+	Cursor string
+	Limit  int
+}
+
+var userSortFields = map[string]string{
+	"id":   "_id",
+	"name": "name",
 }
 
 type UserRepoMongoImpl struct {
@@ -32,6 +55,17 @@ func NewUserRepoMongo(
 	}
 }
 
+func (repo *UserRepoMongoImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "memberships.group_id", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("userRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
 func (repo *UserRepoMongoImpl) Create(
 	ctx context.Context,
 	user *models.User,
@@ -42,7 +76,7 @@ func (repo *UserRepoMongoImpl) Create(
 
 	_, err := repo.coll.InsertOne(ctx, user)
 	if err != nil {
-		return "", fmt.Errorf("userRepo: %w: %v", ErrInsertFailed, err)
+		return "", fmt.Errorf("userRepo: %w: %w", ErrInsertFailed, dbError(err))
 	}
 
 	return user.Id, nil
@@ -53,13 +87,13 @@ func (repo *UserRepoMongoImpl) FindAll(
 ) ([]*models.User, error) {
 	cursor, err := repo.coll.Find(ctx, bson.D{})
 	if err != nil {
-		return nil, fmt.Errorf("userRepo: %w: %v", ErrFindAllFailed, err)
+		return nil, fmt.Errorf("userRepo: %w: %w", ErrFindAllFailed, dbError(err))
 	}
 	defer cursor.Close(ctx)
 
 	var users []*models.User
 	if err := cursor.All(ctx, &users); err != nil {
-		return nil, fmt.Errorf("userRepo: %w: %v", ErrFindAllFailed, err)
+		return nil, fmt.Errorf("userRepo: %w: %w", ErrFindAllFailed, dbError(err))
 	}
 
 	return users, nil
@@ -71,8 +105,124 @@ func (repo *UserRepoMongoImpl) FindOne(
 ) (*models.User, error) {
 	var user models.User
 	if err := repo.coll.FindOne(ctx, bson.D{{Key: "_id", Value: uuid}}).Decode(&user); err != nil {
-		return nil, fmt.Errorf("userRepo: %w: %v", ErrFindOneFailed, err)
+		return nil, fmt.Errorf("userRepo: %w: %w", ErrFindOneFailed, dbError(err))
 	}
 
 	return &user, nil
 }
+
+func (repo *UserRepoMongoImpl) FindByIds(
+	ctx context.Context,
+	ids []string,
+) ([]*models.User, error) {
+	cursor, err := repo.coll.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, fmt.Errorf("userRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("userRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	return users, nil
+}
+
+func (repo *UserRepoMongoImpl) AddMembership(
+	ctx context.Context,
+	userId string,
+	membership models.Membership,
+) error {
+	res, err := repo.coll.UpdateOne(ctx,
+		bson.M{"_id": userId, "memberships.group_id": bson.M{"$ne": membership.GroupId}},
+		bson.M{"$push": bson.M{"memberships": membership}},
+	)
+	if err != nil {
+		return fmt.Errorf("userRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("userRepo: %w", ErrAlreadyExists)
+	}
+
+	return nil
+}
+
+func (repo *UserRepoMongoImpl) FindByGroup(
+	ctx context.Context,
+	groupId string,
+	role models.GroupRole,
+) ([]*models.User, error) {
+	cursor, err := repo.coll.Find(ctx,
+		bson.M{"memberships": bson.M{"$elemMatch": bson.M{"group_id": groupId, "role": role}}},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("userRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	users := make([]*models.User, 0)
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, fmt.Errorf("userRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	return users, nil
+}
+
+// Search returns one page of users matching filter and the cursor for the
+// next page, which is empty on the last page.
+func (repo *UserRepoMongoImpl) Search(
+	ctx context.Context,
+	filter UserFilter,
+) ([]*models.User, string, error) {
+	sortKey, field, dir, err := sortSpec(filter.Sort, userSortFields, "id")
+	if err != nil {
+		return nil, "", err
+	}
+	limit := clampLimit(filter.Limit)
+
+	conds := bson.A{}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		conds = append(conds, bson.M{"name": bson.M{"$regex": regexp.QuoteMeta(q), "$options": "i"}})
+	}
+	if filter.Cursor != "" {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if c.Sort != sortKey {
+			return nil, "", ErrInvalidCursor
+		}
+		conds = append(conds, keysetFilter(field, dir, c))
+	}
+
+	query := bson.M{}
+	if len(conds) > 0 {
+		query = bson.M{"$and": conds}
+	}
+
+	opts := options.Find().SetSort(sortDoc(field, dir)).SetLimit(int64(limit + 1))
+	cursor, err := repo.coll.Find(ctx, query, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("userRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	users := make([]*models.User, 0, limit)
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, "", fmt.Errorf("userRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	next := ""
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[len(users)-1]
+		value := any(last.Id)
+		if field == "name" {
+			value = last.Name
+		}
+		next = encodeCursor(pageCursor{Sort: sortKey, Value: value, Id: last.Id})
+	}
+	return users, next, nil
+}
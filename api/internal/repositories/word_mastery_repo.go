@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type WordMasteryRepoIface interface {
+	// Find returns the mastery row of a word, or nil when the user never saw it.
+	Find(ctx context.Context, userId, word string) (*models.WordMastery, error)
+	FindByUser(ctx context.Context, userId string) ([]*models.WordMastery, error)
+	// Record applies one graded answer to the user's row of word, creating
+	// it on first sight, in a single atomic update as WordMastery.Record
+	// would.
+	Record(ctx context.Context, userId, word, songId string, correct bool, at time.Time) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+type WordMasteryRepoMongoImpl struct {
+	coll   *mongo.Collection
+	logger *slog.Logger
+}
+
+func NewWordMasteryRepoMongo(
+	coll *mongo.Collection,
+	logger *slog.Logger,
+) WordMasteryRepoIface {
+	return &WordMasteryRepoMongoImpl{
+		coll:   coll,
+		logger: logger,
+	}
+}
+
+func (repo *WordMasteryRepoMongoImpl) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.coll.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "word", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("wordMasteryRepo: create indexes: %w", err)
+	}
+	return nil
+}
+
+func (repo *WordMasteryRepoMongoImpl) Find(
+	ctx context.Context,
+	userId string,
+	word string,
+) (*models.WordMastery, error) {
+	var mastery models.WordMastery
+	err := repo.coll.FindOne(ctx, bson.M{"_id": models.WordMasteryId(userId, word)}).Decode(&mastery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("wordMasteryRepo: %w: %w", ErrFindOneFailed, dbError(err))
+	}
+
+	return &mastery, nil
+}
+
+func (repo *WordMasteryRepoMongoImpl) FindByUser(
+	ctx context.Context,
+	userId string,
+) ([]*models.WordMastery, error) {
+	cursor, err := repo.coll.Find(ctx, bson.M{"user_id": userId}, options.Find().SetSort(bson.D{{Key: "word", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("wordMasteryRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+	defer cursor.Close(ctx)
+
+	var rows []*models.WordMastery
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("wordMasteryRepo: %w: %w", ErrFindAllFailed, dbError(err))
+	}
+
+	return rows, nil
+}
+
+// Record runs WordMastery.Record as an update pipeline, so concurrent
+// answers on the same word cannot overwrite each other's counts.
+func (repo *WordMasteryRepoMongoImpl) Record(
+	ctx context.Context,
+	userId, word, songId string,
+	correct bool,
+	at time.Time,
+) error {
+	score := bson.M{"$ifNull": bson.A{"$score", 0.0}}
+	decayed := bson.M{"$cond": bson.A{
+		bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$last_seen_at", at}}, at}},
+		bson.M{"$multiply": bson.A{score, bson.M{"$pow": bson.A{0.5, bson.M{"$divide": bson.A{
+			bson.M{"$subtract": bson.A{at, "$last_seen_at"}},
+			models.MasteryHalfLife.Milliseconds(),
+		}}}}}},
+		score,
+	}}
+	next := bson.M{"$multiply": bson.A{"$$decayed", 0.5}}
+	correctInc := 0
+	if correct {
+		next = bson.M{"$add": bson.A{"$$decayed", bson.M{"$multiply": bson.A{bson.M{"$subtract": bson.A{1, "$$decayed"}}, 0.3}}}}
+		correctInc = 1
+	}
+	songIds := bson.M{"$ifNull": bson.A{"$song_ids", bson.A{}}}
+	if songId != "" {
+		songIds = bson.M{"$cond": bson.A{
+			bson.M{"$in": bson.A{songId, songIds}},
+			songIds,
+			bson.M{"$concatArrays": bson.A{songIds, bson.A{songId}}},
+		}}
+	}
+
+	pipeline := bson.A{bson.M{"$set": bson.M{
+		"user_id":       userId,
+		"word":          word,
+		"score":         bson.M{"$let": bson.M{"vars": bson.M{"decayed": decayed}, "in": next}},
+		"seen_count":    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$seen_count", 0}}, 1}},
+		"correct_count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$correct_count", 0}}, correctInc}},
+		"last_seen_at":  bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$last_seen_at", at}}, at}},
+		"song_ids":      songIds,
+	}}}
+	_, err := repo.coll.UpdateOne(ctx,
+		bson.M{"_id": models.WordMasteryId(userId, word)},
+		pipeline,
+		options.UpdateOne().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("wordMasteryRepo: %w: %w", ErrUpdateFailed, dbError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+var (
+	// ErrChallengeState is returned for actions the challenge's status does
+	// not allow, such as accepting twice.
+	ErrChallengeState = newError(ErrConflict, "challenge does not allow this now")
+	// ErrChallengePlayed is returned when a player starts a second lesson
+	// for the same challenge.
+	ErrChallengePlayed = newError(ErrConflict, "challenge lesson already started")
+)
+
+type ChallengeService struct {
+	challengeRepo repositories.ChallengeRepoIface
+	userRepo      repositories.UserRepoIface
+	songRepo      repositories.SongRepoIface
+	lessonSize    int
+	clock         Clock
+	seeds         SeedSource
+	logger        *slog.Logger
+}
+
+func NewChallengeService(
+	challengeRepo repositories.ChallengeRepoIface,
+	userRepo repositories.UserRepoIface,
+	songRepo repositories.SongRepoIface,
+	logger *slog.Logger,
+) *ChallengeService {
+	return &ChallengeService{
+		challengeRepo: challengeRepo,
+		userRepo:      userRepo,
+		songRepo:      songRepo,
+		lessonSize:    DefaultLessonSize,
+		clock:         time.Now,
+		seeds:         rand.Int64,
+		logger:        logger,
+	}
+}
+
+// WithLessonSize changes the number of items new challenges are played with.
+// The size is stored on the challenge, so changing it leaves open
+// challenges as they were.
+func (svc *ChallengeService) WithLessonSize(size int) *ChallengeService {
+	svc.lessonSize = size
+	return svc
+}
+
+// WithClock replaces the clock stamping challenges and their answers.
+func (svc *ChallengeService) WithClock(clock Clock) *ChallengeService {
+	svc.clock = clock
+	return svc
+}
+
+// WithSeedSource replaces the source of challenge seeds.
+func (svc *ChallengeService) WithSeedSource(seeds SeedSource) *ChallengeService {
+	svc.seeds = seeds
+	return svc
+}
+
+func (svc *ChallengeService) now() time.Time {
+	return svc.clock().UTC()
+}
+
+// CreateChallenge invites an opponent to play a song. The seed and size
+// fixing both lessons are set here, once.
+func (svc *ChallengeService) CreateChallenge(
+	ctx context.Context,
+	dto contracts.CreateChallengeDto,
+) (*contracts.ChallengeResponse, error) {
+	if dto.ChallengerId == dto.OpponentId {
+		return nil, invalid("opponentId", "must differ from challengerId")
+	}
+	for _, id := range []string{dto.ChallengerId, dto.OpponentId} {
+		if _, err := svc.userRepo.FindOne(ctx, id); err != nil {
+			return nil, missing(err, "user with id=%s was not found", id)
+		}
+	}
+	song, err := svc.songRepo.FindById(ctx, dto.SongId)
+	if err != nil {
+		return nil, missing(err, "song with id=%s was not found", dto.SongId)
+	}
+	// both lessons are built from this song, so it must yield an item the
+	// server grades or neither player could ever finish
+	if !gradable(song.Lyrics) {
+		return nil, ungradableSong(song.Id)
+	}
+
+	challenge, err := svc.challengeRepo.Create(ctx, &models.Challenge{
+		ChallengerId: dto.ChallengerId,
+		OpponentId:   dto.OpponentId,
+		SongId:       song.Id,
+		Seed:         svc.seeds(),
+		LessonSize:   svc.lessonSize,
+		Status:       models.ChallengeStatusPending,
+		CreatedAt:    svc.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toChallengeResponse(challenge), nil
+}
+
+func (svc *ChallengeService) Accept(
+	ctx context.Context,
+	challengeId string,
+	dto contracts.ChallengeActionDto,
+) (*contracts.ChallengeResponse, error) {
+	return svc.respond(ctx, challengeId, dto.UserId, models.ChallengeStatusAccepted)
+}
+
+func (svc *ChallengeService) Decline(
+	ctx context.Context,
+	challengeId string,
+	dto contracts.ChallengeActionDto,
+) (*contracts.ChallengeResponse, error) {
+	return svc.respond(ctx, challengeId, dto.UserId, models.ChallengeStatusDeclined)
+}
+
+// respond lets the opponent answer a pending challenge.
+func (svc *ChallengeService) respond(
+	ctx context.Context,
+	challengeId string,
+	userId string,
+	to models.ChallengeStatus,
+) (*contracts.ChallengeResponse, error) {
+	challenge, err := svc.challengeRepo.FindById(ctx, challengeId)
+	if err != nil {
+		return nil, missing(err, "challenge with id=%s was not found", challengeId)
+	}
+	if challenge.RoleOf(userId) != models.ChallengeRoleOpponent {
+		return nil, newError(ErrForbidden, "only the opponent can answer a challenge")
+	}
+	done, err := svc.challengeRepo.SetStatus(ctx, challengeId, []models.ChallengeStatus{models.ChallengeStatusPending}, to, svc.now())
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		return nil, fmt.Errorf("%w: challenge is %s", ErrChallengeState, challenge.Status)
+	}
+	if challenge, err = svc.challengeRepo.FindById(ctx, challengeId); err != nil {
+		return nil, err
+	}
+	return toChallengeResponse(challenge), nil
+}
+
+func (svc *ChallengeService) GetUserChallenges(
+	ctx context.Context,
+	userId string,
+	limit int,
+) ([]contracts.ChallengeResponse, error) {
+	if _, err := svc.userRepo.FindOne(ctx, userId); err != nil {
+		return nil, missing(err, "user with id=%s was not found", userId)
+	}
+	challenges, err := svc.challengeRepo.FindByUser(ctx, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]contracts.ChallengeResponse, 0, len(challenges))
+	for _, c := range challenges {
+		resp = append(resp, *toChallengeResponse(c))
+	}
+	return resp, nil
+}
+
+// GetResult reports who has finished and, once both have, how they did.
+func (svc *ChallengeService) GetResult(
+	ctx context.Context,
+	challengeId string,
+) (*contracts.ChallengeResultResponse, error) {
+	challenge, err := svc.challengeRepo.FindById(ctx, challengeId)
+	if err != nil {
+		return nil, missing(err, "challenge with id=%s was not found", challengeId)
+	}
+
+	completed := challenge.Status == models.ChallengeStatusCompleted
+	participant := func(userId string, e models.ChallengeEntry) contracts.ChallengeParticipantResult {
+		p := contracts.ChallengeParticipantResult{UserId: userId, LessonId: e.LessonId, Finished: e.Finished}
+		if completed {
+			p.Forfeited = e.Forfeited
+			p.Accuracy = e.Accuracy
+			p.Correct = e.Correct
+			p.Wrong = e.Wrong
+			p.DurationSeconds = e.DurationSeconds
+		}
+		return p
+	}
+	return &contracts.ChallengeResultResponse{
+		ChallengeId: challenge.Id,
+		Status:      challenge.Status,
+		WinnerId:    challenge.WinnerId,
+		Draw:        completed && challenge.WinnerId == "",
+		Challenger:  participant(challenge.ChallengerId, challenge.Challenger),
+		Opponent:    participant(challenge.OpponentId, challenge.Opponent),
+	}, nil
+}
+
+func toChallengeResponse(c *models.Challenge) *contracts.ChallengeResponse {
+	return &contracts.ChallengeResponse{
+		Id:           c.Id,
+		ChallengerId: c.ChallengerId,
+		OpponentId:   c.OpponentId,
+		SongId:       c.SongId,
+		Status:       c.Status,
+		CreatedAt:    c.CreatedAt,
+		AcceptedAt:   timePtr(c.AcceptedAt),
+		DeclinedAt:   timePtr(c.DeclinedAt),
+		CompletedAt:  timePtr(c.CompletedAt),
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories/repotest"
+)
+
+// TestCreateChallengeNeedsGradableSong checks that a challenge is refused on a
+// song neither player could finish, and that an accepted one takes its seed
+// and times from the injected sources.
+func TestCreateChallengeNeedsGradableSong(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 4, 2, 9, 0, 0, 0, time.UTC)
+	repos := repotest.New()
+	repos.Users.Create(ctx, &models.User{Id: "ada", Name: "Ada"})
+	repos.Users.Create(ctx, &models.User{Id: "cy", Name: "Cy"})
+	repos.Songs.Add(
+		&models.Song{Id: "shouts", Lyrics: [][]string{{"Hey"}, {"Oh"}}},
+		&models.Song{Id: "song", Lyrics: [][]string{{"one", "two"}, {"three"}}},
+	)
+	svc := NewChallengeService(repos.Challenges, repos.Users, repos.Songs, slog.New(slog.NewTextHandler(io.Discard, nil))).
+		WithClock(func() time.Time { return now }).
+		WithSeedSource(func() int64 { return 42 })
+
+	dto := contracts.CreateChallengeDto{ChallengerId: "ada", OpponentId: "cy", SongId: "shouts"}
+	if _, err := svc.CreateChallenge(ctx, dto); !errors.Is(err, ErrValidation) {
+		t.Fatalf("challenge on one-word lines = %v, want a validation error", err)
+	}
+
+	dto.SongId = "song"
+	created, err := svc.CreateChallenge(ctx, dto)
+	if err != nil {
+		t.Fatalf("CreateChallenge: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if _, err := svc.Accept(ctx, created.Id, contracts.ChallengeActionDto{UserId: "cy"}); err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	c := repos.Challenges.Challenges[0]
+	if c.Seed != 42 || !c.CreatedAt.Equal(now.Add(-time.Hour)) || !c.AcceptedAt.Equal(now) {
+		t.Errorf("challenge seed %d, created %s, accepted %s; want 42 and the injected clock", c.Seed, c.CreatedAt, c.AcceptedAt)
+	}
+}
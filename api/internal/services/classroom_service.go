@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+// ErrNotGroupTeacher is returned when someone other than a group's teacher
+// adds students, manages assignments or reads the dashboard.
+var ErrNotGroupTeacher = newError(ErrForbidden, "only the group's teacher may do this")
+
+type ClassroomService struct {
+	groupRepo      repositories.GroupRepoIface
+	assignmentRepo repositories.AssignmentRepoIface
+	userRepo       repositories.UserRepoIface
+	songRepo       repositories.SongRepoIface
+	clock          Clock
+	logger         *slog.Logger
+}
+
+func NewClassroomService(
+	groupRepo repositories.GroupRepoIface,
+	assignmentRepo repositories.AssignmentRepoIface,
+	userRepo repositories.UserRepoIface,
+	songRepo repositories.SongRepoIface,
+	logger *slog.Logger,
+) *ClassroomService {
+	return &ClassroomService{
+		groupRepo:      groupRepo,
+		assignmentRepo: assignmentRepo,
+		userRepo:       userRepo,
+		songRepo:       songRepo,
+		clock:          time.Now,
+		logger:         logger,
+	}
+}
+
+// WithClock replaces the clock stamping groups and assignments and judging
+// which are overdue.
+func (svc *ClassroomService) WithClock(clock Clock) *ClassroomService {
+	svc.clock = clock
+	return svc
+}
+
+func (svc *ClassroomService) now() time.Time {
+	return svc.clock().UTC()
+}
+
+// CreateGroup creates a group and makes its teacher a member.
+func (svc *ClassroomService) CreateGroup(
+	ctx context.Context,
+	dto contracts.CreateGroupDto,
+) (*contracts.GroupResponse, error) {
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		return nil, invalid("name", "is required")
+	}
+	if _, err := svc.userRepo.FindOne(ctx, dto.TeacherId); err != nil {
+		return nil, missing(err, "user with id=%s was not found", dto.TeacherId)
+	}
+
+	now := svc.now()
+	group, err := svc.groupRepo.Create(ctx, &models.Group{
+		Name:      name,
+		TeacherId: dto.TeacherId,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	membership := models.Membership{GroupId: group.Id, Role: models.GroupRoleTeacher, JoinedAt: now}
+	if err := svc.userRepo.AddMembership(ctx, dto.TeacherId, membership); err != nil {
+		return nil, err
+	}
+	return toGroupResponse(group, nil), nil
+}
+
+// GetGroup returns a group. The teacher id is what the teacher-only calls
+// check, so it is left out unless showTeacher is set.
+func (svc *ClassroomService) GetGroup(
+	ctx context.Context,
+	groupId string,
+	showTeacher bool,
+) (*contracts.GroupResponse, error) {
+	group, err := svc.groupRepo.FindById(ctx, groupId)
+	if err != nil {
+		return nil, missing(err, "group with id=%s was not found", groupId)
+	}
+	students, err := svc.userRepo.FindByGroup(ctx, groupId, models.GroupRoleStudent)
+	if err != nil {
+		return nil, err
+	}
+	resp := toGroupResponse(group, students)
+	if !showTeacher {
+		resp.TeacherId = ""
+	}
+	return resp, nil
+}
+
+// AddStudent puts a user in a group as a student.
+func (svc *ClassroomService) AddStudent(
+	ctx context.Context,
+	groupId string,
+	dto contracts.AddGroupMemberDto,
+) (*contracts.GroupResponse, error) {
+	if _, err := svc.teacherGroup(ctx, groupId, dto.TeacherId); err != nil {
+		return nil, err
+	}
+	if _, err := svc.userRepo.FindOne(ctx, dto.UserId); err != nil {
+		return nil, missing(err, "user with id=%s was not found", dto.UserId)
+	}
+	membership := models.Membership{GroupId: groupId, Role: models.GroupRoleStudent, JoinedAt: svc.now()}
+	if err := svc.userRepo.AddMembership(ctx, dto.UserId, membership); err != nil {
+		return nil, duplicate(err, "user %s is already in group %s", dto.UserId, groupId)
+	}
+	return svc.GetGroup(ctx, groupId, true)
+}
+
+func (svc *ClassroomService) CreateAssignment(
+	ctx context.Context,
+	groupId string,
+	dto contracts.CreateAssignmentDto,
+) (*contracts.AssignmentResponse, error) {
+	if _, err := svc.teacherGroup(ctx, groupId, dto.TeacherId); err != nil {
+		return nil, err
+	}
+	if _, err := svc.songRepo.FindById(ctx, dto.SongId); err != nil {
+		return nil, missing(err, "song with id=%s was not found", dto.SongId)
+	}
+	if dto.DueAt.IsZero() {
+		return nil, invalid("dueAt", "is required")
+	}
+	minAccuracy := dto.MinAccuracy
+	if minAccuracy == 0 {
+		minAccuracy = models.DefaultUnlockAccuracy
+	}
+	if minAccuracy < 0 || minAccuracy > 100 {
+		return nil, invalid("minAccuracy", "must be between 0 and 100")
+	}
+
+	assignment, err := svc.assignmentRepo.Create(ctx, &models.Assignment{
+		GroupId:     groupId,
+		SongId:      dto.SongId,
+		MinAccuracy: minAccuracy,
+		DueAt:       dto.DueAt.UTC(),
+		CreatedAt:   svc.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toAssignmentResponse(assignment), nil
+}
+
+func (svc *ClassroomService) GetAssignments(
+	ctx context.Context,
+	groupId string,
+	teacherId string,
+) ([]contracts.AssignmentResponse, error) {
+	if _, err := svc.teacherGroup(ctx, groupId, teacherId); err != nil {
+		return nil, err
+	}
+	assignments, err := svc.assignmentRepo.FindByGroup(ctx, groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]contracts.AssignmentResponse, 0, len(assignments))
+	for _, a := range assignments {
+		resp = append(resp, *toAssignmentResponse(a))
+	}
+	return resp, nil
+}
+
+// GetDashboard reports every student's completion and accuracy on every
+// assignment of a group. Results are maintained as lessons complete, so the
+// dashboard never scans lessons.
+func (svc *ClassroomService) GetDashboard(
+	ctx context.Context,
+	groupId string,
+	teacherId string,
+) (*contracts.GroupDashboardResponse, error) {
+	group, err := svc.teacherGroup(ctx, groupId, teacherId)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := svc.assignmentRepo.FindByGroup(ctx, groupId)
+	if err != nil {
+		return nil, err
+	}
+	students, err := svc.userRepo.FindByGroup(ctx, groupId, models.GroupRoleStudent)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(assignments))
+	for _, a := range assignments {
+		ids = append(ids, a.Id)
+	}
+	results := make(map[string]*models.AssignmentResult)
+	if len(ids) > 0 {
+		rows, err := svc.assignmentRepo.FindResults(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			results[row.Id] = row
+		}
+	}
+
+	now := svc.now()
+	resp := &contracts.GroupDashboardResponse{
+		GroupId:     group.Id,
+		Name:        group.Name,
+		Assignments: make([]contracts.AssignmentOverview, 0, len(assignments)),
+		Students:    make([]contracts.StudentAssignments, 0, len(students)),
+	}
+	overviews := make([]struct {
+		accuracySum float64
+		tried       int
+	}, len(assignments))
+	for _, a := range assignments {
+		resp.Assignments = append(resp.Assignments, contracts.AssignmentOverview{AssignmentResponse: *toAssignmentResponse(a)})
+	}
+
+	for _, student := range students {
+		row := contracts.StudentAssignments{
+			UserId:      student.Id,
+			Name:        student.Name,
+			Assignments: make([]contracts.StudentAssignment, 0, len(assignments)),
+		}
+		accuracySum, tried := 0.0, 0
+		for i, a := range assignments {
+			progress := contracts.StudentAssignment{AssignmentId: a.Id}
+			if res, ok := results[models.AssignmentResultId(a.Id, student.Id)]; ok {
+				progress.Attempts = res.Attempts
+				progress.BestAccuracy = res.BestAccuracy
+				accuracySum += res.BestAccuracy
+				tried++
+				overviews[i].accuracySum += res.BestAccuracy
+				overviews[i].tried++
+				if !res.PassedAt.IsZero() {
+					progress.Completed = true
+					progress.CompletedAt = timePtr(res.PassedAt)
+					progress.Late = res.PassedAt.After(a.DueAt)
+				}
+			}
+			progress.Overdue = !progress.Completed && now.After(a.DueAt)
+			if progress.Completed {
+				row.Completed++
+				resp.Assignments[i].Completed++
+			}
+			row.Assignments = append(row.Assignments, progress)
+		}
+		if tried > 0 {
+			row.AverageAccuracy = accuracySum / float64(tried)
+		}
+		resp.Students = append(resp.Students, row)
+	}
+	for i, o := range overviews {
+		if o.tried > 0 {
+			resp.Assignments[i].AverageAccuracy = o.accuracySum / float64(o.tried)
+		}
+	}
+	return resp, nil
+}
+
+// teacherGroup loads a group and checks that teacherId teaches it.
+func (svc *ClassroomService) teacherGroup(
+	ctx context.Context,
+	groupId string,
+	teacherId string,
+) (*models.Group, error) {
+	group, err := svc.groupRepo.FindById(ctx, groupId)
+	if err != nil {
+		return nil, missing(err, "group with id=%s was not found", groupId)
+	}
+	if teacherId == "" || teacherId != group.TeacherId {
+		return nil, ErrNotGroupTeacher
+	}
+	return group, nil
+}
+
+func toGroupResponse(group *models.Group, students []*models.User) *contracts.GroupResponse {
+	resp := &contracts.GroupResponse{
+		Id:         group.Id,
+		Name:       group.Name,
+		TeacherId:  group.TeacherId,
+		StudentIds: make([]string, 0, len(students)),
+		CreatedAt:  group.CreatedAt,
+	}
+	for _, s := range students {
+		resp.StudentIds = append(resp.StudentIds, s.Id)
+	}
+	return resp
+}
+
+func toAssignmentResponse(a *models.Assignment) *contracts.AssignmentResponse {
+	return &contracts.AssignmentResponse{
+		Id:          a.Id,
+		GroupId:     a.GroupId,
+		SongId:      a.SongId,
+		MinAccuracy: a.MinAccuracy,
+		DueAt:       a.DueAt,
+		CreatedAt:   a.CreatedAt,
+	}
+}
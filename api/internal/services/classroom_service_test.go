@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories/repotest"
+)
+
+// TestDashboardFollowsClock checks that the dashboard judges late and
+// overdue assignments by the service's clock.
+func TestDashboardFollowsClock(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repos := repotest.New()
+	repos.Users.Create(ctx, &models.User{Id: "teacher", Name: "Ada"})
+	repos.Users.Create(ctx, &models.User{Id: "student", Name: "Cy"})
+	repos.Songs.Add(&models.Song{Id: "song", Title: "T", Lyrics: [][]string{{"one", "two"}}})
+	svc := NewClassroomService(
+		repos.Groups, repos.Assignments, repos.Users, repos.Songs, slog.New(slog.NewTextHandler(io.Discard, nil)),
+	).WithClock(func() time.Time { return now })
+
+	group, err := svc.CreateGroup(ctx, contracts.CreateGroupDto{Name: "5B", TeacherId: "teacher"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !group.CreatedAt.Equal(now) {
+		t.Errorf("group created at %s, want the clock's %s", group.CreatedAt, now)
+	}
+	if _, err := svc.AddStudent(ctx, group.Id, contracts.AddGroupMemberDto{TeacherId: "teacher", UserId: "student"}); err != nil {
+		t.Fatal(err)
+	}
+	due := now.Add(24 * time.Hour)
+	a, err := svc.CreateAssignment(ctx, group.Id, contracts.CreateAssignmentDto{TeacherId: "teacher", SongId: "song", DueAt: due})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overdue := func() bool {
+		t.Helper()
+		d, err := svc.GetDashboard(ctx, group.Id, "teacher")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.Students[0].Assignments[0].Overdue
+	}
+	if overdue() {
+		t.Error("overdue before the due date")
+	}
+	now = due.Add(time.Minute)
+	if !overdue() {
+		t.Error("not overdue after the due date")
+	}
+
+	repos.Assignments.RecordResult(ctx, a.Id, "student", 90, true, now)
+	d, err := svc.GetDashboard(ctx, group.Id, "teacher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Students[0].Assignments[0]; !got.Completed || !got.Late || got.Overdue {
+		t.Errorf("assignment passed after the due date = %+v, want completed and late", got)
+	}
+}
+
+// TestAssignmentLessonsCount checks that only students of the group practice
+// an assignment, on its song, and that their completed lessons reach the
+// dashboard.
+func TestAssignmentLessonsCount(t *testing.T) {
+	ctx := context.Background()
+	lessons, repos := newTestLessonService(testSong("a"), testSong("b"))
+	repos.Users.Create(ctx, &models.User{Id: "teacher", Name: "Ada"})
+	classroom := NewClassroomService(repos.Groups, repos.Assignments, repos.Users, repos.Songs, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	group, err := classroom.CreateGroup(ctx, contracts.CreateGroupDto{Name: "5B", TeacherId: "teacher"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := classroom.CreateAssignment(ctx, group.Id, contracts.CreateAssignmentDto{TeacherId: "teacher", SongId: "b", DueAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := lessons.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, AssignmentId: a.Id}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("a user outside the group practicing the assignment = %v, want ErrForbidden", err)
+	}
+	if _, err := classroom.AddStudent(ctx, group.Id, contracts.AddGroupMemberDto{TeacherId: "teacher", UserId: testUserId}); err != nil {
+		t.Fatal(err)
+	}
+
+	lesson, err := lessons.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, AssignmentId: a.Id})
+	if err != nil {
+		t.Fatalf("assignment lesson: %v", err)
+	}
+	if got := repos.Lessons.Lessons[0]; got.SongId != "b" || got.AssignmentId != a.Id {
+		t.Fatalf("lesson on %s for %q, want song b for %s", got.SongId, got.AssignmentId, a.Id)
+	}
+	answerAll(t, lessons, lesson)
+
+	d, err := classroom.GetDashboard(ctx, group.Id, "teacher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Students[0].Assignments[0]; !got.Completed || got.BestAccuracy != 100 || got.Attempts != 1 {
+		t.Errorf("student progress = %+v, want one completed attempt on 100", got)
+	}
+	if d.Assignments[0].Completed != 1 {
+		t.Errorf("assignment completed by %d students, want 1", d.Assignments[0].Completed)
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type CollectionService struct {
+	collectionRepo repositories.CollectionRepoIface
+	songRepo       repositories.SongRepoIface
+	logger         *slog.Logger
+}
+
+func NewCollectionService(
+	collectionRepo repositories.CollectionRepoIface,
+	songRepo repositories.SongRepoIface,
+	logger *slog.Logger,
+) *CollectionService {
+	return &CollectionService{
+		collectionRepo: collectionRepo,
+		songRepo:       songRepo,
+		logger:         logger,
+	}
+}
+
+func (svc *CollectionService) CreateCollection(
+	ctx context.Context,
+	dto contracts.CreateCollectionDto,
+) (*contracts.CollectionResponse, error) {
+	collection, err := svc.buildCollection(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+	collection, err = svc.collectionRepo.Create(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return toCollectionResponse(collection), nil
+}
+
+func (svc *CollectionService) UpdateCollection(
+	ctx context.Context,
+	collectionId string,
+	dto contracts.CreateCollectionDto,
+) (*contracts.CollectionResponse, error) {
+	collection, err := svc.buildCollection(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+	collection.Id = collectionId
+	if err := svc.collectionRepo.Replace(ctx, collection); err != nil {
+		return nil, err
+	}
+	return toCollectionResponse(collection), nil
+}
+
+func (svc *CollectionService) GetCollection(
+	ctx context.Context,
+	collectionId string,
+) (*contracts.CollectionResponse, error) {
+	collection, err := svc.collectionRepo.FindById(ctx, collectionId)
+	if err != nil {
+		return nil, err
+	}
+	return toCollectionResponse(collection), nil
+}
+
+func (svc *CollectionService) GetAllCollections(
+	ctx context.Context,
+) ([]contracts.CollectionResponse, error) {
+	collections, err := svc.collectionRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]contracts.CollectionResponse, 0, len(collections))
+	for _, c := range collections {
+		resp = append(resp, *toCollectionResponse(c))
+	}
+	return resp, nil
+}
+
+// buildCollection validates dto and checks that every referenced song exists.
+func (svc *CollectionService) buildCollection(
+	ctx context.Context,
+	dto contracts.CreateCollectionDto,
+) (*models.Collection, error) {
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		return nil, invalid("name", "is required")
+	}
+
+	songIds := make([]string, 0, len(dto.SongIds))
+	seen := make(map[string]struct{}, len(dto.SongIds))
+	for _, id := range dto.SongIds {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		songIds = append(songIds, id)
+	}
+	found, err := svc.songRepo.FindIds(ctx, repositories.SongFilter{Ids: songIds})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) != len(songIds) {
+		return nil, invalid("songIds", fmt.Sprintf("reference %d unknown songs", len(songIds)-len(found)))
+	}
+
+	return &models.Collection{
+		Name:        name,
+		Description: strings.TrimSpace(dto.Description),
+		SongIds:     songIds,
+	}, nil
+}
+
+func toCollectionResponse(c *models.Collection) *contracts.CollectionResponse {
+	return &contracts.CollectionResponse{
+		Id:          c.Id,
+		Name:        c.Name,
+		Description: c.Description,
+		SongIds:     c.SongIds,
+	}
+}
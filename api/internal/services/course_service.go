@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type CourseService struct {
+	courseRepo     repositories.CourseRepoIface
+	enrollmentRepo repositories.EnrollmentRepoIface
+	songRepo       repositories.SongRepoIface
+	userRepo       repositories.UserRepoIface
+	logger         *slog.Logger
+}
+
+func NewCourseService(
+	courseRepo repositories.CourseRepoIface,
+	enrollmentRepo repositories.EnrollmentRepoIface,
+	songRepo repositories.SongRepoIface,
+	userRepo repositories.UserRepoIface,
+	logger *slog.Logger,
+) *CourseService {
+	return &CourseService{
+		courseRepo:     courseRepo,
+		enrollmentRepo: enrollmentRepo,
+		songRepo:       songRepo,
+		userRepo:       userRepo,
+		logger:         logger,
+	}
+}
+
+func (svc *CourseService) CreateCourse(
+	ctx context.Context,
+	dto contracts.CreateCourseDto,
+) (*contracts.CourseResponse, error) {
+	course, err := svc.buildCourse(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+	course, err = svc.courseRepo.Create(ctx, course)
+	if err != nil {
+		return nil, err
+	}
+	return toCourseResponse(course), nil
+}
+
+// UpdateCourse replaces a course. Enrollments keep their progress by
+// position, so reordering songs moves learners along with the order.
+func (svc *CourseService) UpdateCourse(
+	ctx context.Context,
+	courseId string,
+	dto contracts.CreateCourseDto,
+) (*contracts.CourseResponse, error) {
+	course, err := svc.buildCourse(ctx, dto)
+	if err != nil {
+		return nil, err
+	}
+	course.Id = courseId
+	if err := svc.courseRepo.Replace(ctx, course); err != nil {
+		return nil, err
+	}
+	return toCourseResponse(course), nil
+}
+
+func (svc *CourseService) GetCourse(
+	ctx context.Context,
+	courseId string,
+) (*contracts.CourseResponse, error) {
+	course, err := svc.courseRepo.FindById(ctx, courseId)
+	if err != nil {
+		return nil, err
+	}
+	return toCourseResponse(course), nil
+}
+
+func (svc *CourseService) GetAllCourses(
+	ctx context.Context,
+) ([]contracts.CourseResponse, error) {
+	courses, err := svc.courseRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]contracts.CourseResponse, 0, len(courses))
+	for _, c := range courses {
+		resp = append(resp, *toCourseResponse(c))
+	}
+	return resp, nil
+}
+
+// Enroll starts a user on the first song of a course.
+func (svc *CourseService) Enroll(
+	ctx context.Context,
+	courseId string,
+	dto contracts.EnrollDto,
+) (*contracts.CourseProgressResponse, error) {
+	if strings.TrimSpace(dto.UserId) == "" {
+		return nil, invalid("userId", "is required")
+	}
+	if _, err := svc.userRepo.FindOne(ctx, dto.UserId); err != nil {
+		return nil, missing(err, "user with id=%s was not found", dto.UserId)
+	}
+	course, err := svc.courseRepo.FindById(ctx, courseId)
+	if err != nil {
+		return nil, missing(err, "course with id=%s was not found", courseId)
+	}
+
+	now := time.Now().UTC()
+	enrollment := &models.Enrollment{
+		UserId:       dto.UserId,
+		CourseId:     courseId,
+		BestAccuracy: map[string]float64{},
+		EnrolledAt:   now,
+		UpdatedAt:    now,
+	}
+	if err := svc.enrollmentRepo.Create(ctx, enrollment); err != nil {
+		return nil, duplicate(err, "user %s is already enrolled in course %s", dto.UserId, courseId)
+	}
+	return toCourseProgress(course, enrollment), nil
+}
+
+func (svc *CourseService) GetProgress(
+	ctx context.Context,
+	courseId string,
+	userId string,
+) (*contracts.CourseProgressResponse, error) {
+	course, err := svc.courseRepo.FindById(ctx, courseId)
+	if err != nil {
+		return nil, missing(err, "course with id=%s was not found", courseId)
+	}
+	enrollment, err := svc.enrollmentRepo.Find(ctx, userId, courseId)
+	if err != nil {
+		return nil, missing(err, "user %s is not enrolled in course %s", userId, courseId)
+	}
+	return toCourseProgress(course, enrollment), nil
+}
+
+func (svc *CourseService) buildCourse(
+	ctx context.Context,
+	dto contracts.CreateCourseDto,
+) (*models.Course, error) {
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		return nil, invalid("name", "is required")
+	}
+	if len(dto.SongIds) == 0 {
+		return nil, invalid("songIds", "must not be empty")
+	}
+	unlock := dto.UnlockAccuracy
+	if unlock == 0 {
+		unlock = models.DefaultUnlockAccuracy
+	}
+	if unlock < 0 || unlock > 100 {
+		return nil, invalid("unlockAccuracy", "must be between 0 and 100")
+	}
+
+	// order matters and a song may not appear twice
+	seen := make(map[string]struct{}, len(dto.SongIds))
+	for _, id := range dto.SongIds {
+		if _, ok := seen[id]; ok {
+			return nil, invalid("songIds", fmt.Sprintf("contain %s twice", id))
+		}
+		seen[id] = struct{}{}
+	}
+	found, err := svc.songRepo.FindIds(ctx, repositories.SongFilter{Ids: dto.SongIds})
+	if err != nil {
+		return nil, err
+	}
+	if len(found) != len(dto.SongIds) {
+		return nil, invalid("songIds", fmt.Sprintf("reference %d unknown songs", len(dto.SongIds)-len(found)))
+	}
+
+	return &models.Course{
+		Name:           name,
+		Description:    strings.TrimSpace(dto.Description),
+		SongIds:        dto.SongIds,
+		UnlockAccuracy: unlock,
+	}, nil
+}
+
+func toCourseResponse(c *models.Course) *contracts.CourseResponse {
+	return &contracts.CourseResponse{
+		Id:             c.Id,
+		Name:           c.Name,
+		Description:    c.Description,
+		SongIds:        c.SongIds,
+		UnlockAccuracy: c.UnlockAccuracy,
+	}
+}
+
+func toCourseProgress(c *models.Course, e *models.Enrollment) *contracts.CourseProgressResponse {
+	e.Fit(c)
+
+	resp := &contracts.CourseProgressResponse{
+		CourseId:  c.Id,
+		UserId:    e.UserId,
+		Position:  e.Position(c),
+		Completed: e.Completed,
+		Songs:     make([]contracts.CourseSongProgress, 0, len(c.SongIds)),
+	}
+	if resp.Position < len(c.SongIds) {
+		resp.CurrentSongId = c.SongIds[resp.Position]
+	}
+	for i, songId := range c.SongIds {
+		resp.Songs = append(resp.Songs, contracts.CourseSongProgress{
+			SongId:       songId,
+			Unlocked:     i <= resp.Position,
+			Passed:       e.HasPassed(songId),
+			BestAccuracy: e.BestAccuracy[songId],
+		})
+	}
+	return resp
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+// Error kinds returned by services. The HTTP layer maps each kind to one
+// status code; repository errors carry the same kinds.
+var (
+	ErrNotFound    = repositories.ErrNotFound
+	ErrConflict    = repositories.ErrConflict
+	ErrValidation  = repositories.ErrInvalid
+	ErrUnavailable = repositories.ErrUnavailable
+	ErrForbidden   = errors.New("forbidden")
+	// ErrLessonOpen is returned when a user starts a lesson while another
+	// is unfinished; the client chooses to resume or restart.
+	ErrLessonOpen = errors.New("lesson open")
+)
+
+// Error is a domain error: a kind, a message safe to show to clients and,
+// for validation errors, the offending fields.
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []contracts.FieldError
+	Err     error // underlying cause, if any
+	// OpenLessonId names the unfinished lesson of an ErrLessonOpen error.
+	OpenLessonId string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+func newError(kind error, format string, args ...any) *Error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// invalid reports a single bad field.
+func invalid(field, message string) *Error {
+	return invalidFields(contracts.FieldError{Field: field, Message: message})
+}
+
+// invalidFields reports several bad fields at once.
+func invalidFields(fields ...contracts.FieldError) *Error {
+	msgs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Field == "" {
+			msgs = append(msgs, f.Message)
+		} else {
+			msgs = append(msgs, f.Field+" "+f.Message)
+		}
+	}
+	return &Error{Kind: ErrValidation, Message: strings.Join(msgs, "; "), Fields: fields}
+}
+
+// missing turns a repository not-found error into one naming what was
+// missing. Other errors, such as outages, pass through unchanged.
+func missing(err error, format string, args ...any) error {
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// duplicate turns a repository conflict error into one naming what already
+// exists. Other errors pass through unchanged.
+func duplicate(err error, format string, args ...any) error {
+	if !errors.Is(err, ErrConflict) {
+		return err
+	}
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...), Err: err}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+// DefaultIdempotencyTTL is how long replies are kept for replay.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// DefaultIdempotencyLease is how long a key stays reserved for a request
+// that has not finished, see WithLease.
+const DefaultIdempotencyLease = time.Minute
+
+// ErrKeyReused is the kind of error returned when an Idempotency-Key comes
+// back with a different request than the one it was first used for.
+var ErrKeyReused = errors.New("idempotency key reused")
+
+type IdempotencyService struct {
+	repo   repositories.IdempotencyRepoIface
+	ttl    time.Duration
+	lease  time.Duration
+	clock  Clock
+	logger *slog.Logger
+}
+
+func NewIdempotencyService(
+	repo repositories.IdempotencyRepoIface,
+	logger *slog.Logger,
+) *IdempotencyService {
+	return &IdempotencyService{
+		repo:   repo,
+		ttl:    DefaultIdempotencyTTL,
+		lease:  DefaultIdempotencyLease,
+		clock:  time.Now,
+		logger: logger,
+	}
+}
+
+// WithTTL changes how long replies are kept.
+func (svc *IdempotencyService) WithTTL(ttl time.Duration) *IdempotencyService {
+	svc.ttl = ttl
+	return svc
+}
+
+// WithLease changes how long a key stays reserved while its request runs.
+// A request that crashed never finishes, so once the lease runs out the key
+// can be claimed again. The request timeout makes a natural lease, as no
+// request runs longer.
+func (svc *IdempotencyService) WithLease(lease time.Duration) *IdempotencyService {
+	svc.lease = lease
+	return svc
+}
+
+// WithClock replaces the clock that reservations and replies expire by.
+func (svc *IdempotencyService) WithClock(clock Clock) *IdempotencyService {
+	svc.clock = clock
+	return svc
+}
+
+// Begin claims key for a request identified by fingerprint. When the key is
+// new, or its first request outlived the lease without finishing, it returns
+// a pending record: run the request, then call Finish. When the key's first
+// request already finished it returns that record, whose reply should be
+// sent back as is.
+func (svc *IdempotencyService) Begin(
+	ctx context.Context,
+	caller, key, fingerprint string,
+) (*models.IdempotencyRecord, error) {
+	now := svc.clock().UTC()
+	rec := &models.IdempotencyRecord{
+		Id:          models.IdempotencyId(caller, key),
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(svc.lease),
+	}
+	existing, err := svc.repo.Reserve(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case existing == nil:
+		return rec, nil
+	case existing.Fingerprint != fingerprint:
+		return nil, newError(ErrKeyReused, "Idempotency-Key was already used for a different request")
+	case !existing.Done():
+		return nil, newError(ErrConflict, "a request with this Idempotency-Key is still in progress")
+	}
+	return existing, nil
+}
+
+// Finish stores the reply to a request started with Begin and keeps it for
+// the TTL. Server errors are not stored; the key is released so that a retry
+// runs the request again. Failures are logged: the request itself already
+// succeeded or failed.
+func (svc *IdempotencyService) Finish(ctx context.Context, rec *models.IdempotencyRecord, status int, body []byte) {
+	var err error
+	if status >= http.StatusInternalServerError {
+		err = svc.repo.Release(ctx, rec.Id)
+	} else {
+		err = svc.repo.Complete(ctx, rec.Id, status, body, svc.clock().UTC().Add(svc.ttl))
+	}
+	if err != nil {
+		svc.logger.WarnContext(ctx, "store idempotent reply failed", "status", status, "err", err)
+	}
+}
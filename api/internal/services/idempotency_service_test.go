@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.tomerab1/todo-api/internal/repositories/repotest"
+)
+
+// TestIdempotencyLeaseExpires checks that a key whose request never finished
+// is reserved only for the lease, while a finished reply is kept for the TTL.
+func TestIdempotencyLeaseExpires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewIdempotencyService(&repotest.IdempotencyRepo{}, slog.New(slog.NewTextHandler(io.Discard, nil))).
+		WithTTL(24 * time.Hour).
+		WithLease(5 * time.Second).
+		WithClock(func() time.Time { return now })
+
+	if _, err := svc.Begin(ctx, "10.0.0.1", "k1", "body"); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	// the first request crashed without calling Finish
+	now = now.Add(4 * time.Second)
+	if _, err := svc.Begin(ctx, "10.0.0.1", "k1", "body"); !errors.Is(err, ErrConflict) {
+		t.Fatalf("retry within the lease = %v, want a conflict", err)
+	}
+	now = now.Add(2 * time.Second)
+	rec, err := svc.Begin(ctx, "10.0.0.1", "k1", "body")
+	if err != nil || rec.Done() {
+		t.Fatalf("retry after the lease = %+v, %v; want a fresh reservation", rec, err)
+	}
+
+	svc.Finish(ctx, rec, http.StatusCreated, []byte(`{"data":{}}`))
+	now = now.Add(time.Hour)
+	replay, err := svc.Begin(ctx, "10.0.0.1", "k1", "body")
+	if err != nil || !replay.Done() || replay.Status != http.StatusCreated {
+		t.Errorf("retry of a finished request = %+v, %v; want its stored reply", replay, err)
+	}
+}
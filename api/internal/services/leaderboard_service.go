@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type LeaderboardService struct {
+	userRepo  repositories.UserRepoIface
+	songRepo  repositories.SongRepoIface
+	boardRepo repositories.LeaderboardRepoIface
+	logger    *slog.Logger
+}
+
+func NewLeaderboardService(
+	userRepo repositories.UserRepoIface,
+	songRepo repositories.SongRepoIface,
+	boardRepo repositories.LeaderboardRepoIface,
+	logger *slog.Logger,
+) *LeaderboardService {
+	return &LeaderboardService{
+		userRepo:  userRepo,
+		songRepo:  songRepo,
+		boardRepo: boardRepo,
+		logger:    logger,
+	}
+}
+
+var weekScopePattern = regexp.MustCompile(`^\d{4}-W\d{2}$`)
+
+// GetLeaderboard returns one page of a board and, when query.UserId is set,
+// the caller's own rank wherever it falls.
+func (svc *LeaderboardService) GetLeaderboard(
+	ctx context.Context,
+	kind string,
+	query contracts.LeaderboardQuery,
+) (*contracts.LeaderboardResponse, string, error) {
+	scope, err := svc.scope(ctx, kind, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	entries, firstRank, next, err := svc.boardRepo.Page(ctx, repositories.LeaderboardFilter{
+		Kind:   kind,
+		Scope:  scope,
+		Cursor: query.Cursor,
+		Limit:  query.Limit,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	ids := make([]string, 0, len(entries)+1)
+	for _, e := range entries {
+		ids = append(ids, e.UserId)
+	}
+
+	var me *models.LeaderboardEntry
+	myRank := 0
+	if query.UserId != "" {
+		if _, err := svc.userRepo.FindOne(ctx, query.UserId); err != nil {
+			return nil, "", missing(err, "user with id=%s was not found", query.UserId)
+		}
+		if myRank, me, err = svc.boardRepo.Rank(ctx, kind, scope, query.UserId); err != nil {
+			return nil, "", err
+		}
+		if me != nil {
+			ids = append(ids, me.UserId)
+		}
+	}
+
+	names := make(map[string]string, len(ids))
+	if len(ids) > 0 {
+		users, err := svc.userRepo.FindByIds(ctx, ids)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, u := range users {
+			names[u.Id] = u.Name
+		}
+	}
+
+	resp := &contracts.LeaderboardResponse{
+		Kind:    kind,
+		Scope:   scope,
+		Entries: make([]contracts.LeaderboardEntryResponse, 0, len(entries)),
+	}
+	for i, e := range entries {
+		resp.Entries = append(resp.Entries, contracts.LeaderboardEntryResponse{
+			Rank:   firstRank + i,
+			UserId: e.UserId,
+			Name:   names[e.UserId],
+			Score:  e.Score,
+		})
+	}
+	if me != nil {
+		resp.Me = &contracts.LeaderboardEntryResponse{
+			Rank:   myRank,
+			UserId: me.UserId,
+			Name:   names[me.UserId],
+			Score:  me.Score,
+		}
+	}
+	return resp, next, nil
+}
+
+// scope resolves which board of a kind the query asks for.
+func (svc *LeaderboardService) scope(
+	ctx context.Context,
+	kind string,
+	query contracts.LeaderboardQuery,
+) (string, error) {
+	switch kind {
+	case models.LeaderboardWeeklyXP:
+		if query.Week == "" {
+			return models.WeekScope(time.Now()), nil
+		}
+		if !weekScopePattern.MatchString(query.Week) {
+			return "", invalid("week", "must look like 2025-W03")
+		}
+		return query.Week, nil
+	case models.LeaderboardAllTimeXP:
+		return "", nil
+	case models.LeaderboardSong:
+		if query.SongId == "" {
+			return "", invalid("songId", "is required for song leaderboards")
+		}
+		if _, err := svc.songRepo.FindById(ctx, query.SongId); err != nil {
+			return "", missing(err, "song with id=%s was not found", query.SongId)
+		}
+		return query.SongId, nil
+	default:
+		return "", newError(ErrNotFound, "unknown leaderboard %q", kind)
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+)
+
+// TestLeaderboardsFollowLessons checks that lesson XP feeds the weekly and
+// all-time boards, that the song board keeps each user's best accuracy, and
+// that the caller's own rank is reported with names resolved.
+func TestLeaderboardsFollowLessons(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"))
+	repos.Users.Create(ctx, &models.User{Id: "rival", Name: "Rival"})
+	boards := NewLeaderboardService(repos.Users, repos.Songs, repos.Boards, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	play := func(userId string, wrong int) {
+		t.Helper()
+		lesson, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: userId})
+		if err != nil {
+			t.Fatalf("lesson for %s: %v", userId, err)
+		}
+		for i, item := range lesson.Items {
+			if item.Type != models.LessonTypeFillBlanks {
+				continue
+			}
+			input := item.CorrectWord
+			if wrong > 0 {
+				input, wrong = "nope", wrong-1
+			}
+			if _, err := svc.SubmitAnswer(ctx, lesson.LessonId, i, item.Type, input); err != nil {
+				t.Fatalf("answer %d for %s: %v", i, userId, err)
+			}
+		}
+	}
+	play("rival", 1)
+	play(testUserId, 0)
+	play("rival", 2)
+
+	for _, kind := range []string{models.LeaderboardWeeklyXP, models.LeaderboardAllTimeXP, models.LeaderboardSong} {
+		board, _, err := boards.GetLeaderboard(ctx, kind, contracts.LeaderboardQuery{Week: models.WeekScope(svc.now()), SongId: "a", UserId: "rival"})
+		if err != nil {
+			t.Fatalf("%s board: %v", kind, err)
+		}
+		if len(board.Entries) != 2 {
+			t.Fatalf("%s board = %+v, want 2 entries", kind, board.Entries)
+		}
+		top, second := board.Entries[0], board.Entries[1]
+		if kind == models.LeaderboardSong {
+			// the rival's best lesson missed one blank, the other none
+			if top.UserId != testUserId || top.Score != 100 || second.Score >= 100 {
+				t.Errorf("song board = %+v, want %s first on 100", board.Entries, testUserId)
+			}
+		} else if top.Rank != 1 || second.Rank != 2 || top.Score < second.Score {
+			t.Errorf("%s board = %+v, want ranked by score", kind, board.Entries)
+		}
+		if board.Me == nil || board.Me.UserId != "rival" || board.Me.Name != "Rival" {
+			t.Errorf("%s board me = %+v, want the rival with their name", kind, board.Me)
+		}
+	}
+
+	if _, _, err := boards.GetLeaderboard(ctx, models.LeaderboardWeeklyXP, contracts.LeaderboardQuery{Week: "last week"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("malformed week = %v, want a validation error", err)
+	}
+	if _, _, err := boards.GetLeaderboard(ctx, models.LeaderboardSong, contracts.LeaderboardQuery{}); !errors.Is(err, ErrValidation) {
+		t.Errorf("song board without songId = %v, want a validation error", err)
+	}
+}
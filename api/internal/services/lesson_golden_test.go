@@ -0,0 +1,180 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/utils"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+var goldenCases = []struct {
+	song string // fixture in testdata/songs, without .txt
+	seed int64
+}{
+	{"short", 1},
+	{"short", 42},
+	{"one_word_lines", 1},
+	{"one_word_lines", 42},
+	{"repeated_lines", 1},
+	{"repeated_lines", 42},
+	{"huge", 1},
+	{"huge", 42},
+}
+
+func loadSong(t *testing.T, name string) *models.Song {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "songs", name+".txt"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	lyrics := utils.LyricsToSlices(string(raw))
+	return &models.Song{
+		Id:                 name,
+		Title:              name,
+		Lyrics:             lyrics,
+		ComputedDifficulty: utils.ComputeDifficulty(lyrics),
+	}
+}
+
+func createSeededLesson(t *testing.T, svc *LessonService, seed int64) *contracts.CreateLessonResponse {
+	t.Helper()
+	resp, err := svc.CreateLesson(context.Background(), contracts.CreateLessonDto{UserId: testUserId, Seed: &seed})
+	if err != nil {
+		t.Fatalf("CreateLesson: %v", err)
+	}
+	return resp
+}
+
+func TestCreateLessonGolden(t *testing.T) {
+	for _, tc := range goldenCases {
+		name := fmt.Sprintf("%s_seed%d", tc.song, tc.seed)
+		t.Run(name, func(t *testing.T) {
+			song := loadSong(t, tc.song)
+			svc, repos := newTestLessonService(song)
+			resp := createSeededLesson(t, svc, tc.seed)
+
+			checkLessonInvariants(t, resp.Items, repos.Lessons.Lessons[0].Size)
+
+			got, err := json.MarshalIndent(resp, "", "  ")
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			got = append(got, '\n')
+
+			path := filepath.Join("testdata", "golden", name+".json")
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatalf("write golden: %v", err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("lesson differs from %s (run with -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+// TestCreateLessonReproducible checks that a seed alone determines a lesson:
+// creating it twice, or regenerating it from the stored seed and size, gives
+// the same items.
+func TestCreateLessonReproducible(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(fmt.Sprintf("%s_seed%d", tc.song, tc.seed), func(t *testing.T) {
+			song := loadSong(t, tc.song)
+
+			svc1, repos := newTestLessonService(song)
+			first := createSeededLesson(t, svc1, tc.seed)
+			svc2, _ := newTestLessonService(song)
+			second := createSeededLesson(t, svc2, tc.seed)
+
+			a, _ := json.Marshal(first.Items)
+			b, _ := json.Marshal(second.Items)
+			if !bytes.Equal(a, b) {
+				t.Fatalf("same seed, different items:\n%s\n%s", a, b)
+			}
+
+			stored := repos.Lessons.Lessons[0]
+			if stored.Seed == nil || *stored.Seed != tc.seed {
+				t.Fatalf("stored seed = %v, want %d", stored.Seed, tc.seed)
+			}
+			regen, _ := json.Marshal(utils.ToContractItems(GenerateItems(*stored.Seed, song.Lyrics, stored.Size)))
+			if !bytes.Equal(a, regen) {
+				t.Fatalf("regenerated items differ:\n%s\n%s", a, regen)
+			}
+		})
+	}
+}
+
+// TestCreateLessonSongPick checks that the seed also fixes which song is
+// picked when several match.
+func TestCreateLessonSongPick(t *testing.T) {
+	songs := []*models.Song{loadSong(t, "short"), loadSong(t, "repeated_lines"), loadSong(t, "huge")}
+	for seed := int64(0); seed < 10; seed++ {
+		svc1, repo1 := newTestLessonService(songs...)
+		createSeededLesson(t, svc1, seed)
+		svc2, repo2 := newTestLessonService(songs...)
+		createSeededLesson(t, svc2, seed)
+		if a, b := repo1.Lessons.Lessons[0].SongId, repo2.Lessons.Lessons[0].SongId; a != b {
+			t.Fatalf("seed %d picked %s, then %s", seed, a, b)
+		}
+	}
+}
+
+// checkLessonInvariants checks items against the size the lesson was
+// created with; every golden song has enough lines to fill it.
+func checkLessonInvariants(t *testing.T, items []contracts.LessonItem, size int) {
+	t.Helper()
+	if len(items) != size {
+		t.Errorf("got %d items, want %d", len(items), size)
+	}
+
+	types := map[string]int{}
+	signatures := map[string]bool{}
+	for i, it := range items {
+		types[it.Type]++
+
+		sig := utils.ItemSignature(models.LessonItem{
+			Type:         it.Type,
+			LineIndex:    it.LineIndex,
+			RenderedLine: it.RenderedLine,
+			Words:        it.Words,
+		})
+		if signatures[sig] {
+			t.Errorf("item %d: duplicate signature %q", i, sig)
+		}
+		signatures[sig] = true
+
+		if it.Type != models.LessonTypeFillBlanks {
+			continue
+		}
+		distinct := map[string]bool{}
+		for _, w := range it.Words {
+			distinct[strings.ToLower(w)] = true
+		}
+		if len(it.Words) != 4 || len(distinct) != 4 {
+			t.Errorf("item %d: options %q, want 4 distinct", i, it.Words)
+		}
+		if !distinct[strings.ToLower(it.CorrectWord)] {
+			t.Errorf("item %d: options %q miss the correct word %q", i, it.Words, it.CorrectWord)
+		}
+	}
+	if types[models.LessonTypeFillBlanks] == 0 || types[models.LessonTypeArrange] == 0 {
+		t.Errorf("got types %v, want both fillblanks and arrange", types)
+	}
+}
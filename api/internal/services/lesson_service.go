@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"math/rand/v2"
 	"slices"
 	"strings"
@@ -13,63 +14,282 @@ import (
 	"github.tomerab1/todo-api/internal/contracts"
 	"github.tomerab1/todo-api/internal/models"
 	"github.tomerab1/todo-api/internal/repositories"
+	"github.tomerab1/todo-api/internal/telemetry"
 	"github.tomerab1/todo-api/internal/utils"
 )
 
 type LessonService struct {
-	songRepo   repositories.SongRepoIface
-	lessonRepo repositories.LessonRepoIface
-	userRepo   repositories.UserRepoIface
-	logger     *slog.Logger
+	songRepo       repositories.SongRepoIface
+	lessonRepo     repositories.LessonRepoIface
+	userRepo       repositories.UserRepoIface
+	collectionRepo repositories.CollectionRepoIface
+	courseRepo     repositories.CourseRepoIface
+	enrollmentRepo repositories.EnrollmentRepoIface
+	masteryRepo    repositories.WordMasteryRepoIface
+	progressRepo   repositories.ProgressRepoIface
+	boardRepo      repositories.LeaderboardRepoIface
+	assignmentRepo repositories.AssignmentRepoIface
+	challengeRepo  repositories.ChallengeRepoIface
+	metrics        *telemetry.Metrics
+	lessonSize     int
+	clock          Clock
+	seeds          SeedSource
+	logger         *slog.Logger
 }
 
-var ErrDuplicateAnswer = errors.New("duplicate answer")
+var ErrDuplicateAnswer = newError(ErrConflict, "duplicate answer")
+
+// DefaultLessonSize is the number of items a lesson aims for.
+const DefaultLessonSize = 6
+
+// storedLessonSize is the size a lesson or challenge was generated with:
+// records from before sizes were stored all used DefaultLessonSize.
+func storedLessonSize(size int) int {
+	if size > 0 {
+		return size
+	}
+	return DefaultLessonSize
+}
 
 func NewLessonService(
 	userRepo repositories.UserRepoIface,
 	songRepo repositories.SongRepoIface,
 	lessonRepo repositories.LessonRepoIface,
+	collectionRepo repositories.CollectionRepoIface,
+	courseRepo repositories.CourseRepoIface,
+	enrollmentRepo repositories.EnrollmentRepoIface,
+	masteryRepo repositories.WordMasteryRepoIface,
+	progressRepo repositories.ProgressRepoIface,
+	boardRepo repositories.LeaderboardRepoIface,
+	assignmentRepo repositories.AssignmentRepoIface,
+	challengeRepo repositories.ChallengeRepoIface,
 	logger *slog.Logger,
 ) *LessonService {
 	return &LessonService{
-		userRepo:   userRepo,
-		songRepo:   songRepo,
-		lessonRepo: lessonRepo,
-		logger:     logger,
+		userRepo:       userRepo,
+		songRepo:       songRepo,
+		lessonRepo:     lessonRepo,
+		collectionRepo: collectionRepo,
+		courseRepo:     courseRepo,
+		enrollmentRepo: enrollmentRepo,
+		masteryRepo:    masteryRepo,
+		progressRepo:   progressRepo,
+		boardRepo:      boardRepo,
+		assignmentRepo: assignmentRepo,
+		challengeRepo:  challengeRepo,
+		clock:          time.Now,
+		seeds:          rand.Int64,
+		lessonSize:     DefaultLessonSize,
+		logger:         logger,
 	}
 }
 
-// CreateLesson generates a 6-item lesson and persists it.
+// Clock returns the current time.
+type Clock func() time.Time
+
+// SeedSource draws the seed of a lesson that did not ask for one.
+type SeedSource func() int64
+
+// WithClock replaces the clock stamping lessons and answers.
+func (svc *LessonService) WithClock(clock Clock) *LessonService {
+	svc.clock = clock
+	return svc
+}
+
+// WithLessonSize changes the number of items new lessons aim for. Challenge
+// lessons use the size stored on the challenge instead.
+func (svc *LessonService) WithLessonSize(size int) *LessonService {
+	svc.lessonSize = size
+	return svc
+}
+
+// WithMetrics counts lessons and answers in m.
+func (svc *LessonService) WithMetrics(m *telemetry.Metrics) *LessonService {
+	svc.metrics = m
+	return svc
+}
+
+// WithSeedSource replaces the source of lesson seeds.
+func (svc *LessonService) WithSeedSource(seeds SeedSource) *LessonService {
+	svc.seeds = seeds
+	return svc
+}
+
+func (svc *LessonService) now() time.Time {
+	return svc.clock().UTC()
+}
+
+// CreateLesson generates a lesson of the configured size and persists it.
 func (svc *LessonService) CreateLesson(
 	ctx context.Context,
 	dto contracts.CreateLessonDto,
 ) (*contracts.CreateLessonResponse, error) {
 	if strings.TrimSpace(dto.UserId) == "" {
-		return nil, errors.New("userId is required")
+		return nil, invalid("userId", "is required")
 	}
-	if _, err := svc.userRepo.FindOne(ctx, dto.UserId); err != nil {
-		svc.logger.Info("find user failed", "err", err)
-		return nil, fmt.Errorf("user with id=%s was not found", dto.UserId)
+	user, err := svc.userRepo.FindOne(ctx, dto.UserId)
+	if err != nil {
+		svc.logger.InfoContext(ctx, "find user failed", "err", err)
+		return nil, missing(err, "user with id=%s was not found", dto.UserId)
 	}
-
-	// 1) Pick a song (random for now)
-	songs, err := svc.songRepo.FindAll(ctx)
+	assignment, err := svc.assignment(ctx, user, dto.AssignmentId)
 	if err != nil {
 		return nil, err
 	}
-	if len(songs) == 0 {
-		return nil, errors.New("no songs available")
+	challenge, err := svc.challenge(ctx, user, dto.ChallengeId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 0) A player's challenge lesson is resumed until they finish or abandon
+	// it. An unfinished practice lesson is resumed or restarted only when the
+	// client says which; otherwise it is offered back
+	var open *models.Lesson
+	if challenge != nil {
+		if lessonId := challenge.Entry(challenge.RoleOf(user.Id)).LessonId; lessonId != "" {
+			return svc.resumeChallengeLesson(ctx, lessonId)
+		}
+	} else {
+		var resumed *contracts.CreateLessonResponse
+		open, resumed, err = svc.openLesson(ctx, dto.UserId, dto.Resume, dto.Restart)
+		if err != nil || resumed != nil {
+			return resumed, err
+		}
+	}
+
+	// Everything random about a lesson follows from its seed: the song pick
+	// and the items draw from separate streams, so a lesson's items can be
+	// regenerated from its seed and song alone
+	var seed int64
+	switch {
+	case challenge != nil && dto.Seed != nil:
+		return nil, invalid("seed", "cannot be combined with challengeId")
+	case challenge != nil:
+		seed = challenge.Seed
+	case dto.Seed != nil:
+		seed = *dto.Seed
+	default:
+		seed = svc.seeds()
+	}
+	pick := rand.New(rand.NewPCG(uint64(seed), 1))
+
+	// 1) Pick a song: the challenge or assigned song, the current song of an
+	// enrolled course, otherwise a random one among the songs matching the
+	// requested tag, difficulty band and collection
+	var enrollment *models.Enrollment
+	if assignment == nil && challenge == nil {
+		if enrollment, err = svc.courseEnrollment(ctx, dto); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		song       *models.Song
+		courseStep int
+	)
+	if challenge != nil {
+		if song, err = svc.songRepo.FindById(ctx, challenge.SongId); err != nil {
+			return nil, missing(err, "song with id=%s was not found", challenge.SongId)
+		}
+	} else if assignment != nil {
+		if song, err = svc.songRepo.FindById(ctx, assignment.SongId); err != nil {
+			return nil, missing(err, "song with id=%s was not found", assignment.SongId)
+		}
+	} else if enrollment != nil {
+		course, err := svc.courseRepo.FindById(ctx, enrollment.CourseId)
+		if err != nil {
+			return nil, missing(err, "course with id=%s was not found", enrollment.CourseId)
+		}
+		courseStep = enrollment.Position(course)
+		if song, err = svc.songRepo.FindById(ctx, course.SongIds[courseStep]); err != nil {
+			return nil, err
+		}
+	} else {
+		filter, err := svc.songFilter(ctx, dto)
+		if err != nil {
+			return nil, err
+		}
+		songIds, err := svc.songRepo.FindIds(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(songIds) == 0 {
+			return nil, newError(ErrNotFound, "no songs match the request")
+		}
+		if song, err = svc.songRepo.FindById(ctx, songIds[pick.IntN(len(songIds))]); err != nil {
+			return nil, err
+		}
 	}
 
-	r := rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0))
-	song := songs[r.IntN(len(songs))]
+	// 2) Build the items; both sides of a challenge get them from its seed
+	if !gradable(song.Lyrics) {
+		return nil, ungradableSong(song.Id)
+	}
+	size := svc.lessonSize
+	if challenge != nil {
+		size = storedLessonSize(challenge.LessonSize)
+	}
+	items := GenerateItems(seed, song.Lyrics, size)
 
-	// 2) Build vocabulary and candidate line indexes from song.Lyrics ([][]string)
-	lines := song.Lyrics
-	if len(lines) == 0 {
-		return nil, errors.New("chosen song has no lines")
+	// 3) Persist lesson
+	lesson := &models.Lesson{
+		UserId:     dto.UserId,
+		SongId:     song.Id,
+		Seed:       &seed,
+		Size:       size,
+		Difficulty: song.Difficulty,
+		Items:      items,
+		Answers:    make([]models.LessonAnswer, 0),
+		CreatedAt:  svc.now(),
+	}
+	if enrollment != nil {
+		lesson.CourseId = enrollment.CourseId
+		lesson.CourseStep = courseStep
+	}
+	if assignment != nil {
+		lesson.AssignmentId = assignment.Id
+	}
+	if challenge != nil {
+		lesson.ChallengeId = challenge.Id
+	}
+	lesson, err = svc.lessonRepo.Create(ctx, dto.UserId, lesson)
+	if err != nil {
+		return nil, err
 	}
+	if challenge != nil {
+		// claim once the lesson exists; a player racing themselves keeps the
+		// first claim and the losing lesson is dropped
+		claimed, err := svc.challengeRepo.ClaimLesson(ctx, challenge.Id, challenge.RoleOf(dto.UserId), lesson.Id)
+		if err != nil || !claimed {
+			if _, dropErr := svc.lessonRepo.SetStatus(ctx, lesson.Id, models.OpenLessonStatuses, models.LessonStatusAbandoned, svc.now()); dropErr != nil {
+				svc.logger.WarnContext(ctx, "drop unclaimed challenge lesson failed", "lessonId", lesson.Id, "err", dropErr)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return nil, ErrChallengePlayed
+		}
+	}
+	if open != nil {
+		svc.abandonOpen(ctx, dto.UserId, lesson.Id)
+	}
+	svc.metrics.LessonCreated(len(lesson.Items), size)
+
+	return &contracts.CreateLessonResponse{
+		LessonId: lesson.Id,
+		Items:    utils.ToContractItems(lesson.Items),
+	}, nil
+}
 
+// GenerateItems builds the items of a lesson with the given seed and size.
+func GenerateItems(seed int64, lines [][]string, size int) []models.LessonItem {
+	return buildItems(rand.New(rand.NewPCG(uint64(seed), 0)), lines, size)
+}
+
+// buildItems generates up to size lesson items (half fillblanks, the rest
+// arrange) from the lines of a song. The items depend only on r, lines and
+// size, so the same seed always yields the same lesson.
+func buildItems(r *rand.Rand, lines [][]string, size int) []models.LessonItem {
+	// Build vocabulary and candidate line indexes
 	vocab := utils.UniqueLower(utils.Flatten(lines)) // []string of unique, lower-cased words for distractors
 
 	// Prepare distinct candidates for each type
@@ -87,8 +307,10 @@ func (svc *LessonService) CreateLesson(
 	r.Shuffle(len(fillCands), func(i, j int) { fillCands[i], fillCands[j] = fillCands[j], fillCands[i] })
 	r.Shuffle(len(arrCands), func(i, j int) { arrCands[i], arrCands[j] = arrCands[j], arrCands[i] })
 
-	// Target: 3 fillblanks + 3 arrange, without repeating the same line
+	// Target: half fillblanks, half arrange, without repeating the same line or
+	// the same question (repeated lyrics render identical blanks)
 	used := make(map[int]struct{})
+	seen := make(map[string]struct{})
 	items := make([]models.LessonItem, 0, 6)
 
 	// helper to consume from a candidate list ensuring unique line usage
@@ -106,7 +328,7 @@ func (svc *LessonService) CreateLesson(
 	}
 
 	// Build fills
-	for len(items) < 3 {
+	for len(items) < size/2 {
 		idx, ok := take(&fillCands)
 		if !ok {
 			break
@@ -116,128 +338,731 @@ func (svc *LessonService) CreateLesson(
 		correct := words[hidden]
 		options := utils.BuildOptions(r, correct, vocab)
 		rendered := utils.RenderBlank(words, hidden)
-		items = append(items, models.LessonItem{
+		cand := models.LessonItem{
 			Type:         models.LessonTypeFillBlanks,
 			LineIndex:    idx,
 			RenderedLine: rendered,
 			Words:        options,
 			CorrectWord:  correct,
-		})
+		}
+		if _, ok := seen[utils.ItemSignature(cand)]; ok {
+			continue
+		}
+		seen[utils.ItemSignature(cand)] = struct{}{}
+		items = append(items, cand)
 	}
 
 	// Build arrange
-	for len(items) < 6 {
+	for len(items) < size {
 		idx, ok := take(&arrCands)
 		if !ok {
 			break
 		}
 		words := slices.Clone(lines[idx])
-		items = append(items, models.LessonItem{
+		cand := models.LessonItem{
 			Type:      models.LessonTypeArrange,
 			LineIndex: idx,
 			Words:     words,
-		})
+		}
+		if _, ok := seen[utils.ItemSignature(cand)]; ok {
+			continue
+		}
+		seen[utils.ItemSignature(cand)] = struct{}{}
+		items = append(items, cand)
 	}
 
-	// If we still don't have 6 items (e.g., not enough distinct lines), allow reuse but avoid exact duplicates
-	if len(items) < 6 {
+	// If we still don't have size items (e.g., not enough distinct lines), allow reuse but avoid exact duplicates
+	if len(items) < size {
 		// fallback pool of all indexes
 		pool := r.Perm(len(lines))
-		seen := make(map[string]struct{})
-		for _, it := range items {
-			sig := utils.ItemSignature(it)
-			seen[sig] = struct{}{}
+		add := func(cand models.LessonItem) bool {
+			if _, ok := seen[utils.ItemSignature(cand)]; ok {
+				return false
+			}
+			seen[utils.ItemSignature(cand)] = struct{}{}
+			items = append(items, cand)
+			return true
 		}
 		for _, idx := range pool {
-			if len(items) >= 6 {
+			if len(items) >= size {
 				break
 			}
 			words := slices.Clone(lines[idx])
 			if len(words) == 0 {
 				continue
 			}
-			// alternate types while creating distinct signatures
-			if len(items)%2 == 0 && len(words) >= 2 {
+			// alternate types while creating distinct signatures; when the
+			// preferred type repeats an item, try the other one
+			fill := func() bool {
+				if len(words) < 2 {
+					return false
+				}
 				hidden := r.IntN(len(words))
 				correct := words[hidden]
 				options := utils.BuildOptions(r, correct, vocab)
 				rendered := utils.RenderBlank(words, hidden)
-				cand := models.LessonItem{Type: models.LessonTypeFillBlanks, LineIndex: idx, RenderedLine: rendered, Words: options, CorrectWord: correct}
-				if _, ok := seen[utils.ItemSignature(cand)]; ok {
-					continue
-				}
-				seen[utils.ItemSignature(cand)] = struct{}{}
-				items = append(items, cand)
-			} else {
-				cand := models.LessonItem{Type: models.LessonTypeArrange, LineIndex: idx, Words: words}
-				if _, ok := seen[utils.ItemSignature(cand)]; ok {
-					continue
+				return add(models.LessonItem{Type: models.LessonTypeFillBlanks, LineIndex: idx, RenderedLine: rendered, Words: options, CorrectWord: correct})
+			}
+			arrange := func() bool {
+				return add(models.LessonItem{Type: models.LessonTypeArrange, LineIndex: idx, Words: words})
+			}
+			if len(items)%2 == 0 {
+				if !fill() {
+					arrange()
 				}
-				seen[utils.ItemSignature(cand)] = struct{}{}
-				items = append(items, cand)
+			} else if !arrange() {
+				fill()
 			}
 		}
 	}
 
-	// 4) Persist lesson
-	lesson := &models.Lesson{
-		UserId:  dto.UserId,
-		SongId:  song.Id,
-		Items:   items,
-		Answers: make([]models.LessonAnswer, 0),
+	return items
+}
+
+// assignment returns the group assignment a lesson practices, checking that
+// the user is a student of the group it was set for.
+func (svc *LessonService) assignment(
+	ctx context.Context,
+	user *models.User,
+	assignmentId string,
+) (*models.Assignment, error) {
+	if assignmentId == "" {
+		return nil, nil
 	}
-	lesson, err = svc.lessonRepo.Create(ctx, dto.UserId, lesson)
+	assignment, err := svc.assignmentRepo.FindById(ctx, assignmentId)
 	if err != nil {
-		return nil, err
+		return nil, missing(err, "assignment with id=%s was not found", assignmentId)
+	}
+	if m := user.MembershipOf(assignment.GroupId); m == nil || m.Role != models.GroupRoleStudent {
+		return nil, newError(ErrForbidden, "user %s is not a student of group %s", user.Id, assignment.GroupId)
+	}
+	return assignment, nil
+}
+
+// openLesson applies the one-open-lesson rule before userId starts a lesson.
+// It returns the unfinished lesson to abandon once the new one exists when
+// restarting, the unfinished lesson itself when resuming, and a
+// ErrLessonOpen when the client said neither.
+func (svc *LessonService) openLesson(
+	ctx context.Context,
+	userId string,
+	resume, restart bool,
+) (*models.Lesson, *contracts.CreateLessonResponse, error) {
+	if resume && restart {
+		return nil, nil, invalid("restart", "cannot be combined with resume")
+	}
+	open, err := svc.lessonRepo.FindLatestOpen(ctx, userId)
+	if err != nil || open == nil {
+		return nil, nil, err
+	}
+	switch {
+	case resume:
+		return nil, resumedLesson(open), nil
+	case restart:
+		return open, nil, nil
+	}
+	return nil, nil, &Error{
+		Kind:         ErrLessonOpen,
+		Message:      fmt.Sprintf("lesson %s is unfinished; resume or restart it", open.Id),
+		OpenLessonId: open.Id,
+	}
+}
+
+// abandonOpen abandons the open lessons of userId other than keepId. It runs
+// only once the new lesson exists, so a failed insert leaves the old one
+// resumable.
+func (svc *LessonService) abandonOpen(ctx context.Context, userId, keepId string) {
+	if _, err := svc.lessonRepo.AbandonOpen(ctx, userId, keepId, svc.now()); err != nil {
+		svc.logger.WarnContext(ctx, "abandon open lessons failed", "userId", userId, "err", err)
 	}
+}
 
+// resumedLesson replies with an unfinished lesson and what was answered.
+func resumedLesson(lesson *models.Lesson) *contracts.CreateLessonResponse {
+	answered := make([]int, 0, len(lesson.Answers))
+	for _, a := range lesson.Answers {
+		answered = append(answered, a.ItemIndex)
+	}
+	slices.Sort(answered)
 	return &contracts.CreateLessonResponse{
-		LessonId: lesson.Id,
-		Items:    utils.ToContractItems(lesson.Items),
-	}, nil
+		LessonId:      lesson.Id,
+		Items:         utils.ToContractItems(lesson.Items),
+		Resumed:       true,
+		AnsweredItems: answered,
+	}
+}
+
+// gradable reports whether lines yield a fillblanks item, which needs a line
+// of two or more words. A lesson without one has nothing the server grades,
+// so it could never complete.
+func gradable(lines [][]string) bool {
+	return slices.ContainsFunc(lines, func(words []string) bool { return len(words) >= 2 })
+}
+
+func ungradableSong(songId string) error {
+	return newError(ErrValidation, "song %s has no line of two or more words to quiz on", songId)
+}
+
+// challenge returns the challenge a lesson plays, checking that the user
+// takes part and may play now: the challenger right away, the opponent once
+// they accepted.
+func (svc *LessonService) challenge(
+	ctx context.Context,
+	user *models.User,
+	challengeId string,
+) (*models.Challenge, error) {
+	if challengeId == "" {
+		return nil, nil
+	}
+	challenge, err := svc.challengeRepo.FindById(ctx, challengeId)
+	if err != nil {
+		return nil, missing(err, "challenge with id=%s was not found", challengeId)
+	}
+	role := challenge.RoleOf(user.Id)
+	if role == "" {
+		return nil, newError(ErrForbidden, "user %s is not part of challenge %s", user.Id, challengeId)
+	}
+	playable := challenge.Status == models.ChallengeStatusAccepted ||
+		(challenge.Status == models.ChallengeStatusPending && role == models.ChallengeRoleChallenger)
+	if !playable {
+		return nil, fmt.Errorf("%w: challenge is %s", ErrChallengeState, challenge.Status)
+	}
+	return challenge, nil
+}
+
+// resumeChallengeLesson returns the lesson a player already started for a
+// challenge, as long as they have not finished or abandoned it.
+func (svc *LessonService) resumeChallengeLesson(
+	ctx context.Context,
+	lessonId string,
+) (*contracts.CreateLessonResponse, error) {
+	lesson, err := svc.lessonRepo.GetById(ctx, lessonId)
+	if err != nil {
+		return nil, err
+	}
+	if !models.IsLessonOpen(lesson.Status) {
+		return nil, ErrChallengePlayed
+	}
+	return resumedLesson(lesson), nil
+}
+
+// courseEnrollment returns the enrollment a lesson should follow: the one for
+// the requested course, or the user's latest active enrollment when the
+// request does not ask for anything more specific.
+func (svc *LessonService) courseEnrollment(
+	ctx context.Context,
+	dto contracts.CreateLessonDto,
+) (*models.Enrollment, error) {
+	if dto.CourseId != "" {
+		enrollment, err := svc.enrollmentRepo.Find(ctx, dto.UserId, dto.CourseId)
+		if err != nil {
+			return nil, missing(err, "user %s is not enrolled in course %s", dto.UserId, dto.CourseId)
+		}
+		return enrollment, nil
+	}
+	if dto.Tag != "" || dto.Difficulty != "" || dto.CollectionId != "" {
+		return nil, nil
+	}
+	return svc.enrollmentRepo.FindLatestActive(ctx, dto.UserId)
 }
 
-// SubmitAnswer persists an answer only for fillblanks; returns correctness and 409 on duplicate.
+// songFilter narrows song selection to what the learner asked for.
+func (svc *LessonService) songFilter(
+	ctx context.Context,
+	dto contracts.CreateLessonDto,
+) (repositories.SongFilter, error) {
+	filter := repositories.SongFilter{Tag: dto.Tag}
+	if dto.Difficulty != "" {
+		lo, hi, ok := models.DifficultyRange(dto.Difficulty)
+		if !ok {
+			return filter, invalid("difficulty", fmt.Sprintf("unknown band %q", dto.Difficulty))
+		}
+		filter.MinDifficulty, filter.MaxDifficulty = lo, hi
+	}
+	if dto.CollectionId != "" {
+		collection, err := svc.collectionRepo.FindById(ctx, dto.CollectionId)
+		if err != nil {
+			return filter, missing(err, "collection with id=%s was not found", dto.CollectionId)
+		}
+		filter.Ids = collection.SongIds
+		if filter.Ids == nil {
+			filter.Ids = []string{}
+		}
+	}
+	return filter, nil
+}
+
+// SubmitAnswer persists an answer only for fillblanks; returns correctness, the
+// progress events it triggered, and 409 on duplicate.
 func (svc *LessonService) SubmitAnswer(
 	ctx context.Context,
 	lessonId string,
 	itemIndex int,
 	ansType string,
 	userInput string,
-) (bool, error) {
+) (*contracts.SubmitAnswerResponse, error) {
 	// Only fillblanks are persisted; correctness computed against stored lesson item
 	lesson, err := svc.lessonRepo.GetById(ctx, lessonId)
 	if err != nil {
-		return false, err
+		return nil, missing(err, "lesson with id=%s was not found", lessonId)
+	}
+	if !models.IsLessonOpen(lesson.Status) {
+		return nil, newError(ErrConflict, "lesson is %s", lesson.Status)
 	}
 	// reject duplicate submissions for same item
 	for _, a := range lesson.Answers {
 		if a.ItemIndex == itemIndex {
-			return false, ErrDuplicateAnswer
+			svc.metrics.AnswerConflict()
+			return nil, ErrDuplicateAnswer
 		}
 	}
 	if itemIndex < 0 || itemIndex >= len(lesson.Items) {
-		return false, errors.New("invalid item index")
+		return nil, invalid("itemIndex", "is out of range")
 	}
 	item := lesson.Items[itemIndex]
-	if ansType != string(models.LessonTypeFillBlanks) {
+	if ansType != string(item.Type) {
+		return nil, invalid("type", "does not match item")
+	}
+	if item.Type != models.LessonTypeFillBlanks {
 		// Ignore persistence for arrange; compute correctness locally if possible
-		// For arrange, UI checks correctness itself; we reply ok without persisting
-		return true, nil
+		// For arrange, UI checks correctness itself; we reply ok without persisting.
+		// The lesson has still started.
+		at := svc.now()
+		if _, err := svc.lessonRepo.SetStatus(ctx, lessonId, []models.LessonStatus{models.LessonStatusCreated}, models.LessonStatusInProgress, at); err != nil {
+			svc.logger.WarnContext(ctx, "start lesson failed", "lessonId", lessonId, "err", err)
+		}
+		svc.metrics.AnswerUngraded(ansType)
+		// lessons stored before every lesson had a fillblanks item complete
+		// on an arrange answer, as nothing else would ever close them
+		var events []models.ProgressEvent
+		if isLessonComplete(lesson) {
+			events = svc.completeLesson(ctx, lesson, at)
+		}
+		return &contracts.SubmitAnswerResponse{Ok: true, Correct: true, Events: toContractEvents(events)}, nil
 	}
 
 	correct := strings.EqualFold(userInput, item.CorrectWord)
+	answer := models.LessonAnswer{
+		ItemIndex:    itemIndex,
+		Type:         ansType,
+		SongId:       lesson.SongId,
+		LineIndex:    item.LineIndex,
+		ExpectedWord: item.CorrectWord,
+		UserInput:    userInput,
+		Correct:      correct,
+		AnsweredAt:   svc.now(),
+	}
 	// Try to push answer; repo enforces single submission per item
-	err = svc.lessonRepo.AddAnswer(ctx, lessonId, models.LessonAnswer{
-		ItemIndex: itemIndex,
-		Type:      ansType,
-		UserInput: userInput,
-		Correct:   correct,
+	err = svc.lessonRepo.AddAnswer(ctx, lessonId, answer)
+	if err != nil {
+		if errors.Is(err, ErrConflict) {
+			svc.metrics.AnswerConflict()
+		}
+		return nil, err
+	}
+	svc.metrics.AnswerGraded(ansType, correct)
+
+	svc.recordMastery(ctx, lesson, item.CorrectWord, correct)
+
+	var events []models.ProgressEvent
+	if correct {
+		xp := int(math.Round(models.XPPerFillBlank * models.DifficultyMultiplier(lesson.Difficulty)))
+		events = append(events, svc.awardXP(ctx, lesson.UserId, xp, answer.AnsweredAt)...)
+	}
+
+	lesson.Answers = append(lesson.Answers, answer)
+	if isLessonComplete(lesson) {
+		events = append(events, svc.completeLesson(ctx, lesson, answer.AnsweredAt)...)
+	}
+	return &contracts.SubmitAnswerResponse{
+		Ok:      true,
+		Correct: correct,
+		Events:  toContractEvents(events),
+	}, nil
+}
+
+// completeLesson closes a lesson whose last item was answered and feeds its
+// accuracy into course progress. Only the request that actually moves the
+// lesson to completed does so, so concurrent answers cannot count it twice.
+func (svc *LessonService) completeLesson(ctx context.Context, lesson *models.Lesson, at time.Time) []models.ProgressEvent {
+	done, err := svc.lessonRepo.SetStatus(ctx, lesson.Id, models.OpenLessonStatuses, models.LessonStatusCompleted, at)
+	if err != nil {
+		svc.logger.WarnContext(ctx, "complete lesson failed", "lessonId", lesson.Id, "err", err)
+		return nil
+	}
+	if !done {
+		return nil
+	}
+	if lesson.CourseId != "" {
+		svc.recordCourseResult(ctx, lesson)
+	}
+	st := summarize(lesson)
+	accuracy := st.accuracy
+	if lesson.AssignmentId != "" {
+		svc.recordAssignmentResult(ctx, lesson, accuracy, at)
+	}
+	if lesson.ChallengeId != "" {
+		svc.recordChallengeResult(ctx, lesson, st, at)
+	}
+	if err := svc.boardRepo.RecordBest(ctx, models.LeaderboardSong, lesson.SongId, lesson.UserId, accuracy, at); err != nil {
+		svc.logger.WarnContext(ctx, "song leaderboard update failed", "lessonId", lesson.Id, "err", err)
+	}
+
+	arrange := 0
+	for _, item := range lesson.Items {
+		if item.Type == models.LessonTypeArrange {
+			arrange++
+		}
+	}
+	base := models.XPPerLessonCompletion + models.XPPerArrangeItem*arrange
+	xp := int(math.Round(float64(base) * models.DifficultyMultiplier(lesson.Difficulty)))
+	// the completion bonus is reported by the xp event of the award, only
+	// when it was applied
+	events := []models.ProgressEvent{{Type: models.ProgressEventLessonCompleted}}
+	return append(events, svc.awardXP(ctx, lesson.UserId, xp, at)...)
+}
+
+// awardXP credits a user with XP. Failures are logged; XP is a bonus and
+// must not fail the answer that earned it.
+func (svc *LessonService) awardXP(ctx context.Context, userId string, xp int, at time.Time) []models.ProgressEvent {
+	_, events, err := updateProgress(ctx, svc.progressRepo, userId, func(p *models.UserProgress) []models.ProgressEvent {
+		return p.Award(xp, at)
 	})
 	if err != nil {
-		return false, err
+		svc.logger.WarnContext(ctx, "award xp failed", "userId", userId, "xp", xp, "err", err)
+		return nil
+	}
+	svc.recordLeaderboardXP(ctx, userId, xp, at)
+	return events
+}
+
+// recordLeaderboardXP adds awarded XP to the weekly and all-time boards.
+func (svc *LessonService) recordLeaderboardXP(ctx context.Context, userId string, xp int, at time.Time) {
+	if err := svc.boardRepo.AddScore(ctx, models.LeaderboardWeeklyXP, models.WeekScope(at), userId, float64(xp), at); err != nil {
+		svc.logger.WarnContext(ctx, "weekly leaderboard update failed", "userId", userId, "err", err)
+	}
+	if err := svc.boardRepo.AddScore(ctx, models.LeaderboardAllTimeXP, "", userId, float64(xp), at); err != nil {
+		svc.logger.WarnContext(ctx, "all-time leaderboard update failed", "userId", userId, "err", err)
+	}
+}
+
+// AbandonLesson closes an open lesson the learner gave up on.
+func (svc *LessonService) AbandonLesson(ctx context.Context, lessonId string) error {
+	lesson, err := svc.lessonRepo.GetById(ctx, lessonId)
+	if err != nil {
+		return missing(err, "lesson with id=%s was not found", lessonId)
+	}
+	at := svc.now()
+	done, err := svc.lessonRepo.SetStatus(ctx, lessonId, models.OpenLessonStatuses, models.LessonStatusAbandoned, at)
+	if err != nil {
+		return err
+	}
+	if !done {
+		return newError(ErrConflict, "lesson is %s", lessonStatus(lesson))
+	}
+	if lesson.ChallengeId != "" {
+		// walking away from a challenge forfeits it
+		svc.finishChallengeEntry(ctx, lesson, models.ChallengeEntry{
+			LessonId:   lesson.Id,
+			Finished:   true,
+			Forfeited:  true,
+			FinishedAt: at,
+		}, at)
+	}
+	return nil
+}
+
+// ExpireStaleLessons expires open lessons idle for longer than ttl.
+func (svc *LessonService) ExpireStaleLessons(ctx context.Context, ttl time.Duration) (int64, error) {
+	now := svc.now()
+	return svc.lessonRepo.ExpireStale(ctx, now.Add(-ttl), now)
+}
+
+// RunExpirySweeper expires stale lessons every interval until ctx is done.
+func (svc *LessonService) RunExpirySweeper(ctx context.Context, ttl, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := svc.ExpireStaleLessons(ctx, ttl)
+			if err != nil {
+				svc.logger.WarnContext(ctx, "lesson sweeper failed", "err", err)
+				continue
+			}
+			if n > 0 {
+				svc.logger.InfoContext(ctx, "expired stale lessons", "count", n)
+			}
+		}
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// lessonStatus reports the status of lessons stored before statuses existed
+// as created or in progress depending on their answers.
+func lessonStatus(lesson *models.Lesson) models.LessonStatus {
+	if lesson.Status != "" {
+		return lesson.Status
+	}
+	if len(lesson.Answers) > 0 {
+		return models.LessonStatusInProgress
+	}
+	return models.LessonStatusCreated
+}
+
+// recordMastery updates the learner's mastery of the expected word. Failures
+// are logged; the answer itself is already stored.
+func (svc *LessonService) recordMastery(ctx context.Context, lesson *models.Lesson, word string, correct bool) {
+	word = utils.NormalizeWord(word)
+	if word == "" {
+		return
+	}
+	if err := svc.masteryRepo.Record(ctx, lesson.UserId, word, lesson.SongId, correct, svc.now()); err != nil {
+		svc.logger.WarnContext(ctx, "word mastery: record failed", "lessonId", lesson.Id, "err", err)
+	}
+}
+
+// recordCourseResult feeds a finished lesson's accuracy into the learner's
+// course progress. Failures are logged; the answer itself is already stored.
+func (svc *LessonService) recordCourseResult(ctx context.Context, lesson *models.Lesson) {
+	course, err := svc.courseRepo.FindById(ctx, lesson.CourseId)
+	if err != nil {
+		svc.logger.WarnContext(ctx, "course progress: find course failed", "lessonId", lesson.Id, "err", err)
+		return
+	}
+	enrollment, err := svc.enrollmentRepo.Find(ctx, lesson.UserId, lesson.CourseId)
+	if err != nil {
+		svc.logger.WarnContext(ctx, "course progress: find enrollment failed", "lessonId", lesson.Id, "err", err)
+		return
+	}
+
+	enrollment.RecordResult(course, lesson.SongId, summarize(lesson).accuracy)
+	enrollment.UpdatedAt = svc.now()
+	if err := svc.enrollmentRepo.Update(ctx, enrollment); err != nil {
+		svc.logger.WarnContext(ctx, "course progress: update enrollment failed", "lessonId", lesson.Id, "err", err)
+	}
+}
+
+// recordAssignmentResult counts a finished lesson towards the learner's
+// assignment. Failures are logged; the answer itself is already stored.
+func (svc *LessonService) recordAssignmentResult(ctx context.Context, lesson *models.Lesson, accuracy float64, at time.Time) {
+	assignment, err := svc.assignmentRepo.FindById(ctx, lesson.AssignmentId)
+	if err != nil {
+		svc.logger.WarnContext(ctx, "assignment result: find assignment failed", "lessonId", lesson.Id, "err", err)
+		return
+	}
+	passed := accuracy >= assignment.MinAccuracy
+	if err := svc.assignmentRepo.RecordResult(ctx, assignment.Id, lesson.UserId, accuracy, passed, at); err != nil {
+		svc.logger.WarnContext(ctx, "assignment result: record failed", "lessonId", lesson.Id, "err", err)
+	}
+}
+
+// recordChallengeResult stores the score of a finished challenge lesson.
+func (svc *LessonService) recordChallengeResult(ctx context.Context, lesson *models.Lesson, st lessonStats, at time.Time) {
+	started := lesson.StartedAt
+	if started.IsZero() {
+		started = lesson.CreatedAt
 	}
-	return correct, nil
+	svc.finishChallengeEntry(ctx, lesson, models.ChallengeEntry{
+		LessonId:        lesson.Id,
+		Finished:        true,
+		Accuracy:        st.accuracy,
+		Correct:         st.correct,
+		Wrong:           st.wrong,
+		DurationSeconds: at.Sub(started).Seconds(),
+		FinishedAt:      at,
+	}, at)
+}
+
+// finishChallengeEntry stores how a player's challenge lesson ended and,
+// when the other player is done too, decides the challenge. Failures are
+// logged; the answer or abandon itself is already stored.
+func (svc *LessonService) finishChallengeEntry(ctx context.Context, lesson *models.Lesson, entry models.ChallengeEntry, at time.Time) {
+	challenge, err := svc.challengeRepo.FindById(ctx, lesson.ChallengeId)
+	if err != nil {
+		svc.logger.WarnContext(ctx, "challenge result: find challenge failed", "lessonId", lesson.Id, "err", err)
+		return
+	}
+	role := challenge.RoleOf(lesson.UserId)
+	if role == "" {
+		return
+	}
+	recorded, err := svc.challengeRepo.RecordResult(ctx, challenge.Id, role, entry)
+	if err != nil || !recorded {
+		if err != nil {
+			svc.logger.WarnContext(ctx, "challenge result: record failed", "lessonId", lesson.Id, "err", err)
+		}
+		return
+	}
+
+	// re-read: the other player may have finished meanwhile
+	if challenge, err = svc.challengeRepo.FindById(ctx, challenge.Id); err != nil {
+		svc.logger.WarnContext(ctx, "challenge result: reload failed", "lessonId", lesson.Id, "err", err)
+		return
+	}
+	if !challenge.Challenger.Finished || !challenge.Opponent.Finished {
+		return
+	}
+	if _, err := svc.challengeRepo.Complete(ctx, challenge.Id, challenge.Winner(), at); err != nil {
+		svc.logger.WarnContext(ctx, "challenge result: complete failed", "challengeId", challenge.Id, "err", err)
+	}
+}
+
+// isLessonComplete reports whether every persisted (fillblanks) item has an
+// answer; arrange outcomes are never sent to the server.
+func isLessonComplete(lesson *models.Lesson) bool {
+	answered := make(map[int]struct{}, len(lesson.Answers))
+	for _, a := range lesson.Answers {
+		answered[a.ItemIndex] = struct{}{}
+	}
+	for i, item := range lesson.Items {
+		if item.Type != models.LessonTypeFillBlanks {
+			continue
+		}
+		if _, ok := answered[i]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ListLessons returns one page of a user's lessons, newest first, and the
+// cursor of the next page.
+func (svc *LessonService) ListLessons(
+	ctx context.Context,
+	userId string,
+	query contracts.ListLessonsQuery,
+) ([]contracts.LessonHistoryItem, string, error) {
+	if _, err := svc.userRepo.FindOne(ctx, userId); err != nil {
+		return nil, "", missing(err, "user with id=%s was not found", userId)
+	}
+	lessons, next, err := svc.lessonRepo.FindByUser(ctx, repositories.LessonFilter{
+		UserId: userId,
+		Status: query.Status,
+		From:   query.From,
+		To:     query.To,
+		Cursor: query.Cursor,
+		Limit:  query.Limit,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp := make([]contracts.LessonHistoryItem, 0, len(lessons))
+	for _, l := range lessons {
+		resp = append(resp, contracts.LessonHistoryItem{
+			LessonId:              l.Id,
+			SongId:                l.SongId,
+			Status:                lessonStatus(l),
+			CreatedAt:             l.CreatedAt,
+			Answered:              len(l.Answers),
+			LessonSummaryResponse: summarize(l).response(),
+		})
+	}
+	return resp, next, nil
+}
+
+// GetLesson returns a lesson with its answers for review.
+func (svc *LessonService) GetLesson(
+	ctx context.Context,
+	lessonId string,
+) (*contracts.LessonDetailResponse, error) {
+	lesson, err := svc.lessonRepo.GetById(ctx, lessonId)
+	if err != nil {
+		return nil, missing(err, "lesson with id=%s was not found", lessonId)
+	}
+
+	answers := make([]contracts.LessonAnswerResponse, 0, len(lesson.Answers))
+	for _, a := range lesson.Answers {
+		answers = append(answers, contracts.LessonAnswerResponse{
+			ItemIndex:    a.ItemIndex,
+			Type:         a.Type,
+			LineIndex:    a.LineIndex,
+			ExpectedWord: lesson.ExpectedWordOf(a),
+			UserInput:    a.UserInput,
+			Correct:      a.Correct,
+			AnsweredAt:   a.AnsweredAt,
+		})
+	}
+	return &contracts.LessonDetailResponse{
+		LessonId:     lesson.Id,
+		UserId:       lesson.UserId,
+		SongId:       lesson.SongId,
+		CourseId:     lesson.CourseId,
+		RetryOf:      lesson.RetryOf,
+		AssignmentId: lesson.AssignmentId,
+		ChallengeId:  lesson.ChallengeId,
+		Seed:         lesson.Seed,
+		Status:       lessonStatus(lesson),
+		CreatedAt:    lesson.CreatedAt,
+		StartedAt:    timePtr(lesson.StartedAt),
+		CompletedAt:  timePtr(lesson.CompletedAt),
+		AbandonedAt:  timePtr(lesson.AbandonedAt),
+		ExpiredAt:    timePtr(lesson.ExpiredAt),
+		Items:        utils.ToContractItems(lesson.Items),
+		Answers:      answers,
+		Summary:      summarize(lesson).response(),
+	}, nil
+}
+
+// RetryLesson creates a fresh lesson with the same items as lessonId for its
+// owner. Like CreateLesson it honours the one-open-lesson rule, so an
+// unfinished lesson is resumed or restarted only when the client says which.
+// A retried course lesson still counts towards the course.
+func (svc *LessonService) RetryLesson(
+	ctx context.Context,
+	lessonId string,
+	dto contracts.RetryLessonDto,
+) (*contracts.CreateLessonResponse, error) {
+	if strings.TrimSpace(dto.UserId) == "" {
+		return nil, invalid("userId", "is required")
+	}
+	prev, err := svc.lessonRepo.GetById(ctx, lessonId)
+	if err != nil {
+		return nil, missing(err, "lesson with id=%s was not found", lessonId)
+	}
+	if prev.UserId != dto.UserId {
+		return nil, newError(ErrForbidden, "lesson %s belongs to another user", lessonId)
+	}
+	open, resumed, err := svc.openLesson(ctx, dto.UserId, dto.Resume, dto.Restart)
+	if err != nil || resumed != nil {
+		return resumed, err
+	}
+
+	lesson := &models.Lesson{
+		SongId:       prev.SongId,
+		CourseId:     prev.CourseId,
+		CourseStep:   prev.CourseStep,
+		RetryOf:      prev.Id,
+		Seed:         prev.Seed,
+		Size:         prev.Size,
+		CreatedAt:    svc.now(),
+		Difficulty:   prev.Difficulty,
+		AssignmentId: prev.AssignmentId,
+		Items:        slices.Clone(prev.Items),
+		Answers:      make([]models.LessonAnswer, 0),
+	}
+	lesson, err = svc.lessonRepo.Create(ctx, prev.UserId, lesson)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		svc.abandonOpen(ctx, prev.UserId, lesson.Id)
+	}
+	svc.metrics.LessonCreated(len(lesson.Items), storedLessonSize(lesson.Size))
+
+	return &contracts.CreateLessonResponse{
+		LessonId: lesson.Id,
+		Items:    utils.ToContractItems(lesson.Items),
+	}, nil
 }
 
 func (svc *LessonService) GetSummary(
@@ -246,11 +1071,35 @@ func (svc *LessonService) GetSummary(
 ) (total int, correct int, wrong int, accuracy float64, scheduled []string, err error) {
 	lesson, err := svc.lessonRepo.GetById(ctx, lessonId)
 	if err != nil {
-		return 0, 0, 0, 0, nil, err
+		return 0, 0, 0, 0, nil, missing(err, "lesson with id=%s was not found", lessonId)
 	}
-	total = len(lesson.Items)
+	st := summarize(lesson)
+	return st.total, st.correct, st.wrong, st.accuracy, st.scheduled, nil
+}
+
+type lessonStats struct {
+	total     int
+	correct   int
+	wrong     int
+	accuracy  float64
+	scheduled []string
+}
+
+func (st lessonStats) response() contracts.LessonSummaryResponse {
+	return contracts.LessonSummaryResponse{
+		Total:                  st.total,
+		Correct:                st.correct,
+		Wrong:                  st.wrong,
+		Accuracy:               st.accuracy,
+		ScheduledForRepractice: st.scheduled,
+	}
+}
 
-	// Count fillblanks answers
+func summarize(lesson *models.Lesson) lessonStats {
+	st := lessonStats{total: len(lesson.Items)}
+
+	// Count fillblanks answers; a miss schedules the word the learner failed
+	// to recall, not the option they picked
 	fillblanksCorrect := 0
 	fillblanksWrong := 0
 	for _, a := range lesson.Answers {
@@ -259,7 +1108,9 @@ func (svc *LessonService) GetSummary(
 				fillblanksCorrect++
 			} else {
 				fillblanksWrong++
-				scheduled = append(scheduled, a.UserInput)
+				if w := lesson.ExpectedWordOf(a); w != "" && !slices.Contains(st.scheduled, w) {
+					st.scheduled = append(st.scheduled, w)
+				}
 			}
 		}
 	}
@@ -273,16 +1124,16 @@ func (svc *LessonService) GetSummary(
 	}
 
 	// Total correct = fillblanks correct + arrange items (assumed correct since not tracked)
-	correct = fillblanksCorrect + arrangeCount
+	st.correct = fillblanksCorrect + arrangeCount
 	// Wrong = only fillblanks wrong
-	wrong = fillblanksWrong
+	st.wrong = fillblanksWrong
 
 	// Calculate accuracy based on all items
-	if total > 0 {
-		accuracy = float64(correct) / float64(total) * 100
+	if st.total > 0 {
+		st.accuracy = float64(st.correct) / float64(st.total) * 100
 	}
-	if scheduled == nil {
-		scheduled = []string{}
+	if st.scheduled == nil {
+		st.scheduled = []string{}
 	}
-	return
+	return st
 }
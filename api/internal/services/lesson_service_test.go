@@ -0,0 +1,635 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+	"github.tomerab1/todo-api/internal/repositories/repotest"
+	"github.tomerab1/todo-api/internal/utils"
+)
+
+const testUserId = "user-1"
+
+// testSong returns a song with enough distinct lines for a full lesson.
+func testSong(id string) *models.Song {
+	lyrics := make([][]string, 0, 8)
+	for i := range 8 {
+		lyrics = append(lyrics, []string{id, "line", fmt.Sprintf("w%d", i), "goes", "on"})
+	}
+	return &models.Song{Id: id, Title: id, Lyrics: lyrics}
+}
+
+// newTestLessonService wires a LessonService to in-memory repositories
+// holding one user and the given songs. Every dependency is a fake, so none
+// is left nil for a test to trip over, and the clock and seeds are fixed so
+// runs repeat.
+func newTestLessonService(songs ...*models.Song) (*LessonService, *repotest.Repos) {
+	repos := repotest.New()
+	repos.Users.Create(context.Background(), &models.User{Id: testUserId, Name: "Test"})
+	repos.Songs.Add(songs...)
+	var seed atomic.Int64
+	svc := NewLessonService(
+		repos.Users, repos.Songs, repos.Lessons, repos.Collections, repos.Courses, repos.Enrollments,
+		repos.Mastery, repos.Progress, repos.Boards, repos.Assignments, repos.Challenges,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	).WithClock(func() time.Time {
+		return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	}).WithSeedSource(func() int64 {
+		return seed.Add(1)
+	})
+	return svc, repos
+}
+
+// answerAll answers every fillblanks item of a lesson correctly.
+func answerAll(t *testing.T, svc *LessonService, lesson *contracts.CreateLessonResponse) {
+	t.Helper()
+	for i, item := range lesson.Items {
+		if item.Type != models.LessonTypeFillBlanks {
+			continue
+		}
+		if _, err := svc.SubmitAnswer(context.Background(), lesson.LessonId, i, item.Type, item.CorrectWord); err != nil {
+			t.Fatalf("answer %d: %v", i, err)
+		}
+	}
+}
+
+// TestCourseProgressFollowsSongs checks that course progress stays with each
+// song when the course is reordered, and that positional progress stored
+// before that is carried over.
+func TestCourseProgressFollowsSongs(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"), testSong("b"), testSong("c"))
+	course := &models.Course{Id: "c1", SongIds: []string{"a", "b", "c"}, UnlockAccuracy: 80}
+	repos.Courses.Create(ctx, course)
+	repos.Enrollments.Create(ctx, &models.Enrollment{UserId: testUserId, CourseId: "c1"})
+
+	first, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, CourseId: "c1"})
+	if err != nil {
+		t.Fatalf("first lesson: %v", err)
+	}
+	if got := repos.Lessons.Lessons[0].SongId; got != "a" {
+		t.Fatalf("course starts with %s, want a", got)
+	}
+	answerAll(t, svc, first)
+
+	course.SongIds = []string{"b", "c", "a"}
+	repos.Courses.Replace(ctx, course)
+	next, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, CourseId: "c1"})
+	if err != nil {
+		t.Fatalf("lesson after reorder: %v", err)
+	}
+	if got := repos.Lessons.Lessons[1].SongId; got != "b" {
+		t.Fatalf("after reorder the course continues with %s, want b", got)
+	}
+	answerAll(t, svc, next)
+	e, _ := repos.Enrollments.Find(ctx, testUserId, "c1")
+	if !e.HasPassed("a") || !e.HasPassed("b") || e.HasPassed("c") || e.Position(course) != 1 {
+		t.Errorf("enrollment = %+v, want a and b passed and c current", e)
+	}
+
+	legacy := &models.Enrollment{CourseId: "c1", LegacyUnlocked: 2, LegacyBest: []float64{90, 40, 0}}
+	legacy.Fit(course)
+	if !legacy.HasPassed("b") || legacy.BestAccuracy["c"] != 40 || legacy.Position(course) != 1 || legacy.LegacyBest != nil {
+		t.Errorf("legacy enrollment = %+v, want b passed and c current at 40%%", legacy)
+	}
+}
+
+// TestRecordMasteryConcurrent checks that concurrent answers on one word are
+// all counted.
+func TestRecordMasteryConcurrent(t *testing.T) {
+	svc, repos := newTestLessonService()
+	lesson := &models.Lesson{Id: "l1", UserId: testUserId, SongId: "a"}
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.recordMastery(context.Background(), lesson, "Rock", i%2 == 0)
+		}()
+	}
+	wg.Wait()
+
+	m := repos.Mastery.Mastery[models.WordMasteryId(testUserId, "rock")]
+	if m == nil || m.SeenCount != 20 || m.CorrectCount != 10 {
+		t.Errorf("mastery = %+v, want 20 answers, 10 correct", m)
+	}
+}
+
+// TestMissedAnswerSchedulesExpectedWord checks that a miss is stored with
+// the word the learner had to recall and schedules that word, also for
+// answers stored before they carried it.
+func TestMissedAnswerSchedulesExpectedWord(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"))
+	lesson, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId})
+	if err != nil {
+		t.Fatalf("CreateLesson: %v", err)
+	}
+	item := lesson.Items[0]
+	wrong := item.Words[slices.IndexFunc(item.Words, func(w string) bool { return w != item.CorrectWord })]
+	if _, err := svc.SubmitAnswer(ctx, lesson.LessonId, 0, item.Type, wrong); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+
+	ans := repos.Lessons.Lessons[0].Answers[0]
+	if ans.ExpectedWord != item.CorrectWord || ans.UserInput != wrong || ans.SongId != "a" || ans.LineIndex != item.LineIndex || ans.AnsweredAt.IsZero() {
+		t.Errorf("stored answer = %+v, want expected word %q, song a, line %d", ans, item.CorrectWord, item.LineIndex)
+	}
+	_, _, _, _, scheduled, err := svc.GetSummary(ctx, lesson.LessonId)
+	if err != nil || !slices.Equal(scheduled, []string{item.CorrectWord}) {
+		t.Errorf("scheduled = %v (%v), want [%s]", scheduled, err, item.CorrectWord)
+	}
+
+	legacy := &models.Lesson{
+		Id: "legacy",
+		Items: []models.LessonItem{
+			{Type: models.LessonTypeFillBlanks, CorrectWord: "stay"},
+			{Type: models.LessonTypeFillBlanks, CorrectWord: "stay"},
+		},
+		Answers: []models.LessonAnswer{
+			{ItemIndex: 0, Type: models.LessonTypeFillBlanks, UserInput: "go"},
+			{ItemIndex: 1, Type: models.LessonTypeFillBlanks, UserInput: "run"},
+		},
+	}
+	if got := summarize(legacy).scheduled; !slices.Equal(got, []string{"stay"}) {
+		t.Errorf("legacy scheduled = %v, want [stay]", got)
+	}
+}
+
+// TestLessonHistoryAndReview checks that a user's history lists their lessons
+// newest first and that a lesson reviews with the word each answer expected.
+func TestLessonHistoryAndReview(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestLessonService(testSong("a"))
+
+	first, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId})
+	if err != nil {
+		t.Fatalf("first lesson: %v", err)
+	}
+	answerAll(t, svc, first)
+	second, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId})
+	if err != nil {
+		t.Fatalf("second lesson: %v", err)
+	}
+
+	history, _, err := svc.ListLessons(ctx, testUserId, contracts.ListLessonsQuery{})
+	if err != nil {
+		t.Fatalf("ListLessons: %v", err)
+	}
+	if len(history) != 2 || history[0].LessonId != second.LessonId || history[1].LessonId != first.LessonId {
+		t.Fatalf("history = %+v, want %s then %s", history, second.LessonId, first.LessonId)
+	}
+	if history[1].Answered == 0 || history[1].Wrong != 0 || history[0].Answered != 0 {
+		t.Errorf("history answered = %d/%d wrong, %d; want the first fully right and the second untouched",
+			history[1].Answered, history[1].Wrong, history[0].Answered)
+	}
+
+	review, err := svc.GetLesson(ctx, first.LessonId)
+	if err != nil {
+		t.Fatalf("GetLesson: %v", err)
+	}
+	if len(review.Answers) != history[1].Answered {
+		t.Fatalf("review has %d answers, want %d", len(review.Answers), history[1].Answered)
+	}
+	for _, a := range review.Answers {
+		if want := first.Items[a.ItemIndex].CorrectWord; a.ExpectedWord != want || !a.Correct {
+			t.Errorf("answer %d expected %q (correct %v), want %q", a.ItemIndex, a.ExpectedWord, a.Correct, want)
+		}
+	}
+}
+
+// TestRetryLessonOwnerOnly checks that only the owner may retry a lesson and
+// that the retry replays its items.
+func TestRetryLessonOwnerOnly(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"))
+
+	first, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId})
+	if err != nil {
+		t.Fatalf("CreateLesson: %v", err)
+	}
+	answerAll(t, svc, first)
+	if _, err := svc.RetryLesson(ctx, first.LessonId, contracts.RetryLessonDto{UserId: "someone-else"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("retry by another user = %v, want ErrForbidden", err)
+	}
+	if n := len(repos.Lessons.Lessons); n != 1 {
+		t.Fatalf("%d lessons stored, want 1", n)
+	}
+
+	retry, err := svc.RetryLesson(ctx, first.LessonId, contracts.RetryLessonDto{UserId: testUserId})
+	if err != nil {
+		t.Fatalf("RetryLesson: %v", err)
+	}
+	fresh := repos.Lessons.Lessons[1]
+	if fresh.Id != retry.LessonId || fresh.RetryOf != first.LessonId || fresh.UserId != testUserId {
+		t.Errorf("retry %s of %q for %s, want a retry of %s for %s", fresh.Id, fresh.RetryOf, fresh.UserId, first.LessonId, testUserId)
+	}
+	if !reflect.DeepEqual(retry.Items, first.Items) {
+		t.Errorf("retry items differ from the original")
+	}
+}
+
+// TestCreateLessonOffersOpenLesson checks that an unfinished lesson, even
+// one stored before lessons had a status, is offered back instead of being
+// silently abandoned.
+func TestCreateLessonOffersOpenLesson(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"))
+	legacy := &models.Lesson{Id: "legacy", UserId: testUserId, SongId: "a", CreatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)}
+	repos.Lessons.Lessons = append(repos.Lessons.Lessons, legacy)
+
+	_, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId})
+	var open *Error
+	if !errors.Is(err, ErrLessonOpen) || !errors.As(err, &open) || open.OpenLessonId != "legacy" {
+		t.Fatalf("CreateLesson = %v, want ErrLessonOpen naming the legacy lesson", err)
+	}
+	if _, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, Resume: true, Restart: true}); !errors.Is(err, ErrValidation) {
+		t.Errorf("resume with restart = %v, want a validation error", err)
+	}
+
+	resumed, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, Resume: true})
+	if err != nil || !resumed.Resumed || resumed.LessonId != "legacy" {
+		t.Fatalf("resume = %+v, %v; want the legacy lesson", resumed, err)
+	}
+
+	restarted, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, Restart: true})
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if legacy.Status != models.LessonStatusAbandoned {
+		t.Errorf("legacy status = %q, want abandoned", legacy.Status)
+	}
+	if fresh := repos.Lessons.Lessons[1]; fresh.Id != restarted.LessonId || fresh.Status != models.LessonStatusCreated {
+		t.Errorf("new lesson %s is %q, want created", fresh.Id, fresh.Status)
+	}
+
+	done, err := repos.Lessons.SetStatus(ctx, "legacy", models.OpenLessonStatuses, models.LessonStatusCompleted, time.Now())
+	if err != nil || done {
+		t.Errorf("completing the abandoned lesson = %v, %v; want false", done, err)
+	}
+}
+
+// TestRetryLessonFollowsOpenLessonRule checks that a retry, like a new
+// lesson, offers back an unfinished one.
+func TestRetryLessonFollowsOpenLessonRule(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"))
+
+	first, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId})
+	if err != nil {
+		t.Fatalf("CreateLesson: %v", err)
+	}
+	_, err = svc.RetryLesson(ctx, first.LessonId, contracts.RetryLessonDto{UserId: testUserId})
+	var open *Error
+	if !errors.Is(err, ErrLessonOpen) || !errors.As(err, &open) || open.OpenLessonId != first.LessonId {
+		t.Fatalf("retry with an open lesson = %v, want ErrLessonOpen naming %s", err, first.LessonId)
+	}
+	resumed, err := svc.RetryLesson(ctx, first.LessonId, contracts.RetryLessonDto{UserId: testUserId, Resume: true})
+	if err != nil || !resumed.Resumed || resumed.LessonId != first.LessonId {
+		t.Fatalf("resume = %+v, %v; want %s", resumed, err, first.LessonId)
+	}
+	if n := len(repos.Lessons.Lessons); n != 1 {
+		t.Fatalf("%d lessons stored, want 1", n)
+	}
+
+	retry, err := svc.RetryLesson(ctx, first.LessonId, contracts.RetryLessonDto{UserId: testUserId, Restart: true})
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	prev, fresh := repos.Lessons.Lessons[0], repos.Lessons.Lessons[1]
+	if prev.Status != models.LessonStatusAbandoned {
+		t.Errorf("retried lesson is %q, want abandoned", prev.Status)
+	}
+	if fresh.Id != retry.LessonId || fresh.RetryOf != first.LessonId || fresh.Status != models.LessonStatusCreated {
+		t.Errorf("retry %s of %q is %q, want a created retry of %s", fresh.Id, fresh.RetryOf, fresh.Status, first.LessonId)
+	}
+}
+
+// TestLessonLifecycle checks that answers move a lesson to in_progress and
+// completed, that closed lessons reject answers, and that the sweeper
+// expires idle lessons only.
+func TestLessonLifecycle(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"))
+
+	first, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId})
+	if err != nil {
+		t.Fatalf("CreateLesson: %v", err)
+	}
+	fill := slices.IndexFunc(first.Items, func(it contracts.LessonItem) bool { return it.Type == models.LessonTypeFillBlanks })
+	if _, err := svc.SubmitAnswer(ctx, first.LessonId, fill, models.LessonTypeFillBlanks, "nope"); err != nil {
+		t.Fatalf("first answer: %v", err)
+	}
+	if got := repos.Lessons.Lessons[0]; got.Status != models.LessonStatusInProgress || got.StartedAt.IsZero() {
+		t.Fatalf("after one answer: %q started %s, want in_progress", got.Status, got.StartedAt)
+	}
+	for i, item := range first.Items {
+		if item.Type == models.LessonTypeFillBlanks && i != fill {
+			if _, err := svc.SubmitAnswer(ctx, first.LessonId, i, item.Type, item.CorrectWord); err != nil {
+				t.Fatalf("answer %d: %v", i, err)
+			}
+		}
+	}
+	if got := repos.Lessons.Lessons[0]; got.Status != models.LessonStatusCompleted || got.CompletedAt.IsZero() {
+		t.Fatalf("after every answer: %q, want completed", got.Status)
+	}
+	if err := svc.AbandonLesson(ctx, first.LessonId); !errors.Is(err, ErrConflict) {
+		t.Errorf("abandoning a completed lesson = %v, want a conflict", err)
+	}
+
+	second, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId})
+	if err != nil {
+		t.Fatalf("second lesson: %v", err)
+	}
+	if n, err := svc.ExpireStaleLessons(ctx, time.Hour); err != nil || n != 0 {
+		t.Fatalf("sweep of a fresh lesson = %d, %v; want 0", n, err)
+	}
+	repos.Lessons.Lessons[1].CreatedAt = svc.now().Add(-2 * time.Hour)
+	if n, err := svc.ExpireStaleLessons(ctx, time.Hour); err != nil || n != 1 {
+		t.Fatalf("sweep of an idle lesson = %d, %v; want 1", n, err)
+	}
+	stale := repos.Lessons.Lessons[1]
+	if stale.Id != second.LessonId || stale.Status != models.LessonStatusExpired || !stale.ExpiredAt.Equal(svc.now()) {
+		t.Errorf("idle lesson %s is %q expired at %s, want expired at %s", stale.Id, stale.Status, stale.ExpiredAt, svc.now())
+	}
+	if _, err := svc.SubmitAnswer(ctx, second.LessonId, fill, models.LessonTypeFillBlanks, "late"); !errors.Is(err, ErrConflict) {
+		t.Errorf("answering an expired lesson = %v, want a conflict", err)
+	}
+}
+
+// failingProgressRepo loses every progress read, as an unreachable database
+// would.
+type failingProgressRepo struct {
+	repositories.ProgressRepoIface
+}
+
+func (failingProgressRepo) Find(context.Context, string) (*models.UserProgress, error) {
+	return nil, repositories.ErrFindOneFailed
+}
+
+// TestCompleteLessonReportsXPOnce checks that completing a lesson reports its
+// bonus in a single xp event matching the XP applied, and none when the award
+// fails.
+func TestCompleteLessonReportsXPOnce(t *testing.T) {
+	ctx := context.Background()
+	for _, awardFails := range []bool{false, true} {
+		svc, repos := newTestLessonService(testSong("a"))
+		if awardFails {
+			svc.progressRepo = failingProgressRepo{}
+		}
+		lesson, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		reported, completed := 0, 0
+		for i, item := range lesson.Items {
+			if item.Type != models.LessonTypeFillBlanks {
+				continue
+			}
+			resp, err := svc.SubmitAnswer(ctx, lesson.LessonId, i, item.Type, item.CorrectWord)
+			if err != nil {
+				t.Fatalf("answer %d: %v", i, err)
+			}
+			for _, e := range resp.Events {
+				switch e.Type {
+				case models.ProgressEventXP:
+					reported += e.XP
+				case models.ProgressEventLessonCompleted:
+					completed++
+					if e.XP != 0 {
+						t.Errorf("lesson_completed carries %d XP, want none", e.XP)
+					}
+				}
+			}
+		}
+		if completed != 1 {
+			t.Errorf("award fails=%v: %d lesson_completed events, want 1", awardFails, completed)
+		}
+		applied := 0
+		if p := repos.Progress.Progress[testUserId]; p != nil {
+			applied = p.TotalXP
+		}
+		if reported != applied || (applied == 0) != awardFails {
+			t.Errorf("award fails=%v: reported %d XP, applied %d", awardFails, reported, applied)
+		}
+	}
+}
+
+// TestLessonWithoutFillBlanks checks that no lesson is built from a song
+// without a line to quiz on, and that a lesson stored with arrange items only
+// completes on an arrange answer instead of staying open.
+func TestLessonWithoutFillBlanks(t *testing.T) {
+	ctx := context.Background()
+	song := &models.Song{Id: "shouts", Lyrics: [][]string{{"Hey"}, {"Oh"}, {"Baby"}}}
+	svc, repos := newTestLessonService(song)
+
+	if _, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId}); !errors.Is(err, ErrValidation) {
+		t.Fatalf("CreateLesson = %v, want a validation error", err)
+	}
+
+	repos.Lessons.Lessons = append(repos.Lessons.Lessons, &models.Lesson{
+		Id: "legacy", UserId: testUserId, SongId: song.Id, Status: models.LessonStatusCreated,
+		Items: []models.LessonItem{
+			{Type: models.LessonTypeArrange, LineIndex: 0, Words: []string{"Hey"}},
+			{Type: models.LessonTypeArrange, LineIndex: 1, Words: []string{"Oh"}},
+		},
+	})
+	resp, err := svc.SubmitAnswer(ctx, "legacy", 0, string(models.LessonTypeArrange), "")
+	if err != nil {
+		t.Fatalf("answer: %v", err)
+	}
+	if !slices.ContainsFunc(resp.Events, func(e contracts.ProgressEvent) bool { return e.Type == models.ProgressEventLessonCompleted }) {
+		t.Errorf("events = %+v, want lesson_completed", resp.Events)
+	}
+	if got := repos.Lessons.Lessons[0].Status; got != models.LessonStatusCompleted {
+		t.Errorf("status = %q, want completed", got)
+	}
+}
+
+// TestChallengeLessonLifecycle checks that a challenge lesson is only
+// closed by its player: practice lessons and the expiry sweep leave it open, and
+// abandoning it forfeits the challenge.
+func TestChallengeLessonLifecycle(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"))
+	repos.Users.Create(ctx, &models.User{Id: "rival", Name: "Rival"})
+	repos.Challenges.Create(ctx, &models.Challenge{
+		Id: "c1", ChallengerId: testUserId, OpponentId: "rival", SongId: "a", Seed: 7,
+		Status: models.ChallengeStatusAccepted,
+	})
+
+	first, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, ChallengeId: "c1"})
+	if err != nil {
+		t.Fatalf("challenge lesson: %v", err)
+	}
+	again, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, ChallengeId: "c1"})
+	if err != nil || !again.Resumed || again.LessonId != first.LessonId {
+		t.Fatalf("second start = %+v, %v; want the first lesson resumed", again, err)
+	}
+	if n := len(repos.Lessons.Lessons); n != 1 {
+		t.Fatalf("stored %d lessons, want 1", n)
+	}
+
+	if _, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId}); err != nil {
+		t.Fatalf("practice lesson beside a challenge: %v", err)
+	}
+	if _, err := svc.ExpireStaleLessons(ctx, -time.Hour); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	challengeLesson, practice := repos.Lessons.Lessons[0], repos.Lessons.Lessons[1]
+	if challengeLesson.Status != models.LessonStatusCreated || practice.Status != models.LessonStatusExpired {
+		t.Fatalf("after the sweep: challenge lesson %q, practice %q; want created, expired", challengeLesson.Status, practice.Status)
+	}
+	if want := svc.now(); !practice.ExpiredAt.Equal(want) {
+		t.Errorf("expired at %s, want the service clock's %s", practice.ExpiredAt, want)
+	}
+
+	repos.Challenges.Challenges[0].Opponent = models.ChallengeEntry{LessonId: "rival-lesson", Finished: true, Accuracy: 10}
+	if err := svc.AbandonLesson(ctx, first.LessonId); err != nil {
+		t.Fatalf("abandon: %v", err)
+	}
+	c := repos.Challenges.Challenges[0]
+	if !c.Challenger.Forfeited || c.Status != models.ChallengeStatusCompleted || c.WinnerId != "rival" {
+		t.Errorf("challenge = %+v, want completed and won by rival after the forfeit", c)
+	}
+	if _, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, ChallengeId: "c1"}); !errors.Is(err, ErrConflict) {
+		t.Errorf("restart after forfeit = %v, want a conflict", err)
+	}
+}
+
+// TestChallengeLessonsMatch checks that both players of a challenge get the
+// same items from its seed and that the better score wins.
+func TestChallengeLessonsMatch(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"))
+	repos.Users.Create(ctx, &models.User{Id: "rival", Name: "Rival"})
+	challenges := NewChallengeService(repos.Challenges, repos.Users, repos.Songs, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	created, err := challenges.CreateChallenge(ctx, contracts.CreateChallengeDto{ChallengerId: testUserId, OpponentId: "rival", SongId: "a"})
+	if err != nil {
+		t.Fatalf("CreateChallenge: %v", err)
+	}
+	mine, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, ChallengeId: created.Id})
+	if err != nil {
+		t.Fatalf("challenger lesson: %v", err)
+	}
+	if _, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: "rival", ChallengeId: created.Id}); !errors.Is(err, ErrChallengeState) {
+		t.Fatalf("opponent playing before accepting = %v, want ErrChallengeState", err)
+	}
+	if _, err := challenges.Accept(ctx, created.Id, contracts.ChallengeActionDto{UserId: "rival"}); err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	theirs, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: "rival", ChallengeId: created.Id})
+	if err != nil {
+		t.Fatalf("opponent lesson: %v", err)
+	}
+	if !reflect.DeepEqual(mine.Items, theirs.Items) {
+		t.Fatal("players of one challenge got different items")
+	}
+
+	answerAll(t, svc, mine)
+	for i, item := range theirs.Items {
+		if item.Type == models.LessonTypeFillBlanks {
+			if _, err := svc.SubmitAnswer(ctx, theirs.LessonId, i, item.Type, "wrong"); err != nil {
+				t.Fatalf("answer %d: %v", i, err)
+			}
+		}
+	}
+	result, err := challenges.GetResult(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("GetResult: %v", err)
+	}
+	if result.Status != string(models.ChallengeStatusCompleted) || result.WinnerId != testUserId {
+		t.Errorf("result = %+v, want completed and won by the challenger", result)
+	}
+}
+
+// TestSeededLessonsRepeat checks that a lesson's seed decides its song and
+// items, and that the seed is stored on the lesson.
+func TestSeededLessonsRepeat(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(testSong("a"), testSong("b"), testSong("c"))
+	seed := int64(99)
+
+	first, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, Seed: &seed})
+	if err != nil {
+		t.Fatalf("first lesson: %v", err)
+	}
+	again, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, Seed: &seed, Restart: true})
+	if err != nil {
+		t.Fatalf("second lesson: %v", err)
+	}
+	a, b := repos.Lessons.Lessons[0], repos.Lessons.Lessons[1]
+	if a.SongId != b.SongId || !reflect.DeepEqual(first.Items, again.Items) {
+		t.Errorf("seed %d gave %s and %s with different items", seed, a.SongId, b.SongId)
+	}
+	if a.Seed == nil || *a.Seed != seed {
+		t.Errorf("stored seed = %v, want %d", a.Seed, seed)
+	}
+}
+
+func TestSubmitAnswerTypeMustMatchItem(t *testing.T) {
+	ctx := context.Background()
+	svc, repos := newTestLessonService(loadSong(t, "short"))
+
+	seed := int64(7)
+	lesson, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, Seed: &seed})
+	if err != nil {
+		t.Fatalf("CreateLesson: %v", err)
+	}
+	for i, item := range lesson.Items {
+		wrong := string(models.LessonTypeArrange)
+		if item.Type == wrong {
+			wrong = string(models.LessonTypeFillBlanks)
+		}
+		if _, err := svc.SubmitAnswer(ctx, lesson.LessonId, i, wrong, ""); !errors.Is(err, ErrValidation) {
+			t.Errorf("item %d answered as %s: err = %v, want a validation error", i, wrong, err)
+		}
+	}
+	if got := repos.Lessons.Lessons[0]; len(got.Answers) != 0 || got.Status != models.LessonStatusCreated {
+		t.Errorf("lesson = %d answers, status %q; want untouched", len(got.Answers), got.Status)
+	}
+}
+
+// TestChallengeLessonKeepsStoredSize checks that a challenge lesson is
+// generated with the size stored on the challenge, not the service's current
+// one, so both players get the same items across a LESSON_SIZE change.
+func TestChallengeLessonKeepsStoredSize(t *testing.T) {
+	ctx := context.Background()
+	song := loadSong(t, "huge")
+	svc, repos := newTestLessonService(song)
+	svc.WithLessonSize(10)
+	repos.Users.Create(ctx, &models.User{Id: "rival", Name: "Rival"})
+	repos.Challenges.Create(ctx, &models.Challenge{
+		Id: "c1", ChallengerId: testUserId, OpponentId: "rival", SongId: song.Id, Seed: 7, LessonSize: 3,
+		Status: models.ChallengeStatusAccepted,
+	})
+
+	resp, err := svc.CreateLesson(ctx, contracts.CreateLessonDto{UserId: testUserId, ChallengeId: "c1"})
+	if err != nil {
+		t.Fatalf("challenge lesson: %v", err)
+	}
+	if stored := repos.Lessons.Lessons[0]; stored.Size != 3 {
+		t.Errorf("stored size = %d, want the challenge's 3", stored.Size)
+	}
+	got, _ := json.Marshal(resp.Items)
+	want, _ := json.Marshal(utils.ToContractItems(GenerateItems(7, song.Lyrics, 3)))
+	if !bytes.Equal(got, want) {
+		t.Errorf("items = %s, want those of size 3: %s", got, want)
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+type ProgressService struct {
+	userRepo     repositories.UserRepoIface
+	progressRepo repositories.ProgressRepoIface
+	logger       *slog.Logger
+}
+
+func NewProgressService(
+	userRepo repositories.UserRepoIface,
+	progressRepo repositories.ProgressRepoIface,
+	logger *slog.Logger,
+) *ProgressService {
+	return &ProgressService{
+		userRepo:     userRepo,
+		progressRepo: progressRepo,
+		logger:       logger,
+	}
+}
+
+func (svc *ProgressService) GetProgress(
+	ctx context.Context,
+	userId string,
+) (*contracts.UserProgressResponse, error) {
+	if _, err := svc.userRepo.FindOne(ctx, userId); err != nil {
+		return nil, missing(err, "user with id=%s was not found", userId)
+	}
+	progress, err := svc.progressRepo.Find(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		progress = models.NewUserProgress(userId)
+	}
+	return toProgressResponse(progress, time.Now().UTC()), nil
+}
+
+// UpdateSettings changes a user's timezone and daily goal. The new timezone
+// applies from the next XP award on.
+func (svc *ProgressService) UpdateSettings(
+	ctx context.Context,
+	userId string,
+	dto contracts.UpdateProgressSettingsDto,
+) (*contracts.UserProgressResponse, error) {
+	if dto.Timezone != "" {
+		if _, err := time.LoadLocation(dto.Timezone); err != nil {
+			return nil, invalid("timezone", fmt.Sprintf("unknown timezone %q", dto.Timezone))
+		}
+	}
+	if dto.DailyGoalXP < 0 {
+		return nil, invalid("dailyGoalXp", "must not be negative")
+	}
+	if _, err := svc.userRepo.FindOne(ctx, userId); err != nil {
+		return nil, missing(err, "user with id=%s was not found", userId)
+	}
+
+	progress, _, err := updateProgress(ctx, svc.progressRepo, userId, func(p *models.UserProgress) []models.ProgressEvent {
+		if dto.Timezone != "" {
+			p.Timezone = dto.Timezone
+		}
+		if dto.DailyGoalXP > 0 {
+			p.DailyGoalXP = dto.DailyGoalXP
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toProgressResponse(progress, time.Now().UTC()), nil
+}
+
+const progressSaveAttempts = 5
+
+var errProgressContention = newError(ErrConflict, "progress was updated concurrently too many times")
+
+// updateProgress applies fn to a user's progress and saves it, re-reading and
+// retrying when another request saved in between.
+func updateProgress(
+	ctx context.Context,
+	repo repositories.ProgressRepoIface,
+	userId string,
+	fn func(p *models.UserProgress) []models.ProgressEvent,
+) (*models.UserProgress, []models.ProgressEvent, error) {
+	for range progressSaveAttempts {
+		progress, err := repo.Find(ctx, userId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if progress == nil {
+			progress = models.NewUserProgress(userId)
+		}
+		events := fn(progress)
+		saved, err := repo.Save(ctx, progress)
+		if err != nil {
+			return nil, nil, err
+		}
+		if saved {
+			return progress, events, nil
+		}
+	}
+	return nil, nil, errProgressContention
+}
+
+func toProgressResponse(p *models.UserProgress, now time.Time) *contracts.UserProgressResponse {
+	todayXP := p.XPOn(now)
+	return &contracts.UserProgressResponse{
+		UserId:        p.Id,
+		TotalXP:       p.TotalXP,
+		Timezone:      p.Timezone,
+		DailyGoalXP:   p.DailyGoalXP,
+		TodayXP:       todayXP,
+		DailyGoalMet:  p.LastGoalDay == now.In(p.Location()).Format(time.DateOnly),
+		Streak:        p.StreakOn(now),
+		LongestStreak: p.LongestStreak,
+	}
+}
+
+func toContractEvents(events []models.ProgressEvent) []contracts.ProgressEvent {
+	out := make([]contracts.ProgressEvent, 0, len(events))
+	for _, e := range events {
+		out = append(out, contracts.ProgressEvent{Type: e.Type, XP: e.XP, Streak: e.Streak})
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories"
+)
+
+// ErrRateLimited is the kind of error returned when a client exceeds its
+// rate limit.
+var ErrRateLimited = errors.New("rate limited")
+
+type RateLimitService struct {
+	repo   repositories.RateLimitRepoIface
+	limits map[string]models.RateLimit
+	clock  Clock
+	logger *slog.Logger
+}
+
+// NewRateLimitService limits each route group in limits; groups without a
+// limit are not limited.
+func NewRateLimitService(
+	repo repositories.RateLimitRepoIface,
+	limits map[string]models.RateLimit,
+	logger *slog.Logger,
+) *RateLimitService {
+	return &RateLimitService{
+		repo:   repo,
+		limits: limits,
+		clock:  time.Now,
+		logger: logger,
+	}
+}
+
+// WithClock replaces the clock refilling the buckets.
+func (svc *RateLimitService) WithClock(clock Clock) *RateLimitService {
+	svc.clock = clock
+	return svc
+}
+
+// Limit returns the limit of a route group, if it has one.
+func (svc *RateLimitService) Limit(group string) (models.RateLimit, bool) {
+	limit, ok := svc.limits[group]
+	return limit, ok
+}
+
+// Take spends one request of client's allowance in group. When the store
+// fails the request is let through: losing rate limiting for a while is
+// better than failing every request.
+func (svc *RateLimitService) Take(ctx context.Context, group, client string) models.RateDecision {
+	limit, ok := svc.limits[group]
+	if !ok {
+		return models.RateDecision{Allowed: true}
+	}
+	d, err := svc.repo.Take(ctx, group+":"+client, limit, svc.clock())
+	if err != nil {
+		svc.logger.WarnContext(ctx, "rate limit store failed, allowing request", "group", group, "err", err)
+		return models.RateDecision{Allowed: true, Remaining: limit.Burst}
+	}
+	return d
+}
@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.tomerab1/todo-api/internal/contracts"
 	"github.tomerab1/todo-api/internal/models"
@@ -29,12 +31,22 @@ func (svc *SongService) CreateSong(
 	ctx context.Context,
 	createSongDto contracts.CreateSongDto,
 ) (*contracts.CreateSongsReponse, error) {
+	if err := validateAdminDifficulty(createSongDto.Difficulty); err != nil {
+		return nil, err
+	}
+
+	lyrics := utils.LyricsToSlices(createSongDto.Lyrics)
 	song, err := svc.songRepo.Create(
 		ctx,
 		&models.Song{
-			Title:  createSongDto.Title,
-			Artist: createSongDto.Artist,
-			Lyrics: utils.LyricsToSlices(createSongDto.Lyrics),
+			Title:              createSongDto.Title,
+			Artist:             createSongDto.Artist,
+			Lyrics:             lyrics,
+			Tags:               utils.UniqueLower(createSongDto.Tags),
+			Genres:             utils.UniqueLower(createSongDto.Genres),
+			Language:           strings.ToLower(strings.TrimSpace(createSongDto.Language)),
+			AdminDifficulty:    createSongDto.Difficulty,
+			ComputedDifficulty: utils.ComputeDifficulty(lyrics),
 		},
 	)
 
@@ -43,26 +55,90 @@ func (svc *SongService) CreateSong(
 	}
 
 	return &contracts.CreateSongsReponse{
-		Id:        song.Id,
-		LineCount: len(song.Lyrics),
+		Id:         song.Id,
+		LineCount:  len(song.Lyrics),
+		Difficulty: song.Difficulty,
 	}, nil
 }
 
-func (svc *SongService) GetAllSongs(
+// UpdateMetadata replaces the admin-curated tags, genres, language and
+// difficulty override of a song.
+func (svc *SongService) UpdateMetadata(
 	ctx context.Context,
-) ([]contracts.GetSongResponse, error) {
-	songs, err := svc.songRepo.FindAll(ctx)
+	songId string,
+	dto contracts.UpdateSongMetadataDto,
+) (*contracts.SongMetadataResponse, error) {
+	if err := validateAdminDifficulty(dto.Difficulty); err != nil {
+		return nil, err
+	}
+
+	song, err := svc.songRepo.UpdateMetadata(ctx, songId, repositories.SongMetadata{
+		Tags:            utils.UniqueLower(dto.Tags),
+		Genres:          utils.UniqueLower(dto.Genres),
+		Language:        strings.ToLower(strings.TrimSpace(dto.Language)),
+		AdminDifficulty: dto.Difficulty,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	resp := make([]contracts.GetSongResponse, 0)
+	return &contracts.SongMetadataResponse{
+		Id:                 song.Id,
+		Tags:               song.Tags,
+		Genres:             song.Genres,
+		Language:           song.Language,
+		AdminDifficulty:    song.AdminDifficulty,
+		ComputedDifficulty: song.ComputedDifficulty,
+		Difficulty:         song.Difficulty,
+	}, nil
+}
+
+// ListSongs returns one page of songs and the cursor of the next page.
+func (svc *SongService) ListSongs(
+	ctx context.Context,
+	query contracts.ListSongsQuery,
+) ([]contracts.GetSongResponse, string, error) {
+	filter := repositories.SongFilter{
+		Query:    query.Query,
+		Artist:   query.Artist,
+		Tag:      query.Tag,
+		Genre:    query.Genre,
+		Language: query.Language,
+		MinLines: query.MinLines,
+		MaxLines: query.MaxLines,
+		Sort:     query.Sort,
+		Cursor:   query.Cursor,
+		Limit:    query.Limit,
+	}
+	if query.Difficulty != "" {
+		lo, hi, ok := models.DifficultyRange(query.Difficulty)
+		if !ok {
+			return nil, "", invalid("difficulty", fmt.Sprintf("unknown band %q", query.Difficulty))
+		}
+		filter.MinDifficulty, filter.MaxDifficulty = lo, hi
+	}
+
+	songs, next, err := svc.songRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp := make([]contracts.GetSongResponse, 0, len(songs))
 	for _, song := range songs {
 		resp = append(resp, contracts.GetSongResponse{
-			Id:    song.Id,
-			Title: song.Title,
+			Id:         song.Id,
+			Title:      song.Title,
+			Tags:       song.Tags,
+			Difficulty: song.Difficulty,
 		})
 	}
 
-	return resp, nil
+	return resp, next, nil
+}
+
+func validateAdminDifficulty(d int) error {
+	if d != 0 && (d < models.MinDifficulty || d > models.MaxDifficulty) {
+		return invalid("difficulty", fmt.Sprintf("must be between %d and %d", models.MinDifficulty, models.MaxDifficulty))
+	}
+	return nil
 }
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/repositories"
+	"github.tomerab1/todo-api/internal/utils"
+)
+
+type StatsService struct {
+	userRepo    repositories.UserRepoIface
+	songRepo    repositories.SongRepoIface
+	lessonRepo  repositories.LessonRepoIface
+	masteryRepo repositories.WordMasteryRepoIface
+	clock       Clock
+	logger      *slog.Logger
+}
+
+func NewStatsService(
+	userRepo repositories.UserRepoIface,
+	songRepo repositories.SongRepoIface,
+	lessonRepo repositories.LessonRepoIface,
+	masteryRepo repositories.WordMasteryRepoIface,
+	logger *slog.Logger,
+) *StatsService {
+	return &StatsService{
+		userRepo:    userRepo,
+		songRepo:    songRepo,
+		lessonRepo:  lessonRepo,
+		masteryRepo: masteryRepo,
+		clock:       time.Now,
+		logger:      logger,
+	}
+}
+
+// WithClock replaces the clock that word mastery scores are decayed to.
+func (svc *StatsService) WithClock(clock Clock) *StatsService {
+	svc.clock = clock
+	return svc
+}
+
+func (svc *StatsService) now() time.Time {
+	return svc.clock().UTC()
+}
+
+// mostMissedLimit is how many words GetUserStats lists as most missed.
+const mostMissedLimit = 10
+
+// GetUserStats aggregates all lessons of a user. Accuracy here is the share
+// of correct fillblanks answers, unlike the lesson summary which also counts
+// arrange items as correct.
+func (svc *StatsService) GetUserStats(
+	ctx context.Context,
+	userId string,
+	timezone string,
+) (*contracts.UserStatsResponse, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, invalid("tz", fmt.Sprintf("unknown timezone %q", timezone))
+	}
+	if _, err := svc.userRepo.FindOne(ctx, userId); err != nil {
+		return nil, missing(err, "user with id=%s was not found", userId)
+	}
+
+	stats, err := svc.lessonRepo.UserStats(ctx, userId, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &contracts.UserStatsResponse{
+		UserId:           userId,
+		Lessons:          stats.Totals.Lessons,
+		Answers:          stats.Totals.Answers,
+		Correct:          stats.Totals.Correct,
+		Wrong:            stats.Totals.Answers - stats.Totals.Correct,
+		Accuracy:         percent(stats.Totals.Correct, stats.Totals.Answers),
+		TimeSpentSeconds: stats.Totals.TimeSpentMs / 1000,
+		Daily:            make([]contracts.DailyStats, 0, len(stats.Daily)),
+		Songs:            make([]contracts.SongStats, 0, len(stats.Songs)),
+		MostMissedWords:  mostMissed(stats.MissedWord),
+	}
+	for _, d := range stats.Daily {
+		resp.Daily = append(resp.Daily, contracts.DailyStats{
+			Date:             d.Key,
+			Lessons:          d.Lessons,
+			Answers:          d.Answers,
+			Correct:          d.Correct,
+			Accuracy:         percent(d.Correct, d.Answers),
+			TimeSpentSeconds: d.TimeSpentMs / 1000,
+		})
+	}
+	for _, s := range stats.Songs {
+		resp.Songs = append(resp.Songs, contracts.SongStats{
+			SongId:   s.Key,
+			Lessons:  s.Lessons,
+			Answers:  s.Answers,
+			Correct:  s.Correct,
+			Accuracy: percent(s.Correct, s.Answers),
+		})
+	}
+	return resp, nil
+}
+
+// mostMissed folds missed words with utils.NormalizeWord, the form word
+// mastery is keyed by, so "Try," and "try" count as one word in both places.
+func mostMissed(missed []repositories.WordCount) []contracts.WordCount {
+	counts := make(map[string]int, len(missed))
+	for _, w := range missed {
+		counts[utils.NormalizeWord(w.Word)] += w.Count
+	}
+	out := make([]contracts.WordCount, 0, len(counts))
+	for word, count := range counts {
+		out = append(out, contracts.WordCount{Word: word, Count: count})
+	}
+	slices.SortFunc(out, func(a, b contracts.WordCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Word, b.Word))
+	})
+	if len(out) > mostMissedLimit {
+		out = out[:mostMissedLimit]
+	}
+	return out
+}
+
+// GetUserWords lists the word mastery table of a user. sort is "mastery"
+// (weakest first, the default), "-mastery", "word" or "lastSeen" (most recent
+// first); limit 0 returns every word.
+func (svc *StatsService) GetUserWords(
+	ctx context.Context,
+	userId string,
+	sortBy string,
+	limit int,
+) ([]contracts.WordMasteryResponse, error) {
+	if _, err := svc.userRepo.FindOne(ctx, userId); err != nil {
+		return nil, missing(err, "user with id=%s was not found", userId)
+	}
+	rows, err := svc.masteryRepo.FindByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := svc.now()
+	resp := make([]contracts.WordMasteryResponse, 0, len(rows))
+	for _, m := range rows {
+		resp = append(resp, contracts.WordMasteryResponse{
+			Word:         m.Word,
+			SeenCount:    m.SeenCount,
+			CorrectCount: m.CorrectCount,
+			LastSeenAt:   m.LastSeenAt,
+			Mastery:      m.ScoreAt(now),
+			SongIds:      m.SongIds,
+		})
+	}
+
+	// scores decay with time, so ordering by mastery happens here rather
+	// than on the stored value
+	var order func(a, b contracts.WordMasteryResponse) int
+	switch sortBy {
+	case "", "mastery":
+		order = func(a, b contracts.WordMasteryResponse) int { return cmp.Compare(a.Mastery, b.Mastery) }
+	case "-mastery":
+		order = func(a, b contracts.WordMasteryResponse) int { return cmp.Compare(b.Mastery, a.Mastery) }
+	case "word":
+		order = func(a, b contracts.WordMasteryResponse) int { return strings.Compare(a.Word, b.Word) }
+	case "lastSeen":
+		order = func(a, b contracts.WordMasteryResponse) int { return b.LastSeenAt.Compare(a.LastSeenAt) }
+	default:
+		return nil, invalid("sort", fmt.Sprintf("unknown sort %q", sortBy))
+	}
+	slices.SortStableFunc(resp, order)
+
+	if limit > 0 && len(resp) > limit {
+		resp = resp[:limit]
+	}
+	return resp, nil
+}
+
+const (
+	wrongChoicesPerBlank = 3
+	topDistractorsLimit  = 10
+)
+
+// GetSongAnalytics shows admins where learners struggle on a song: error
+// rates per line and blanked word, and the wrong options picked most often.
+func (svc *StatsService) GetSongAnalytics(
+	ctx context.Context,
+	songId string,
+) (*contracts.SongAnalyticsResponse, error) {
+	if _, err := svc.songRepo.FindById(ctx, songId); err != nil {
+		return nil, missing(err, "song with id=%s was not found", songId)
+	}
+	rows, err := svc.lessonRepo.SongAnswerBreakdown(ctx, songId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &contracts.SongAnalyticsResponse{
+		SongId:         songId,
+		Lines:          make([]contracts.LineAnalytics, 0),
+		TopDistractors: make([]contracts.WordCount, 0),
+	}
+	distractors := make(map[string]int)
+	// rows come sorted by line, then word, then count descending
+	for _, row := range rows {
+		n := len(resp.Lines)
+		if n == 0 || resp.Lines[n-1].LineIndex != row.LineIndex {
+			resp.Lines = append(resp.Lines, contracts.LineAnalytics{LineIndex: row.LineIndex})
+			n++
+		}
+		line := &resp.Lines[n-1]
+		b := len(line.Blanks)
+		if b == 0 || line.Blanks[b-1].Word != row.Word {
+			line.Blanks = append(line.Blanks, contracts.BlankAnalytics{Word: row.Word, WrongChoices: make([]contracts.WordCount, 0)})
+			b++
+		}
+		blank := &line.Blanks[b-1]
+
+		blank.Attempts += row.Count
+		line.Attempts += row.Count
+		resp.Attempts += row.Count
+		if row.Correct {
+			continue
+		}
+		blank.Errors += row.Count
+		line.Errors += row.Count
+		resp.Errors += row.Count
+		distractors[row.Choice] += row.Count
+		if len(blank.WrongChoices) < wrongChoicesPerBlank {
+			blank.WrongChoices = append(blank.WrongChoices, contracts.WordCount{Word: row.Choice, Count: row.Count})
+		}
+	}
+
+	resp.ErrorRate = percent(resp.Errors, resp.Attempts)
+	for i := range resp.Lines {
+		line := &resp.Lines[i]
+		line.ErrorRate = percent(line.Errors, line.Attempts)
+		for j := range line.Blanks {
+			line.Blanks[j].ErrorRate = percent(line.Blanks[j].Errors, line.Blanks[j].Attempts)
+		}
+	}
+	slices.SortStableFunc(resp.Lines, func(a, b contracts.LineAnalytics) int {
+		return cmp.Compare(b.ErrorRate, a.ErrorRate)
+	})
+
+	for word, count := range distractors {
+		resp.TopDistractors = append(resp.TopDistractors, contracts.WordCount{Word: word, Count: count})
+	}
+	slices.SortFunc(resp.TopDistractors, func(a, b contracts.WordCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Word, b.Word)
+	})
+	if len(resp.TopDistractors) > topDistractorsLimit {
+		resp.TopDistractors = resp.TopDistractors[:topDistractorsLimit]
+	}
+	return resp, nil
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.tomerab1/todo-api/internal/contracts"
+	"github.tomerab1/todo-api/internal/models"
+	"github.tomerab1/todo-api/internal/repositories/repotest"
+)
+
+func newTestStatsService(now time.Time) (*StatsService, *repotest.Repos) {
+	repos := repotest.New()
+	repos.Users.Create(context.Background(), &models.User{Id: testUserId, Name: "Test"})
+	svc := NewStatsService(repos.Users, repos.Songs, repos.Lessons, repos.Mastery, slog.New(slog.NewTextHandler(io.Discard, nil))).
+		WithClock(func() time.Time { return now })
+	return svc, repos
+}
+
+// answeredLesson stores a lesson with one fillblanks item per answer, the
+// expected word of each given by words.
+func answeredLesson(repos *repotest.Repos, id, songId string, createdAt time.Time, words []string, correct ...bool) {
+	l := &models.Lesson{Id: id, UserId: testUserId, SongId: songId, CreatedAt: createdAt}
+	for i, w := range words {
+		l.Items = append(l.Items, models.LessonItem{Type: models.LessonTypeFillBlanks, LineIndex: i, CorrectWord: w})
+		l.Answers = append(l.Answers, models.LessonAnswer{ItemIndex: i, Type: models.LessonTypeFillBlanks, Correct: correct[i]})
+	}
+	repos.Lessons.Lessons = append(repos.Lessons.Lessons, l)
+}
+
+func TestUserStats(t *testing.T) {
+	svc, repos := newTestStatsService(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	// 23:30 UTC is already the next day in Europe/Berlin
+	answeredLesson(repos, "l1", "s1", time.Date(2025, 1, 1, 23, 30, 0, 0, time.UTC), []string{"a", "b"}, true, false)
+	answeredLesson(repos, "l2", "s2", time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC), []string{"c", "d", "e"}, true, true, true)
+
+	stats, err := svc.GetUserStats(context.Background(), testUserId, "Europe/Berlin")
+	if err != nil {
+		t.Fatalf("GetUserStats: %v", err)
+	}
+	if stats.Lessons != 2 || stats.Answers != 5 || stats.Correct != 4 || stats.Wrong != 1 || stats.Accuracy != 80 {
+		t.Errorf("totals = %+v", stats)
+	}
+	if len(stats.Daily) != 1 || stats.Daily[0].Date != "2025-01-02" || stats.Daily[0].Lessons != 2 {
+		t.Errorf("daily = %+v, want both lessons on 2025-01-02", stats.Daily)
+	}
+	if len(stats.Songs) != 2 || stats.Songs[0].SongId != "s2" || stats.Songs[1].Accuracy != 50 {
+		t.Errorf("songs = %+v, want s2 first and s1 at 50%%", stats.Songs)
+	}
+
+	if _, err := svc.GetUserStats(context.Background(), testUserId, "Mars/Olympus"); !errors.Is(err, ErrValidation) {
+		t.Errorf("unknown timezone = %v, want a validation error", err)
+	}
+}
+
+// TestMostMissedWordsMatchMastery checks that missed words are folded the way
+// word mastery keys them, punctuation and case included.
+func TestMostMissedWordsMatchMastery(t *testing.T) {
+	svc, repos := newTestStatsService(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	answeredLesson(repos, "l1", "s1", time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), []string{"Try,", "try", "(Love)", "stay"}, false, false, false, true)
+
+	stats, err := svc.GetUserStats(context.Background(), testUserId, "")
+	if err != nil {
+		t.Fatalf("GetUserStats: %v", err)
+	}
+	want := []contracts.WordCount{{Word: "try", Count: 2}, {Word: "love", Count: 1}}
+	if !slices.Equal(stats.MostMissedWords, want) {
+		t.Errorf("most missed = %+v, want %+v", stats.MostMissedWords, want)
+	}
+}
+
+// TestUserWordsDecayToClock checks that mastery scores are decayed to the
+// service's clock.
+func TestUserWordsDecayToClock(t *testing.T) {
+	now := time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
+	svc, repos := newTestStatsService(now)
+	// one correct answer scores 0.3, halved a half-life later
+	if err := repos.Mastery.Record(context.Background(), testUserId, "try", "s1", true, now.Add(-models.MasteryHalfLife)); err != nil {
+		t.Fatal(err)
+	}
+
+	words, err := svc.GetUserWords(context.Background(), testUserId, "", 0)
+	if err != nil {
+		t.Fatalf("GetUserWords: %v", err)
+	}
+	if len(words) != 1 || math.Abs(words[0].Mastery-0.15) > 1e-9 {
+		t.Errorf("words = %+v, want try at mastery 0.15", words)
+	}
+}
+
+func TestSongAnalytics(t *testing.T) {
+	svc, repos := newTestStatsService(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	repos.Songs.Add(&models.Song{Id: "s1", Lyrics: [][]string{{"hold", "on"}, {"let", "go"}}})
+	answer := func(line int, word, choice string) models.LessonAnswer {
+		return models.LessonAnswer{Type: models.LessonTypeFillBlanks, LineIndex: line, ExpectedWord: word, UserInput: choice, Correct: word == choice}
+	}
+	repos.Lessons.Lessons = append(repos.Lessons.Lessons,
+		&models.Lesson{Id: "l1", UserId: testUserId, SongId: "s1", Answers: []models.LessonAnswer{
+			answer(0, "hold", "hold"), answer(1, "Go", "so"),
+		}},
+		&models.Lesson{Id: "l2", UserId: "user-2", SongId: "s1", Answers: []models.LessonAnswer{
+			answer(0, "hold", "fold"), answer(1, "go", "so"),
+		}},
+		&models.Lesson{Id: "l3", UserId: testUserId, SongId: "s2", Answers: []models.LessonAnswer{
+			answer(0, "hold", "fold"),
+		}},
+	)
+
+	got, err := svc.GetSongAnalytics(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("GetSongAnalytics: %v", err)
+	}
+	if got.Attempts != 4 || got.Errors != 3 || got.ErrorRate != 75 {
+		t.Errorf("totals = %d attempts, %d errors, %v%%, want 4, 3, 75%%", got.Attempts, got.Errors, got.ErrorRate)
+	}
+	if len(got.Lines) != 2 || got.Lines[0].LineIndex != 1 || got.Lines[0].ErrorRate != 100 {
+		t.Fatalf("lines = %+v, want line 1 first at 100%%", got.Lines)
+	}
+	blanks := got.Lines[0].Blanks
+	if len(blanks) != 1 || blanks[0].Word != "go" || !slices.Equal(blanks[0].WrongChoices, []contracts.WordCount{{Word: "so", Count: 2}}) {
+		t.Errorf("line 1 blanks = %+v, want go missed twice as so", blanks)
+	}
+	want := []contracts.WordCount{{Word: "so", Count: 2}, {Word: "fold", Count: 1}}
+	if !slices.Equal(got.TopDistractors, want) {
+		t.Errorf("top distractors = %+v, want %+v", got.TopDistractors, want)
+	}
+
+	if _, err := svc.GetSongAnalytics(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("analytics of an unknown song = %v, want ErrNotFound", err)
+	}
+}
@@ -33,21 +33,37 @@ func (svc *UserService) CreateUser(
 	})
 }
 
-func (svc *UserService) GetAllUsers(
+// ListUsers returns one page of users and the cursor of the next page.
+// Group memberships name each group's teacher, whose id the teacher-only
+// calls check, so they are left out unless showMemberships is set.
+func (svc *UserService) ListUsers(
 	ctx context.Context,
-) ([]contracts.GetUserResponse, error) {
-	users, err := svc.userRepo.FindAll(ctx)
+	query contracts.ListUsersQuery,
+	showMemberships bool,
+) ([]contracts.GetUserResponse, string, error) {
+	users, next, err := svc.userRepo.Search(ctx, repositories.UserFilter{
+		Query:  query.Query,
+		Sort:   query.Sort,
+		Cursor: query.Cursor,
+		Limit:  query.Limit,
+	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	resp := make([]contracts.GetUserResponse, 0)
+	resp := make([]contracts.GetUserResponse, 0, len(users))
 	for _, user := range users {
-		resp = append(resp, contracts.GetUserResponse{
+		u := contracts.GetUserResponse{
 			Id:   user.Id,
 			Name: user.Name,
-		})
+		}
+		if showMemberships {
+			for _, m := range user.Memberships {
+				u.Memberships = append(u.Memberships, contracts.MembershipResponse{GroupId: m.GroupId, Role: m.Role})
+			}
+		}
+		resp = append(resp, u)
 	}
 
-	return resp, nil
+	return resp, next, nil
 }
@@ -0,0 +1,134 @@
+package telemetry
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are the service's own metrics, kept in a registry of their own. A
+// nil *Metrics is valid and records nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	dbDuration      *prometheus.HistogramVec
+	dbErrors        *prometheus.CounterVec
+	lessonsCreated  prometheus.Counter
+	shortLessons    prometheus.Counter
+	answers         *prometheus.CounterVec
+	answerConflicts prometheus.Counter
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by method, route pattern and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time to serve HTTP requests, by method and route pattern.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "Time of MongoDB commands, by collection and command.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"collection", "operation"}),
+		dbErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "repository_operation_errors_total",
+			Help: "Failed MongoDB commands, by collection and command.",
+		}, []string{"collection", "operation"}),
+		lessonsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "lessons_created_total",
+			Help: "Lessons created, including retries.",
+		}),
+		shortLessons: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "lessons_short_total",
+			Help: "Lessons created with fewer items than the lesson size because their song has too few usable lines.",
+		}),
+		answers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "answers_total",
+			Help: "Answers submitted, by item type and correctness (true, false, or unknown for items the client checks).",
+		}, []string{"type", "correct"}),
+		answerConflicts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "answer_conflicts_total",
+			Help: "Answers rejected because the item was already answered.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.httpRequests, m.httpDuration, m.dbDuration, m.dbErrors,
+		m.lessonsCreated, m.shortLessons, m.answers, m.answerConflicts,
+	)
+	return m
+}
+
+// Handler serves the metrics to Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records a served HTTP request.
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+func (m *Metrics) observeCommand(collection, operation string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.dbDuration.WithLabelValues(collection, operation).Observe(d.Seconds())
+	if err != nil {
+		m.dbErrors.WithLabelValues(collection, operation).Inc()
+	}
+}
+
+// LessonCreated records a new lesson with the given number of items, out of
+// the size lessons aim for.
+func (m *Metrics) LessonCreated(items, size int) {
+	if m == nil {
+		return
+	}
+	m.lessonsCreated.Inc()
+	if items < size {
+		m.shortLessons.Inc()
+	}
+}
+
+// AnswerGraded records an accepted answer the server checked.
+func (m *Metrics) AnswerGraded(itemType string, correct bool) {
+	if m == nil {
+		return
+	}
+	m.answers.WithLabelValues(itemType, strconv.FormatBool(correct)).Inc()
+}
+
+// AnswerUngraded records an accepted answer only the client checks, such as
+// arrange items.
+func (m *Metrics) AnswerUngraded(itemType string) {
+	if m == nil {
+		return
+	}
+	m.answers.WithLabelValues(itemType, "unknown").Inc()
+}
+
+// AnswerConflict records an answer to an item that was already answered.
+func (m *Metrics) AnswerConflict() {
+	if m == nil {
+		return
+	}
+	m.answerConflicts.Inc()
+}
@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MongoMonitor returns a command monitor that wraps every MongoDB command in
+// a client span, parented to the span of the context the command runs with,
+// and records its latency and failures in m. It returns nil when there is
+// nothing to record.
+func MongoMonitor(t *Tracer, m *Metrics) *event.CommandMonitor {
+	if t == nil && m == nil {
+		return nil
+	}
+	type command struct {
+		span       trace.Span
+		collection string
+		name       string
+		start      time.Time
+	}
+	var inflight sync.Map // request id → command
+	finish := func(requestId int64, err error) {
+		v, ok := inflight.LoadAndDelete(requestId)
+		if !ok {
+			return
+		}
+		cmd := v.(command)
+		if err != nil {
+			cmd.span.RecordError(err)
+			cmd.span.SetStatus(codes.Error, err.Error())
+		}
+		cmd.span.End()
+		m.observeCommand(cmd.collection, cmd.name, time.Since(cmd.start), err)
+	}
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			cmd := command{name: e.CommandName, start: time.Now()}
+			cmd.collection, _ = e.Command.Lookup(e.CommandName).StringValueOK()
+			_, cmd.span = t.Start(ctx, "mongo "+e.CommandName, trace.SpanKindClient,
+				attribute.String("db.system", "mongodb"),
+				attribute.String("db.name", e.DatabaseName),
+				attribute.String("db.operation", e.CommandName),
+				attribute.String("db.mongodb.collection", cmd.collection),
+			)
+			inflight.Store(e.RequestID, cmd)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finish(e.RequestID, nil)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finish(e.RequestID, e.Failure)
+		},
+	}
+}
@@ -0,0 +1,33 @@
+// Package telemetry carries request ids and trace spans through contexts and
+// surfaces them in logs and traces.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type requestIdKey struct{}
+
+// WithRequestId returns a copy of ctx carrying id.
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// RequestId returns the request id carried by ctx, or "".
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// NewRequestId returns a random 16-byte id in hex.
+func NewRequestId() string {
+	return randomHex(16)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,57 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName names the spans' instrumentation scope.
+const instrumentationName = "github.tomerab1/todo-api"
+
+// Tracer starts spans and exports them in batches from a background
+// goroutine. A nil *Tracer is valid and traces nothing.
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// NewTracer exports the spans of serviceName through exporter. Export
+// failures go to the OpenTelemetry error handler.
+func NewTracer(exporter sdktrace.SpanExporter, serviceName string) *Tracer {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	return &Tracer{provider: provider, tracer: provider.Tracer(instrumentationName)}
+}
+
+// Start begins a span as a child of the span carried by ctx, if any, and
+// returns a copy of ctx carrying the new span. Call End on it when the
+// operation ends.
+func (t *Tracer) Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, noop.Span{}
+	}
+	return t.tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// Shutdown exports the spans still queued and stops the tracer.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.provider.Shutdown(ctx)
+}
+
+// Extract returns a copy of ctx whose next span continues the trace of the
+// W3C traceparent header in h. Malformed headers are ignored.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	return propagation.TraceContext{}.Extract(ctx, propagation.HeaderCarrier(h))
+}
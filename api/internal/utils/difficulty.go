@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"math"
+	"strings"
+)
+
+// commonWords holds very frequent English words. They never count as rare,
+// whatever the song.
+var commonWords = func() map[string]struct{} {
+	list := strings.Fields(`
+		a about all am an and any are as at be been but by can come could day
+		do don't down for from get go going got had has have he her here him
+		his how i i'm if in into is it it's just know let like love make me
+		more my no not now of oh on one only or our out say see she so some
+		take tell that the their them then there they this time to too up us
+		want was way we well were what when where who why will with would
+		yeah yes you you're your
+	`)
+	m := make(map[string]struct{}, len(list))
+	for _, w := range list {
+		m[w] = struct{}{}
+	}
+	return m
+}()
+
+// ComputeDifficulty scores lyrics between 1 (easiest) and 5 (hardest) from
+// word rarity, average line length and vocabulary size.
+func ComputeDifficulty(lines [][]string) float64 {
+	counts := make(map[string]int)
+	tokens := 0
+	nonEmpty := 0
+	for _, ln := range lines {
+		if len(ln) == 0 {
+			continue
+		}
+		nonEmpty++
+		for _, w := range ln {
+			lw := NormalizeWord(w)
+			if lw == "" {
+				continue
+			}
+			counts[lw]++
+			tokens++
+		}
+	}
+	if tokens == 0 {
+		return 1
+	}
+
+	// a word is rare when it is not a common word and the song does not
+	// repeat it, so the learner gets a single chance to see it
+	rare := 0
+	for w, n := range counts {
+		if _, ok := commonWords[w]; !ok && n == 1 {
+			rare++
+		}
+	}
+	rarity := float64(rare) / float64(tokens)
+	lineLen := clamp01((float64(tokens)/float64(nonEmpty) - 3) / 9)
+	vocab := clamp01((float64(len(counts)) - 20) / 180)
+
+	score := 1 + 4*(0.5*rarity+0.25*lineLen+0.25*vocab)
+	return math.Round(score*10) / 10
+}
+
+// NormalizeWord lower-cases w and strips surrounding punctuation.
+func NormalizeWord(w string) string {
+	return strings.ToLower(strings.Trim(w, ".,!?;:\"()[]{}…-"))
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
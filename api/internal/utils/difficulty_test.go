@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComputeDifficulty(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines [][]string
+		want  float64
+	}{
+		{"no words", [][]string{{}, {"..."}}, 1},
+		{"short common lines", [][]string{{"I", "love", "you"}, {"I", "love", "you!"}}, 1},
+		{"long line of rare words", [][]string{strings.Fields("zephyr quixotic labyrinth ephemeral obsidian cascade meridian solstice gossamer halcyon nebula verdant")}, 4},
+	}
+	for _, c := range cases {
+		if got := ComputeDifficulty(c.lines); got != c.want {
+			t.Errorf("%s: ComputeDifficulty = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestComputeDifficultyRepeatedWordsAreNotRare(t *testing.T) {
+	once := [][]string{{"zephyr", "quixotic", "labyrinth"}}
+	twice := [][]string{{"zephyr", "quixotic", "labyrinth"}, {"Zephyr,", "quixotic", "labyrinth"}}
+	if ComputeDifficulty(twice) >= ComputeDifficulty(once) {
+		t.Errorf("repeating a line should make it easier: %v >= %v", ComputeDifficulty(twice), ComputeDifficulty(once))
+	}
+}
+
+func TestNormalizeWord(t *testing.T) {
+	for in, want := range map[string]string{"Hello,": "hello", "(Yeah)": "yeah", "don't": "don't", "…": ""} {
+		if got := NormalizeWord(in); got != want {
+			t.Errorf("NormalizeWord(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
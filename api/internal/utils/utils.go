@@ -3,7 +3,6 @@ package utils
 import (
 	"math/rand/v2"
 	"slices"
-	"strconv"
 	"strings"
 
 	"github.tomerab1/todo-api/internal/contracts"
@@ -113,6 +112,7 @@ func ItemSignature(it models.LessonItem) string {
 	if it.Type == models.LessonTypeFillBlanks {
 		return "F:" + it.RenderedLine
 	}
-	// arrange uniqueness by type + line index
-	return "A:" + strconv.Itoa(it.LineIndex)
+	// arrange uniqueness by type + words: repeated lyrics on different lines
+	// make the same exercise
+	return "A:" + strings.ToLower(strings.Join(it.Words, " "))
 }
@@ -21,19 +21,34 @@ export function LessonPanel() {
 	const [summary, setSummary] = useState<Summary | null>(null);
 	const [error, setError] = useState<string | null>(null);
 
+	const postLesson = (choice: { resume?: boolean; restart?: boolean } = {}) =>
+		fetch(`${API_BASE}/lessons`, {
+			method: "POST",
+			headers: { "Content-Type": "application/json" },
+			body: JSON.stringify({ userId, ...choice }),
+		});
+
 	const startLesson = async () => {
 		setBusy(true);
 		setError(null);
 		setSummary(null);
 		try {
-			const res = await fetch(`${API_BASE}/lessons`, {
-				method: "POST",
-				headers: { "Content-Type": "application/json" },
-				body: JSON.stringify({ userId }),
-			});
+			let res = await postLesson();
+			if (res.status === 409) {
+				// an unfinished lesson is waiting; let the user pick
+				const body = await res.clone().json().catch(() => null);
+				if (body?.error?.code === "lesson_open") {
+					const resume = window.confirm(
+						"You have an unfinished lesson. Resume it? Cancel starts a new one.",
+					);
+					res = await postLesson(resume ? { resume: true } : { restart: true });
+				}
+			}
 			const data = await json<{ data: Lesson }>(res);
+			const answered = new Set(data.data.answeredItems ?? []);
+			const next = data.data.items.findIndex((_, i) => !answered.has(i));
 			setLesson(data.data);
-			setIndex(0);
+			setIndex(next < 0 ? 0 : next);
 		} catch (err: any) {
 			setError(err.message);
 		} finally {
@@ -14,6 +14,7 @@ export function SongForm() {
   const [title, setTitle] = useState("");
   const [artist, setArtist] = useState("");
   const [lyrics, setLyrics] = useState("");
+  const [adminToken, setAdminToken] = useState("");
   const [loading, setLoading] = useState(false);
   const [created, setCreated] = useState<{ id: string; lineCount: number } | null>(null);
   const [error, setError] = useState<string | null>(null);
@@ -26,7 +27,7 @@ export function SongForm() {
     try {
       const res = await fetch(`${API_BASE}/songs`, {
         method: "POST",
-        headers: { "Content-Type": "application/json" },
+        headers: { "Content-Type": "application/json", "X-Admin-Token": adminToken },
         body: JSON.stringify({ title, artist, lyrics }),
       });
       const data = await json<{ data: { id: string; lineCount: number } }>(res);
@@ -47,6 +48,7 @@ export function SongForm() {
         <input className="w-full border border-gray-300 rounded-lg px-3 py-2 focus:outline-none focus:ring-2 focus:ring-indigo-500" placeholder="Title" value={title} onChange={(e) => setTitle(e.target.value)} required />
         <input className="w-full border border-gray-300 rounded-lg px-3 py-2 focus:outline-none focus:ring-2 focus:ring-indigo-500" placeholder="Artist" value={artist} onChange={(e) => setArtist(e.target.value)} required />
         <textarea className="w-full border border-gray-300 rounded-lg px-3 py-2 h-40 font-mono focus:outline-none focus:ring-2 focus:ring-indigo-500" placeholder={"Paste lyrics here. One line per lyric line."} value={lyrics} onChange={(e) => setLyrics(e.target.value)} required />
+        <input type="password" className="w-full border border-gray-300 rounded-lg px-3 py-2 focus:outline-none focus:ring-2 focus:ring-indigo-500" placeholder="Admin token" value={adminToken} onChange={(e) => setAdminToken(e.target.value)} required />
         <button type="submit" className="px-4 py-2 rounded-xl bg-indigo-600 hover:bg-indigo-700 text-white shadow focus:outline-none focus:ring-2 focus:ring-indigo-500 disabled:opacity-50" disabled={loading}>
           {loading ? "Creating..." : "Create Song"}
         </button>
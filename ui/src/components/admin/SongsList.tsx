@@ -20,9 +20,18 @@ export function SongsList() {
 		setLoading(true);
 		setError(null);
 		try {
-			const res = await fetch(`${API_BASE}/songs`);
-			const data = await json<{ data: Song[] }>(res);
-			setSongs(data.data);
+			// the API pages lists; follow the cursor to show them all
+			const all: Song[] = [];
+			let cursor: string | undefined;
+			do {
+				const params = new URLSearchParams({ limit: "200" });
+				if (cursor) params.set("cursor", cursor);
+				const res = await fetch(`${API_BASE}/songs?${params}`);
+				const page = await json<{ data: Song[]; meta?: { nextCursor?: string } }>(res);
+				all.push(...page.data);
+				cursor = page.meta?.nextCursor;
+			} while (cursor);
+			setSongs(all);
 		} catch (err: any) {
 			setError(err.message);
 		} finally {
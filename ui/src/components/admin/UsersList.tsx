@@ -20,9 +20,18 @@ export function UsersList() {
 		setLoading(true);
 		setError(null);
 		try {
-			const res = await fetch(`${API_BASE}/users`);
-			const data = await json<{ data: User[] }>(res);
-			setUsers(data.data);
+			// the API pages lists; follow the cursor to show them all
+			const all: User[] = [];
+			let cursor: string | undefined;
+			do {
+				const params = new URLSearchParams({ limit: "200" });
+				if (cursor) params.set("cursor", cursor);
+				const res = await fetch(`${API_BASE}/users?${params}`);
+				const page = await json<{ data: User[]; meta?: { nextCursor?: string } }>(res);
+				all.push(...page.data);
+				cursor = page.meta?.nextCursor;
+			} while (cursor);
+			setUsers(all);
 		} catch (err: any) {
 			setError(err.message);
 		} finally {
@@ -20,6 +20,8 @@ export type LessonItem = LessonItemFill | LessonItemArrange;
 export type Lesson = {
   lessonId: string;
   items: LessonItem[];
+  resumed?: boolean;
+  answeredItems?: number[];
 };
 
 export type Summary = {